@@ -0,0 +1,35 @@
+# The new address must be served by every authoritative nameserver and
+# by all major public resolvers
+resource "terraprobe_dns_propagation_test" "www_cutover" {
+  name          = "WWW Record Propagated"
+  hostname      = "www.example.com"
+  record_type   = "A"
+  expect_result = "192.0.2.20"
+  authoritative = true
+
+  resolvers = [
+    "8.8.8.8",
+    "1.1.1.1",
+    "9.9.9.9",
+    "https://dns.quad9.net/dns-query",
+  ]
+}
+
+# Pass once most resolvers return the same MX records
+resource "terraprobe_dns_propagation_test" "mail_exchangers" {
+  name        = "MX Records Mostly Propagated"
+  hostname    = "example.com"
+  record_type = "MX"
+  resolvers   = ["8.8.8.8", "1.1.1.1", "9.9.9.9", "208.67.222.222"]
+  quorum      = 3
+}
+
+# Output test results
+output "www_cutover" {
+  value = {
+    passed    = terraprobe_dns_propagation_test.www_cutover.test_passed
+    agreeing  = terraprobe_dns_propagation_test.www_cutover.agreeing_count
+    total     = terraprobe_dns_propagation_test.www_cutover.total_count
+    consensus = terraprobe_dns_propagation_test.www_cutover.consensus
+  }
+}
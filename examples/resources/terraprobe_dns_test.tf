@@ -16,6 +16,102 @@ resource "terraprobe_dns_test" "mail_servers" {
   record_type = "MX"
 }
 
+resource "terraprobe_dns_test" "sip_service" {
+  name        = "SIP SRV Record"
+  hostname    = "_sip._tcp.example.com"
+  record_type = "SRV"
+}
+
+resource "terraprobe_dns_test" "certificate_authority" {
+  name          = "CAA Restricts Issuance"
+  hostname      = "example.com"
+  record_type   = "CAA"
+  expect_result = "0 issue \"letsencrypt.org\""
+}
+
+resource "terraprobe_dns_test" "dns_over_tls" {
+  name        = "A Record over DNS-over-TLS"
+  hostname    = "example.com"
+  record_type = "A"
+  resolver    = "tls://dns.google"
+}
+
+resource "terraprobe_dns_test" "dns_over_https" {
+  name        = "A Record over DNS-over-HTTPS"
+  hostname    = "example.com"
+  record_type = "A"
+  resolver    = "https://cloudflare-dns.com/dns-query"
+}
+
+resource "terraprobe_dns_test" "internal_resolver" {
+  name             = "Internal Resolver on a Custom Port"
+  hostname         = "app.internal.example.com"
+  record_type      = "A"
+  resolver         = "[fd00::53]:5353"
+  transport        = "tcp"
+  edns_buffer_size = 4096
+}
+
+resource "terraprobe_dns_test" "migration_cutover" {
+  name           = "Load Balancer Records After Migration"
+  hostname       = "www.example.com"
+  record_type    = "A"
+  expect_exact   = ["192.0.2.10", "192.0.2.11"]
+  expect_ttl_max = 300
+}
+
+resource "terraprobe_dns_test" "spf_record" {
+  name         = "SPF Record Present"
+  hostname     = "example.com"
+  record_type  = "TXT"
+  expect_regex = "^v=spf1 "
+}
+
+resource "terraprobe_dns_test" "cdn" {
+  name                   = "Website Served Through the CDN"
+  hostname               = "www.example.com"
+  record_type            = "A"
+  expect_cname_target    = "cdn.example.net"
+  max_cname_chain_length = 3
+}
+
+resource "terraprobe_dns_test" "mail_fcrdns" {
+  name           = "Mail Server Forward-Confirmed Reverse DNS"
+  hostname       = "mail.example.com"
+  record_type    = "A"
+  verify_reverse = true
+}
+
+resource "terraprobe_dns_test" "decommissioned" {
+  name        = "Legacy API Name Removed"
+  hostname    = "legacy-api.example.com"
+  record_type = "A"
+  expect      = "nxdomain"
+}
+
+resource "terraprobe_dns_test" "dnssec" {
+  name            = "DNSSEC Chain of Trust"
+  hostname        = "example.com"
+  record_type     = "A"
+  resolver        = "1.1.1.1"
+  validate_dnssec = true
+}
+
+output "dnssec" {
+  value = {
+    valid                   = terraprobe_dns_test.dnssec.last_dnssec_valid
+    authenticated_data      = terraprobe_dns_test.dnssec.last_authenticated_data
+    rrsig_days_until_expiry = terraprobe_dns_test.dnssec.last_rrsig_days_until_expiry
+  }
+}
+
+output "sip_targets" {
+  value = [
+    for record in terraprobe_dns_test.sip_service.last_records :
+    "${record.fields.target}:${record.fields.port}"
+  ]
+}
+
 output "dns_test_results" {
   value = {
     passed         = terraprobe_dns_test.example_a_record.test_passed
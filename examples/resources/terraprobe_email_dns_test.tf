@@ -0,0 +1,30 @@
+# Mail for the domain must pass SPF, DKIM and DMARC, and receivers must
+# enforce TLS through MTA-STS
+resource "terraprobe_email_dns_test" "example_com" {
+  name            = "Example.com Mail Authentication"
+  domain          = "example.com"
+  expect_spf_all  = "-all"
+  dmarc_policy    = "reject"
+  dkim_selectors  = ["google", "s1"]
+  require_mta_sts = true
+  require_tls_rpt = true
+}
+
+# Keep room for another provider's include in the SPF record
+resource "terraprobe_email_dns_test" "marketing" {
+  name            = "Marketing Domain SPF Headroom"
+  domain          = "news.example.com"
+  spf_max_lookups = 8
+  dmarc_policy    = "quarantine"
+  resolver        = "1.1.1.1"
+}
+
+# Output test results
+output "example_com_mail" {
+  value = {
+    passed       = terraprobe_email_dns_test.example_com.test_passed
+    spf_lookups  = terraprobe_email_dns_test.example_com.last_spf_lookup_count
+    dmarc_policy = terraprobe_email_dns_test.example_com.last_dmarc_policy
+    error        = terraprobe_email_dns_test.example_com.error
+  }
+}
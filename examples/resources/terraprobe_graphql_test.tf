@@ -0,0 +1,45 @@
+resource "terraprobe_graphql_test" "user_lookup" {
+  name = "GraphQL User Lookup"
+  url  = "https://api.example.com/graphql"
+
+  query = <<-EOT
+    query GetUser($id: ID!) {
+      user(id: $id) {
+        id
+        name
+      }
+    }
+  EOT
+
+  variables = {
+    id = "42"
+  }
+
+  bearer_token = var.api_token
+
+  # JSONPath assertions evaluated against the response data
+  expect_data = {
+    "$.user.id"   = "42"
+    "$.user.name" = "smoke-test"
+  }
+}
+
+# Verify that a deployment exposes the expected schema
+resource "terraprobe_graphql_test" "schema" {
+  name  = "GraphQL Schema Check"
+  url   = "https://api.example.com/graphql"
+  query = "{ __typename }"
+
+  expect_types  = ["User", "Order"]
+  expect_fields = ["Query.user", "User.email"]
+}
+
+# Output test results
+output "graphql_test_results" {
+  value = {
+    passed           = terraprobe_graphql_test.user_lookup.test_passed
+    response_time_ms = terraprobe_graphql_test.user_lookup.last_response_time
+    errors           = terraprobe_graphql_test.user_lookup.last_errors
+    error            = terraprobe_graphql_test.user_lookup.error
+  }
+}
@@ -0,0 +1,46 @@
+# Standard health check for a single service
+resource "terraprobe_grpc_test" "orders_health" {
+  name    = "Orders Service Health"
+  address = "orders.internal.example.com:50051"
+  service = "orders.v1.OrderService"
+}
+
+# Invoke a unary method described via server reflection
+resource "terraprobe_grpc_test" "get_order" {
+  name    = "Get Order"
+  address = "api.example.com:443"
+  use_tls = true
+
+  method       = "orders.v1.OrderService/GetOrder"
+  request_json = jsonencode({ id = "smoke-test" })
+
+  metadata = {
+    authorization = "Bearer ${var.api_token}"
+  }
+
+  # JSONPath assertions evaluated against the JSON encoded response
+  expect_response = {
+    "$.order.id"     = "smoke-test"
+    "$.order.status" = "OPEN"
+  }
+}
+
+# Use a descriptor set when the server does not expose reflection
+resource "terraprobe_grpc_test" "missing_order" {
+  name                = "Missing Order"
+  address             = "orders.internal.example.com:50051"
+  method              = "orders.v1.OrderService/GetOrder"
+  descriptor_set_file = "${path.module}/orders.binpb"
+  request_json        = jsonencode({ id = "does-not-exist" })
+  expect_code         = "NOT_FOUND"
+}
+
+# Output test results
+output "grpc_test_results" {
+  value = {
+    passed           = terraprobe_grpc_test.orders_health.test_passed
+    status           = terraprobe_grpc_test.orders_health.last_status
+    response_time_ms = terraprobe_grpc_test.orders_health.last_response_time
+    error            = terraprobe_grpc_test.orders_health.error
+  }
+}
@@ -31,6 +31,78 @@ resource "terraprobe_http_test" "load_balancer_check" {
   depends_on = [aws_lb.example]
 }
 
+# Verify that the load balancer negotiates HTTP/2
+resource "terraprobe_http_test" "load_balancer_http2" {
+  name = "Load Balancer HTTP/2"
+  url  = "https://${aws_lb.example.dns_name}/health"
+
+  http_version    = "2"
+  expect_protocol = "HTTP/2.0"
+}
+
+# Exercise an upload endpoint without hand-building the payload
+resource "terraprobe_http_test" "upload" {
+  name   = "Report Upload"
+  url    = "https://api.example.com/reports"
+  method = "POST"
+
+  multipart = [
+    {
+      name  = "title"
+      value = "Nightly report"
+    },
+    {
+      name         = "file"
+      file         = "${path.module}/fixtures/report.csv"
+      content_type = "text/csv"
+    },
+  ]
+
+  expect_status_code = 201
+}
+
+# Send an object as JSON
+resource "terraprobe_http_test" "create_user" {
+  name   = "Create User"
+  url    = "https://api.example.com/users"
+  method = "POST"
+
+  json_body = {
+    name  = "smoke-test"
+    roles = ["reader"]
+  }
+  compress_body = true
+
+  expect_status_code = 201
+}
+
+# Query a local admin API that only listens on a unix socket
+resource "terraprobe_http_test" "docker_ping" {
+  name        = "Docker Engine Ping"
+  url         = "http://localhost/_ping"
+  unix_socket = "/var/run/docker.sock"
+
+  expect_contains = "OK"
+}
+
+# Hit a specific backend while sending the public Host header
+resource "terraprobe_http_test" "backend_direct" {
+  name       = "Backend Direct Check"
+  url        = "https://www.example.com/health"
+  connect_to = "10.0.1.15:443"
+}
+
+# Watch a Server-Sent Events feed for a few seconds
+resource "terraprobe_http_test" "deploy_events" {
+  name   = "Deployment Event Stream"
+  url    = "https://api.example.com/events"
+  stream = "sse"
+
+  stream_duration      = 5
+  expect_min_events    = 1
+  expect_event_matches = "\"status\":\\s*\"healthy\""
+}
+
 # Output test results
 output "api_test_results" {
   value = {
@@ -0,0 +1,27 @@
+# Every node of the cluster must accept connections on the service ports
+resource "terraprobe_tcp_matrix_test" "cluster_nodes" {
+  name  = "Cluster Node Ports"
+  hosts = aws_instance.node[*].private_ip
+  ports = ["22", "9090", "10250-10252"]
+
+  concurrency = 20
+  timeout     = 3
+}
+
+# None of the database ports may be reachable from this network
+resource "terraprobe_tcp_matrix_test" "databases_private" {
+  name    = "Databases Not Public"
+  hosts   = ["db-1.example.com", "db-2.example.com"]
+  ports   = ["3306", "5432"]
+  expect  = "filtered"
+  timeout = 3
+}
+
+# Output test results
+output "cluster_node_ports" {
+  value = {
+    passed           = terraprobe_tcp_matrix_test.cluster_nodes.test_passed
+    passed_count     = terraprobe_tcp_matrix_test.cluster_nodes.passed_count
+    failed_endpoints = terraprobe_tcp_matrix_test.cluster_nodes.failed_endpoints
+  }
+}
@@ -19,6 +19,51 @@ resource "terraprobe_tcp_test" "redis_connection" {
   depends_on = [aws_elasticache_cluster.redis]
 }
 
+# Verify that Redis answers, not just that the port is open
+resource "terraprobe_tcp_test" "redis_ping" {
+  name            = "Redis PING"
+  host            = "redis.example.com"
+  port            = 6379
+  send            = "PING\r\n"
+  expect_response = "+PONG"
+  read_timeout    = 2
+}
+
+# Match the SSH version string sent on connect
+resource "terraprobe_tcp_test" "ssh_banner" {
+  name          = "SSH Banner"
+  host          = "bastion.example.com"
+  port          = 22
+  expect_banner = "^SSH-2\\.0-OpenSSH_9\\."
+  match_mode    = "regex"
+}
+
+# Prove the database is not reachable from the public subnet
+resource "terraprobe_tcp_test" "db_not_public" {
+  name    = "Database Not Publicly Reachable"
+  host    = "db.example.com"
+  port    = 5432
+  expect  = "filtered"
+  timeout = 3
+}
+
+# Check that the load balancer answers over both IPv4 and IPv6, on every address
+resource "terraprobe_tcp_test" "dual_stack" {
+  name               = "Dual-Stack Load Balancer"
+  host               = "www.example.com"
+  port               = 443
+  ip_version         = "both"
+  test_all_addresses = true
+}
+
+# Verify the partner allowlist from the CI runner's egress interface
+resource "terraprobe_tcp_test" "partner_allowlist" {
+  name           = "Partner API Allowlist"
+  host           = "api.partner.example.com"
+  port           = 443
+  source_address = "203.0.113.10"
+}
+
 # Output test results
 output "database_connection_test" {
   value = {
@@ -0,0 +1,42 @@
+# Check the HTTPS endpoint only negotiates modern TLS and renews in time
+resource "terraprobe_tls_test" "website" {
+  name                  = "Website TLS"
+  host                  = "www.example.com"
+  port                  = 443
+  allowed_versions      = ["1.2", "1.3"]
+  min_days_until_expiry = 21
+}
+
+# Mail submission upgraded via STARTTLS
+resource "terraprobe_tls_test" "smtp" {
+  name                  = "SMTP STARTTLS"
+  host                  = "mail.example.com"
+  port                  = 587
+  starttls              = "smtp"
+  min_days_until_expiry = 14
+}
+
+# PostgreSQL with a private CA and a restricted set of cipher suites
+resource "terraprobe_tls_test" "postgres" {
+  name     = "PostgreSQL TLS"
+  host     = "db.example.com"
+  port     = 5432
+  starttls = "postgres"
+  ca_cert  = file("${path.module}/certs/db-ca.pem")
+  allowed_cipher_suites = [
+    "TLS_AES_128_GCM_SHA256",
+    "TLS_AES_256_GCM_SHA384",
+    "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+  ]
+}
+
+# Output test results
+output "website_tls" {
+  value = {
+    passed            = terraprobe_tls_test.website.test_passed
+    version           = terraprobe_tls_test.website.last_version
+    cipher_suite      = terraprobe_tls_test.website.last_cipher_suite
+    days_until_expiry = terraprobe_tls_test.website.last_days_until_expiry
+    issuer            = terraprobe_tls_test.website.last_certificate_chain[0].issuer
+  }
+}
@@ -0,0 +1,30 @@
+# Query a DNS server with a raw query for example.com/A
+resource "terraprobe_udp_test" "dns_server" {
+  name            = "Internal DNS Server"
+  host            = "10.0.0.2"
+  port            = 53
+  send            = "12 34 01 00 00 01 00 00 00 00 00 00 07 65 78 61 6d 70 6c 65 03 63 6f 6d 00 00 01 00 01"
+  send_encoding   = "hex"
+  expect_response = "^\\x12\\x34"
+  match_mode      = "regex"
+  read_timeout    = 2
+  retries         = 2
+}
+
+# StatsD never replies; the test fails if the port is reported unreachable
+resource "terraprobe_udp_test" "statsd" {
+  name         = "StatsD"
+  host         = "statsd.example.com"
+  port         = 8125
+  send         = "terraprobe.deploys:1|c"
+  read_timeout = 1
+}
+
+# Output test results
+output "dns_server_test" {
+  value = {
+    passed           = terraprobe_udp_test.dns_server.test_passed
+    response_time_ms = terraprobe_udp_test.dns_server.last_response_time
+    error            = terraprobe_udp_test.dns_server.error
+  }
+}
@@ -0,0 +1,31 @@
+resource "terraprobe_websocket_test" "realtime_gateway" {
+  name = "Realtime Gateway"
+  url  = "wss://realtime.example.com/socket"
+
+  subprotocols       = ["graphql-transport-ws"]
+  expect_subprotocol = "graphql-transport-ws"
+  bearer_token       = var.api_token
+
+  messages = [
+    { data = jsonencode({ type = "connection_init" }) },
+    { data = jsonencode({ id = "1", type = "ping" }) },
+  ]
+
+  # Expectations are matched in order against the received messages
+  expect_messages = [
+    { json_path = { "$.type" = "connection_ack" } },
+    { regex = "\"type\"\\s*:\\s*\"pong\"" },
+  ]
+
+  timeout = 10
+}
+
+# Output test results
+output "websocket_test_results" {
+  value = {
+    passed            = terraprobe_websocket_test.realtime_gateway.test_passed
+    handshake_time_ms = terraprobe_websocket_test.realtime_gateway.last_handshake_time
+    round_trip_ms     = terraprobe_websocket_test.realtime_gateway.last_round_trip_time
+    error             = terraprobe_websocket_test.realtime_gateway.error
+  }
+}
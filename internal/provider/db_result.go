@@ -0,0 +1,289 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultDbMaxResultRows is the number of rows kept in last_result unless
+// max_result_rows is set.
+const defaultDbMaxResultRows = 10
+
+const (
+	dbOperatorEq       = "eq"
+	dbOperatorNe       = "ne"
+	dbOperatorGt       = "gt"
+	dbOperatorGte      = "gte"
+	dbOperatorLt       = "lt"
+	dbOperatorLte      = "lte"
+	dbOperatorContains = "contains"
+	dbOperatorRegex    = "regex"
+	dbOperatorNull     = "null"
+	dbOperatorNotNull  = "not_null"
+)
+
+// DbRowAssertionModel describes an assertion on a column of the result rows.
+type DbRowAssertionModel struct {
+	Row      types.Int64  `tfsdk:"row"`
+	Column   types.String `tfsdk:"column"`
+	Operator types.String `tfsdk:"operator"`
+	Value    types.String `tfsdk:"value"`
+}
+
+// dbRowAssertion is a validated row assertion. A negative row applies the
+// assertion to every row.
+type dbRowAssertion struct {
+	row      int64
+	column   string
+	operator string
+	value    string
+	regex    *regexp.Regexp
+}
+
+// parseDbRowAssertions reads and validates the row_assertions attribute.
+func parseDbRowAssertions(ctx context.Context, list types.List) ([]dbRowAssertion, error) {
+	if list.IsNull() || list.IsUnknown() {
+		return nil, nil
+	}
+
+	var models []DbRowAssertionModel
+	if diags := list.ElementsAs(ctx, &models, false); diags.HasError() {
+		return nil, fmt.Errorf("failed to read row_assertions")
+	}
+
+	assertions := make([]dbRowAssertion, 0, len(models))
+	for _, model := range models {
+		assertion := dbRowAssertion{
+			row:      -1,
+			column:   model.Column.ValueString(),
+			operator: model.Operator.ValueString(),
+			value:    model.Value.ValueString(),
+		}
+		if assertion.operator == "" {
+			assertion.operator = dbOperatorEq
+		}
+		if !model.Row.IsNull() {
+			if model.Row.ValueInt64() < 0 {
+				return nil, fmt.Errorf("row_assertions row must not be negative")
+			}
+			assertion.row = model.Row.ValueInt64()
+		}
+
+		switch assertion.operator {
+		case dbOperatorNull, dbOperatorNotNull:
+		case dbOperatorEq, dbOperatorNe, dbOperatorGt, dbOperatorGte, dbOperatorLt, dbOperatorLte, dbOperatorContains:
+			if model.Value.IsNull() {
+				return nil, fmt.Errorf("row_assertions on column %s: operator %s requires a value", assertion.column, assertion.operator)
+			}
+		case dbOperatorRegex:
+			regex, err := regexp.Compile(assertion.value)
+			if err != nil {
+				return nil, fmt.Errorf("row_assertions on column %s: invalid regex: %w", assertion.column, err)
+			}
+			assertion.regex = regex
+		default:
+			return nil, fmt.Errorf("unsupported row_assertions operator %q, must be one of: eq, ne, gt, gte, lt, lte, contains, regex, null, not_null", assertion.operator)
+		}
+
+		assertions = append(assertions, assertion)
+	}
+	return assertions, nil
+}
+
+// dbResult holds what was read from a query result: the row count, the
+// first value, the first rows for last_result and the first failure of
+// each row assertion.
+type dbResult struct {
+	columns    []string
+	count      int64
+	firstValue any
+	rows       []map[string]any
+	failures   []string
+}
+
+// readDbResult reads every row of a result, keeping the first maxRows rows
+// and checking the row assertions against each row.
+func readDbResult(rows *sql.Rows, maxRows int64, assertions []dbRowAssertion) (*dbResult, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dbResult{
+		columns:  columns,
+		rows:     []map[string]any{},
+		failures: make([]string, len(assertions)),
+	}
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			values[i] = dbJsonValue(value)
+		}
+
+		if result.count == 0 && len(values) > 0 {
+			result.firstValue = values[0]
+		}
+		if result.count < maxRows {
+			row := make(map[string]any, len(columns))
+			for i, column := range columns {
+				row[column] = values[i]
+			}
+			result.rows = append(result.rows, row)
+		}
+
+		for i, assertion := range assertions {
+			if result.failures[i] != "" || (assertion.row >= 0 && assertion.row != result.count) {
+				continue
+			}
+			if err := assertion.check(columns, values); err != nil {
+				result.failures[i] = fmt.Sprintf("row %d: %s", result.count, err)
+			}
+		}
+
+		result.count++
+	}
+
+	return result, rows.Err()
+}
+
+// assertionFailures returns the first failure of each row assertion,
+// including assertions on rows that were not returned.
+func (r *dbResult) assertionFailures(assertions []dbRowAssertion) []string {
+	var failures []string
+	for i, assertion := range assertions {
+		switch {
+		case r.failures[i] != "":
+			failures = append(failures, r.failures[i])
+		case assertion.row >= r.count:
+			failures = append(failures, fmt.Sprintf("row %d on column %s: the query returned %d rows", assertion.row, assertion.column, r.count))
+		}
+	}
+	return failures
+}
+
+// check checks the assertion against the values of a row.
+func (a dbRowAssertion) check(columns []string, values []any) error {
+	index := slices.Index(columns, a.column)
+	if index < 0 {
+		index = slices.IndexFunc(columns, func(column string) bool { return strings.EqualFold(column, a.column) })
+	}
+	if index < 0 {
+		return fmt.Errorf("column %s not found in result (columns: %s)", a.column, strings.Join(columns, ", "))
+	}
+
+	value := values[index]
+	switch a.operator {
+	case dbOperatorNull:
+		if value != nil {
+			return fmt.Errorf("expected %s to be NULL, got %q", a.column, dbValueString(value))
+		}
+		return nil
+	case dbOperatorNotNull:
+		if value == nil {
+			return fmt.Errorf("expected %s not to be NULL", a.column)
+		}
+		return nil
+	}
+
+	if value == nil {
+		return fmt.Errorf("expected %s %s %q, got NULL", a.column, a.operator, a.value)
+	}
+	actual := dbValueString(value)
+
+	var matched bool
+	switch a.operator {
+	case dbOperatorEq:
+		matched = dbValuesEqual(actual, a.value)
+	case dbOperatorNe:
+		matched = !dbValuesEqual(actual, a.value)
+	case dbOperatorContains:
+		matched = strings.Contains(actual, a.value)
+	case dbOperatorRegex:
+		matched = a.regex.MatchString(actual)
+	default:
+		actualNumber, err := strconv.ParseFloat(actual, 64)
+		if err != nil {
+			return fmt.Errorf("expected %s %s %s, got non-numeric value %q", a.column, a.operator, a.value, actual)
+		}
+		expectedNumber, err := strconv.ParseFloat(a.value, 64)
+		if err != nil {
+			return fmt.Errorf("cannot compare %s with non-numeric value %q", a.column, a.value)
+		}
+		switch a.operator {
+		case dbOperatorGt:
+			matched = actualNumber > expectedNumber
+		case dbOperatorGte:
+			matched = actualNumber >= expectedNumber
+		case dbOperatorLt:
+			matched = actualNumber < expectedNumber
+		case dbOperatorLte:
+			matched = actualNumber <= expectedNumber
+		}
+	}
+
+	if !matched {
+		return fmt.Errorf("expected %s %s %q, got %q", a.column, a.operator, a.value, actual)
+	}
+	return nil
+}
+
+// dbJsonValue converts a value scanned from a row to a value that encodes
+// naturally as JSON. Drivers return text columns, and for MySQL every
+// column, as bytes.
+func dbJsonValue(value any) any {
+	switch v := value.(type) {
+	case nil, bool, int64, float64, string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// dbValueString renders a converted value for comparisons and messages.
+func dbValueString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// dbValuesEqual compares two values numerically when both are numbers and
+// as strings otherwise, so that 1.0 equals 1.
+func dbValuesEqual(actual, expected string) bool {
+	if actual == expected {
+		return true
+	}
+	actualNumber, err := strconv.ParseFloat(actual, 64)
+	if err != nil {
+		return false
+	}
+	expectedNumber, err := strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return false
+	}
+	return actualNumber == expectedNumber
+}
@@ -3,12 +3,25 @@ package provider
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	// Database drivers.
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/lib/pq"
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	mssql "github.com/microsoft/go-mssqldb"
+	"modernc.org/sqlite"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -50,17 +63,41 @@ type DbTestResourceModel struct {
 	Id         types.String `tfsdk:"id"`
 
 	// Additional connection options
-	SSLMode     types.String `tfsdk:"ssl_mode"`
-	MaxLifetime types.Int64  `tfsdk:"max_lifetime"`
-	MaxIdleConn types.Int64  `tfsdk:"max_idle_conn"`
-	MaxOpenConn types.Int64  `tfsdk:"max_open_conn"`
+	SSLMode         types.String `tfsdk:"ssl_mode"`
+	Dsn             types.String `tfsdk:"dsn"`
+	MysqlOptions    types.Map    `tfsdk:"mysql_options"`
+	PostgresOptions types.Map    `tfsdk:"postgres_options"`
+	MaxLifetime     types.Int64  `tfsdk:"max_lifetime"`
+	MaxIdleConn     types.Int64  `tfsdk:"max_idle_conn"`
+	MaxOpenConn     types.Int64  `tfsdk:"max_open_conn"`
+	SourceAddress   types.String `tfsdk:"source_address"`
+
+	// Result expectations
+	ExpectRows    types.Int64  `tfsdk:"expect_rows"`
+	ExpectMinRows types.Int64  `tfsdk:"expect_min_rows"`
+	ExpectMaxRows types.Int64  `tfsdk:"expect_max_rows"`
+	ExpectValue   types.String `tfsdk:"expect_value"`
+	RowAssertions types.List   `tfsdk:"row_assertions"`
+	MaxResultRows types.Int64  `tfsdk:"max_result_rows"`
 
 	// Results
-	LastRun        types.String `tfsdk:"last_run"`
-	LastQueryTime  types.Int64  `tfsdk:"last_query_time"`
-	LastResultRows types.Int64  `tfsdk:"last_result_rows"`
-	TestPassed     types.Bool   `tfsdk:"test_passed"`
-	Error          types.String `tfsdk:"error"`
+	LastRun          types.String `tfsdk:"last_run"`
+	LastQueryTime    types.Int64  `tfsdk:"last_query_time"`
+	LastResultRows   types.Int64  `tfsdk:"last_result_rows"`
+	LastResult       types.String `tfsdk:"last_result"`
+	LastLocalAddress types.String `tfsdk:"last_local_address"`
+	TestPassed       types.Bool   `tfsdk:"test_passed"`
+	Error            types.String `tfsdk:"error"`
+}
+
+// dbDefaultPorts are the ports used for each database type when port is not
+// set. SQLite databases are files and have no port.
+var dbDefaultPorts = map[string]int64{
+	"mysql":      3306,
+	"postgres":   5432,
+	"sqlserver":  1433,
+	"clickhouse": 9000,
+	"sqlite":     0,
 }
 
 func (r *DbTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -77,30 +114,45 @@ func (r *DbTestResource) Schema(ctx context.Context, req resource.SchemaRequest,
 				Required:            true,
 			},
 			"type": schema.StringAttribute{
-				MarkdownDescription: "Type of database (mysql, postgres)",
+				MarkdownDescription: "Type of database (mysql, postgres, sqlserver, clickhouse, sqlite). Use mysql or postgres for compatible databases such as TiDB, Aurora or CockroachDB.",
 				Required:            true,
 			},
 			"host": schema.StringAttribute{
-				MarkdownDescription: "Database host to connect to",
-				Required:            true,
+				MarkdownDescription: "Database host to connect to. Required unless `dsn` is set or `type` is sqlite.",
+				Optional:            true,
 			},
 			"port": schema.Int64Attribute{
-				MarkdownDescription: "Database port",
-				Required:            true,
+				MarkdownDescription: "Database port. Defaults to 3306 for mysql, 5432 for postgres, 1433 for sqlserver and 9000 for clickhouse.",
+				Optional:            true,
 			},
 			"username": schema.StringAttribute{
 				MarkdownDescription: "Database username",
-				Required:            true,
+				Optional:            true,
 				Sensitive:           true,
 			},
 			"password": schema.StringAttribute{
 				MarkdownDescription: "Database password",
-				Required:            true,
+				Optional:            true,
 				Sensitive:           true,
 			},
 			"database": schema.StringAttribute{
-				MarkdownDescription: "Database name to connect to",
-				Required:            true,
+				MarkdownDescription: "Database name to connect to, or the path of the database file for sqlite",
+				Optional:            true,
+			},
+			"dsn": schema.StringAttribute{
+				MarkdownDescription: "Connection string in the driver's own format, used instead of `host`, `port`, `username`, `password`, `database` and `ssl_mode` (e.g., `user:pass@tcp(db:3306)/app?tls=true`, `postgres://user:pass@db:26257/app?sslmode=verify-full`, `sqlserver://user:pass@db:1433?database=app`, `clickhouse://user:pass@db:9000/app` or a file path for sqlite)",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"mysql_options": schema.MapAttribute{
+				MarkdownDescription: "MySQL connection parameters added to the connection string (e.g., `tls`, `parseTime`, `charset`, `collation`, `readTimeout`)",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"postgres_options": schema.MapAttribute{
+				MarkdownDescription: "PostgreSQL connection parameters added to the connection string (e.g., `application_name`, `search_path`, `sslrootcert`, `statement_timeout`). Parameters unknown to the driver are sent to the server as run-time settings.",
+				ElementType:         types.StringType,
+				Optional:            true,
 			},
 			"query": schema.StringAttribute{
 				MarkdownDescription: "SQL query to execute (default: SELECT 1)",
@@ -127,7 +179,7 @@ func (r *DbTestResource) Schema(ctx context.Context, req resource.SchemaRequest,
 				Default:             int64default.StaticInt64(0), // 0 means use provider default
 			},
 			"ssl_mode": schema.StringAttribute{
-				MarkdownDescription: "SSL mode for the database connection (disable, require, verify-ca, verify-full)",
+				MarkdownDescription: "SSL mode for postgres, sqlserver and clickhouse connections (disable, require, verify-ca, verify-full). `require` encrypts without verifying the server certificate.",
 				Optional:            true,
 				Computed:            true,
 				Default:             stringdefault.StaticString("disable"),
@@ -150,6 +202,55 @@ func (r *DbTestResource) Schema(ctx context.Context, req resource.SchemaRequest,
 				Computed:            true,
 				Default:             int64default.StaticInt64(5),
 			},
+			"source_address": sourceAddressSchemaAttribute(),
+			"expect_rows": schema.Int64Attribute{
+				MarkdownDescription: "Exact number of rows the query must return",
+				Optional:            true,
+			},
+			"expect_min_rows": schema.Int64Attribute{
+				MarkdownDescription: "Minimum number of rows the query must return",
+				Optional:            true,
+			},
+			"expect_max_rows": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of rows the query may return",
+				Optional:            true,
+			},
+			"expect_value": schema.StringAttribute{
+				MarkdownDescription: "Expected value of the first column of the first row, e.g. for `SELECT count(*) FROM users`. Numbers are compared numerically.",
+				Optional:            true,
+			},
+			"row_assertions": schema.ListNestedAttribute{
+				MarkdownDescription: "Assertions on named columns of the result rows",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"row": schema.Int64Attribute{
+							MarkdownDescription: "Index of the row to check, starting at 0. Without it, every row must satisfy the assertion.",
+							Optional:            true,
+						},
+						"column": schema.StringAttribute{
+							MarkdownDescription: "Name of the column to check",
+							Required:            true,
+						},
+						"operator": schema.StringAttribute{
+							MarkdownDescription: "Comparison operator: `eq`, `ne`, `gt`, `gte`, `lt`, `lte` (numbers are compared numerically), `contains`, `regex`, `null` or `not_null`",
+							Optional:            true,
+							Computed:            true,
+							Default:             stringdefault.StaticString(dbOperatorEq),
+						},
+						"value": schema.StringAttribute{
+							MarkdownDescription: "Value to compare the column with. Not used by `null` and `not_null`.",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"max_result_rows": schema.Int64Attribute{
+				MarkdownDescription: "Number of rows included in `last_result`",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(defaultDbMaxResultRows),
+			},
 
 			// Results - these are computed values based on the last test run
 			"last_run": schema.StringAttribute{
@@ -164,6 +265,14 @@ func (r *DbTestResource) Schema(ctx context.Context, req resource.SchemaRequest,
 				MarkdownDescription: "Number of rows returned by the query",
 				Computed:            true,
 			},
+			"last_result": schema.StringAttribute{
+				MarkdownDescription: "The first `max_result_rows` rows of the result as a JSON array of objects keyed by column name",
+				Computed:            true,
+			},
+			"last_local_address": schema.StringAttribute{
+				MarkdownDescription: "Local address (`ip:port`) of the last database connection opened in the last test run",
+				Computed:            true,
+			},
 			"test_passed": schema.BoolAttribute{
 				MarkdownDescription: "Whether the test passed",
 				Computed:            true,
@@ -320,31 +429,57 @@ func (r *DbTestResource) runTest(ctx context.Context, data *DbTestResourceModel)
 		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
 	}
 
-	// Create a database connection string based on the database type
-	var connStr string
 	dbType := data.Type.ValueString()
-
-	switch dbType {
-	case "mysql":
-		connStr = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
-			data.Username.ValueString(),
-			data.Password.ValueString(),
-			data.Host.ValueString(),
-			data.Port.ValueInt64(),
-			data.Database.ValueString())
-	case "postgres":
-		sslMode := data.SSLMode.ValueString()
-		connStr = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-			data.Host.ValueString(),
-			data.Port.ValueInt64(),
-			data.Username.ValueString(),
-			data.Password.ValueString(),
-			data.Database.ValueString(),
-			sslMode)
-	default:
+	if _, ok := dbDefaultPorts[dbType]; !ok {
 		return fmt.Errorf("unsupported database type: %s", dbType)
 	}
 
+	// Read the engine specific options
+	mysqlOptions := make(map[string]string)
+	if !data.MysqlOptions.IsNull() {
+		if dbType != "mysql" {
+			return fmt.Errorf("mysql_options can only be used with type mysql")
+		}
+		if diags := data.MysqlOptions.ElementsAs(ctx, &mysqlOptions, false); diags.HasError() {
+			return fmt.Errorf("failed to read mysql_options")
+		}
+	}
+	postgresOptions := make(map[string]string)
+	if !data.PostgresOptions.IsNull() {
+		if dbType != "postgres" {
+			return fmt.Errorf("postgres_options can only be used with type postgres")
+		}
+		if diags := data.PostgresOptions.ElementsAs(ctx, &postgresOptions, false); diags.HasError() {
+			return fmt.Errorf("failed to read postgres_options")
+		}
+	}
+
+	// Read the result expectations
+	maxResultRows := int64(defaultDbMaxResultRows)
+	if !data.MaxResultRows.IsNull() {
+		maxResultRows = data.MaxResultRows.ValueInt64()
+	}
+	if maxResultRows < 0 {
+		return fmt.Errorf("max_result_rows must not be negative")
+	}
+	assertions, err := parseDbRowAssertions(ctx, data.RowAssertions)
+	if err != nil {
+		return err
+	}
+
+	// Create a database connection string based on the database type
+	connStr, err := dbConnectionString(dbType, data, mysqlOptions, postgresOptions)
+	if err != nil {
+		return err
+	}
+
+	// Dial connections through our own dialer to bind and observe the local address
+	sourceAddress, err := parseSourceAddress(data.SourceAddress.ValueString())
+	if err != nil {
+		return err
+	}
+	dialer := &dbDialer{dialer: newSourceDialer("tcp", sourceAddress, timeout)}
+
 	// Create a context with timeout for the database operations
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -352,12 +487,13 @@ func (r *DbTestResource) runTest(ctx context.Context, data *DbTestResourceModel)
 	// Perform the database connection and query with retries
 	var db *sql.DB
 	var openErr error
-	var rowCount int64
+	var result *dbResult
 	var queryTime time.Duration
 
 	for i := int64(0); i <= retries; i++ {
 		// Open the database connection
-		db, openErr = sql.Open(dbType, connStr)
+		var connector driver.Connector
+		connector, openErr = newDbConnector(dbType, connStr, dialer)
 		if openErr != nil {
 			if i < retries {
 				time.Sleep(retryDelay)
@@ -365,6 +501,7 @@ func (r *DbTestResource) runTest(ctx context.Context, data *DbTestResourceModel)
 			}
 			break
 		}
+		db = sql.OpenDB(connector)
 
 		// Configure the connection pool
 		if !data.MaxLifetime.IsNull() && data.MaxLifetime.ValueInt64() > 0 {
@@ -405,14 +542,9 @@ func (r *DbTestResource) runTest(ctx context.Context, data *DbTestResourceModel)
 			break
 		}
 
-		// Count the rows
-		rowCount = 0
-		for rows.Next() {
-			rowCount++
-		}
-
-		// Check for errors during row iteration
-		rowErr := rows.Err()
+		// Read the rows, checking the row assertions as we go
+		var rowErr error
+		result, rowErr = readDbResult(rows, maxResultRows, assertions)
 		_ = rows.Close()
 
 		if rowErr != nil {
@@ -431,20 +563,291 @@ func (r *DbTestResource) runTest(ctx context.Context, data *DbTestResourceModel)
 		break
 	}
 
+	data.LastLocalAddress = types.StringValue(dialer.lastLocalAddress())
+
 	// Handle errors
 	if openErr != nil {
 		data.Error = types.StringValue(fmt.Sprintf("Database test failed: %s", openErr.Error()))
 		data.TestPassed = types.BoolValue(false)
 		data.LastQueryTime = types.Int64Value(0)
 		data.LastResultRows = types.Int64Value(0)
+		data.LastResult = types.StringValue("")
 		return nil // Don't return error as we want to keep the error in the state
 	}
 
+	lastResult, err := json.Marshal(result.rows)
+	if err != nil {
+		return fmt.Errorf("failed to encode the query result: %w", err)
+	}
+
 	// Update the results
 	data.LastQueryTime = types.Int64Value(int64(queryTime / time.Millisecond))
-	data.LastResultRows = types.Int64Value(rowCount)
-	data.TestPassed = types.BoolValue(true)
-	data.Error = types.StringValue("")
+	data.LastResultRows = types.Int64Value(result.count)
+	data.LastResult = types.StringValue(string(lastResult))
+
+	// Check the expectations against the result
+	var errorMsg strings.Builder
+	if !data.ExpectRows.IsNull() && result.count != data.ExpectRows.ValueInt64() {
+		errorMsg.WriteString(fmt.Sprintf("Expected %d rows, got %d. ", data.ExpectRows.ValueInt64(), result.count))
+	}
+	if !data.ExpectMinRows.IsNull() && result.count < data.ExpectMinRows.ValueInt64() {
+		errorMsg.WriteString(fmt.Sprintf("Expected at least %d rows, got %d. ", data.ExpectMinRows.ValueInt64(), result.count))
+	}
+	if !data.ExpectMaxRows.IsNull() && result.count > data.ExpectMaxRows.ValueInt64() {
+		errorMsg.WriteString(fmt.Sprintf("Expected at most %d rows, got %d. ", data.ExpectMaxRows.ValueInt64(), result.count))
+	}
+	if !data.ExpectValue.IsNull() {
+		switch {
+		case result.count == 0 || len(result.columns) == 0:
+			errorMsg.WriteString(fmt.Sprintf("Expected value %q, but the query returned no rows. ", data.ExpectValue.ValueString()))
+		case result.firstValue == nil:
+			errorMsg.WriteString(fmt.Sprintf("Expected value %q, got NULL. ", data.ExpectValue.ValueString()))
+		case !dbValuesEqual(dbValueString(result.firstValue), data.ExpectValue.ValueString()):
+			errorMsg.WriteString(fmt.Sprintf("Expected value %q, got %q. ", data.ExpectValue.ValueString(), dbValueString(result.firstValue)))
+		}
+	}
+	for _, failure := range result.assertionFailures(assertions) {
+		errorMsg.WriteString(fmt.Sprintf("Row assertion failed: %s. ", failure))
+	}
+
+	// Set the test result
+	passed := errorMsg.Len() == 0
+	data.TestPassed = types.BoolValue(passed)
+
+	// Set error message if test failed
+	if !passed {
+		data.Error = types.StringValue(errorMsg.String())
+	} else {
+		data.Error = types.StringValue("")
+	}
 
 	return nil
 }
+
+// dbConnectionString returns the connection string for the database type,
+// built from the connection attributes unless dsn is set.
+func dbConnectionString(dbType string, data *DbTestResourceModel, mysqlOptions, postgresOptions map[string]string) (string, error) {
+	dsn := data.Dsn.ValueString()
+	host := data.Host.ValueString()
+	if dsn == "" && host == "" && dbType != "sqlite" {
+		return "", errors.New("host is required unless dsn is set")
+	}
+
+	port := data.Port.ValueInt64()
+	if port == 0 {
+		port = dbDefaultPorts[dbType]
+	}
+	address := net.JoinHostPort(host, strconv.FormatInt(port, 10))
+	sslMode := data.SSLMode.ValueString()
+
+	switch dbType {
+	case "mysql":
+		connStr := dsn
+		if connStr == "" {
+			connStr = fmt.Sprintf("%s:%s@tcp(%s)/%s",
+				data.Username.ValueString(),
+				data.Password.ValueString(),
+				address,
+				data.Database.ValueString())
+		}
+		if len(mysqlOptions) > 0 {
+			params := url.Values{}
+			for key, value := range mysqlOptions {
+				params.Set(key, value)
+			}
+			separator := "?"
+			if strings.Contains(connStr, "?") {
+				separator = "&"
+			}
+			connStr += separator + params.Encode()
+		}
+		return connStr, nil
+	case "postgres":
+		connStr := dsn
+		if connStr == "" {
+			connStr = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+				host,
+				port,
+				data.Username.ValueString(),
+				data.Password.ValueString(),
+				data.Database.ValueString(),
+				sslMode)
+		} else if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+			var err error
+			if connStr, err = pq.ParseURL(connStr); err != nil {
+				return "", err
+			}
+		}
+		// Later parameters override earlier ones, including those in dsn
+		keys := make([]string, 0, len(postgresOptions))
+		for key := range postgresOptions {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(postgresOptions[key])
+			connStr += fmt.Sprintf(" %s='%s'", key, value)
+		}
+		return connStr, nil
+	case "sqlserver":
+		if dsn != "" {
+			return dsn, nil
+		}
+		query := url.Values{}
+		if database := data.Database.ValueString(); database != "" {
+			query.Set("database", database)
+		}
+		switch sslMode {
+		case "", "disable":
+			query.Set("encrypt", "disable")
+		case "require":
+			query.Set("encrypt", "true")
+			query.Set("TrustServerCertificate", "true")
+		default:
+			query.Set("encrypt", "true")
+		}
+		connURL := url.URL{
+			Scheme:   "sqlserver",
+			User:     url.UserPassword(data.Username.ValueString(), data.Password.ValueString()),
+			Host:     address,
+			RawQuery: query.Encode(),
+		}
+		return connURL.String(), nil
+	case "clickhouse":
+		if dsn != "" {
+			return dsn, nil
+		}
+		query := url.Values{}
+		if sslMode != "" && sslMode != "disable" {
+			query.Set("secure", "true")
+			if sslMode == "require" {
+				query.Set("skip_verify", "true")
+			}
+		}
+		connURL := url.URL{
+			Scheme:   "clickhouse",
+			User:     url.UserPassword(data.Username.ValueString(), data.Password.ValueString()),
+			Host:     address,
+			Path:     "/" + data.Database.ValueString(),
+			RawQuery: query.Encode(),
+		}
+		return connURL.String(), nil
+	case "sqlite":
+		if dsn != "" {
+			return dsn, nil
+		}
+		if data.Database.ValueString() == "" {
+			return "", errors.New("database must be set to the path of the database file for sqlite")
+		}
+		return data.Database.ValueString(), nil
+	default:
+		return "", fmt.Errorf("unsupported database type: %s", dbType)
+	}
+}
+
+// newDbConnector returns a connector for the database type that opens its
+// network connections through dialer.
+func newDbConnector(dbType, connStr string, dialer *dbDialer) (driver.Connector, error) {
+	switch dbType {
+	case "mysql":
+		cfg, err := mysql.ParseDSN(connStr)
+		if err != nil {
+			return nil, err
+		}
+		cfg.DialFunc = dialer.DialContext
+		return mysql.NewConnector(cfg)
+	case "postgres":
+		connector, err := pq.NewConnector(connStr)
+		if err != nil {
+			return nil, err
+		}
+		connector.Dialer(dialer)
+		return connector, nil
+	case "sqlserver":
+		connector, err := mssql.NewConnector(connStr)
+		if err != nil {
+			return nil, err
+		}
+		connector.Dialer = dialer
+		return connector, nil
+	case "clickhouse":
+		options, err := clickhouse.ParseDSN(connStr)
+		if err != nil {
+			return nil, err
+		}
+		options.DialContext = func(ctx context.Context, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", address)
+		}
+		return clickhouse.Connector(options), nil
+	case "sqlite":
+		// Opening a missing file would create an empty database
+		if path, _, _ := strings.Cut(connStr, "?"); !strings.HasPrefix(path, "file:") && path != ":memory:" {
+			if _, err := os.Stat(path); err != nil {
+				return nil, err
+			}
+		}
+		return dbDriverConnector{driver: &sqlite.Driver{}, name: connStr}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+}
+
+// dbDriverConnector opens connections through a driver that has no
+// connector of its own.
+type dbDriverConnector struct {
+	driver driver.Driver
+	name   string
+}
+
+// Connect opens a connection with the data source name.
+func (c dbDriverConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+// Driver returns the underlying driver.
+func (c dbDriverConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// dbDialer dials database connections, optionally from a source address, and
+// remembers the local address of the last connection. It implements the
+// dialer interfaces of the database drivers.
+type dbDialer struct {
+	dialer *net.Dialer
+
+	mu           sync.Mutex
+	localAddress string
+}
+
+// DialContext dials the address and records the local address used.
+func (d *dbDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.localAddress = localAddressString(conn)
+	d.mu.Unlock()
+
+	return conn, nil
+}
+
+// Dial implements pq.Dialer.
+func (d *dbDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialTimeout implements pq.Dialer.
+func (d *dbDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.DialContext(ctx, network, address)
+}
+
+// lastLocalAddress returns the local address of the last connection dialed.
+func (d *dbDialer) lastLocalAddress() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.localAddress
+}
@@ -0,0 +1,940 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/miekg/dns"
+)
+
+// dnsRecordTypes are the record types that can be queried.
+var dnsRecordTypes = map[string]uint16{
+	"A":      dns.TypeA,
+	"AAAA":   dns.TypeAAAA,
+	"CNAME":  dns.TypeCNAME,
+	"MX":     dns.TypeMX,
+	"TXT":    dns.TypeTXT,
+	"NS":     dns.TypeNS,
+	"SRV":    dns.TypeSRV,
+	"CAA":    dns.TypeCAA,
+	"SOA":    dns.TypeSOA,
+	"PTR":    dns.TypePTR,
+	"DS":     dns.TypeDS,
+	"DNSKEY": dns.TypeDNSKEY,
+	"HTTPS":  dns.TypeHTTPS,
+	"SVCB":   dns.TypeSVCB,
+	"NAPTR":  dns.TypeNAPTR,
+}
+
+// dnsSystemRecordTypes are the record types looked up through the operating
+// system resolver when no resolver is set.
+var dnsSystemRecordTypes = map[uint16]bool{
+	dns.TypeA:     true,
+	dns.TypeAAAA:  true,
+	dns.TypeCNAME: true,
+	dns.TypeMX:    true,
+	dns.TypeTXT:   true,
+	dns.TypeNS:    true,
+}
+
+// dnsRecordTypeNames returns the supported record types in alphabetical order.
+func dnsRecordTypeNames() []string {
+	names := make([]string, 0, len(dnsRecordTypes))
+	for name := range dnsRecordTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dnsQueryName returns the fully qualified name to query. PTR queries for an
+// IP address are rewritten to the matching reverse lookup name.
+func dnsQueryName(hostname string, qtype uint16) string {
+	if qtype == dns.TypePTR {
+		if reverse, err := dns.ReverseAddr(hostname); err == nil {
+			return reverse
+		}
+	}
+	return dns.Fqdn(hostname)
+}
+
+const (
+	dnsTransportUDP = "udp"
+	dnsTransportTCP = "tcp"
+	dnsTransportDoT = "dot"
+	dnsTransportDoH = "doh"
+)
+
+// defaultEdnsBufferSize is advertised when EDNS0 is enabled without an
+// explicit buffer size. It avoids IP fragmentation on common paths.
+const defaultEdnsBufferSize = 1232
+
+// parseDnsResolver turns a resolver setting into the server address for the
+// transport, returning the transport actually used. Resolvers may be given as
+// host, host:port, [v6]:port, tls://host[:port] for DNS-over-TLS or an
+// https:// URL for DNS-over-HTTPS. The transport defaults to the one implied
+// by the resolver, or UDP. An empty resolver yields an empty address, which
+// stands for the system resolvers.
+func parseDnsResolver(resolver, transport string) (string, string, error) {
+	implied := ""
+	switch {
+	case strings.HasPrefix(resolver, "https://"):
+		implied = dnsTransportDoH
+	case strings.HasPrefix(resolver, "tls://"):
+		implied = dnsTransportDoT
+	case strings.Contains(resolver, "://"):
+		return "", "", fmt.Errorf("unsupported resolver %q, URLs must use tls:// or https://", resolver)
+	}
+
+	switch transport {
+	case "":
+		transport = implied
+		if transport == "" {
+			transport = dnsTransportUDP
+		}
+	case dnsTransportUDP, dnsTransportTCP, dnsTransportDoT, dnsTransportDoH:
+		if implied != "" && transport != implied {
+			return "", "", fmt.Errorf("resolver %q requires transport %s, got %s", resolver, implied, transport)
+		}
+	default:
+		return "", "", fmt.Errorf("unsupported transport %q, must be one of: udp, tcp, dot, doh", transport)
+	}
+	resolver = strings.TrimPrefix(resolver, "tls://")
+
+	switch {
+	case resolver == "" && (transport == dnsTransportDoT || transport == dnsTransportDoH):
+		return "", "", fmt.Errorf("transport %s requires resolver", transport)
+	case resolver == "":
+		return "", transport, nil
+	case transport == dnsTransportDoT:
+		return dnsServerAddress(resolver, "853"), transport, nil
+	case transport == dnsTransportDoH && implied == dnsTransportDoH:
+		return resolver, transport, nil
+	case transport == dnsTransportDoH:
+		return "https://" + dnsServerAddress(resolver, "443") + "/dns-query", transport, nil
+	default:
+		return dnsServerAddress(resolver, "53"), transport, nil
+	}
+}
+
+// dnsServerAddress returns host:port for a resolver given with or without a
+// port. Bare IPv6 addresses are accepted with or without brackets.
+func dnsServerAddress(resolver, defaultPort string) string {
+	if host, port, err := net.SplitHostPort(resolver); err == nil && host != "" {
+		return net.JoinHostPort(host, port)
+	}
+	return net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(resolver, "["), "]"), defaultPort)
+}
+
+// dnsClient sends DNS queries to a list of servers, trying each in turn
+// until one answers.
+type dnsClient struct {
+	// servers are host:port addresses of the servers to query, or URLs for
+	// DNS-over-HTTPS.
+	servers   []string
+	transport string
+	timeout   time.Duration
+
+	// ednsBufferSize enables EDNS0 with this UDP buffer size when non-zero.
+	// Setting dnssecOK enables EDNS0 with the default size if needed.
+	ednsBufferSize uint16
+	dnssecOK       bool
+
+	// checkingDisabled sets the CD flag so that validating resolvers return
+	// data that fails validation instead of SERVFAIL.
+	checkingDisabled bool
+
+	// tlsConfig is used for DNS-over-TLS and httpClient for DNS-over-HTTPS.
+	tlsConfig  *tls.Config
+	httpClient *http.Client
+}
+
+// systemDnsServers returns the nameservers configured in /etc/resolv.conf.
+// It returns none when the file is missing, unreadable or lists no
+// nameservers, as on Windows, and queries then go through the operating
+// system resolver instead.
+func systemDnsServers() []string {
+	config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil || len(config.Servers) == 0 {
+		return nil
+	}
+
+	servers := make([]string, 0, len(config.Servers))
+	for _, server := range config.Servers {
+		servers = append(servers, net.JoinHostPort(server, config.Port))
+	}
+	return servers
+}
+
+// exchange queries the servers for a record set with recursion desired and
+// returns the first response along with the time it took.
+func (c dnsClient) exchange(ctx context.Context, name string, qtype uint16) (*dns.Msg, time.Duration, error) {
+	query := new(dns.Msg)
+	query.SetQuestion(name, qtype)
+	query.CheckingDisabled = c.checkingDisabled
+	if c.ednsBufferSize > 0 || c.dnssecOK {
+		size := c.ednsBufferSize
+		if size == 0 {
+			size = defaultEdnsBufferSize
+		}
+		query.SetEdns0(size, c.dnssecOK)
+	}
+
+	if len(c.servers) == 0 {
+		return c.exchangeSystem(ctx, query)
+	}
+
+	var lastErr error
+	for _, server := range c.servers {
+		response, rtt, err := c.exchangeWith(ctx, server, query)
+		if err == nil {
+			return response, rtt, nil
+		}
+		lastErr = err
+	}
+	return nil, 0, lastErr
+}
+
+// exchangeSystem answers a query through the operating system resolver,
+// which also consults the hosts file and search domains. It only supports
+// the record types the Go resolver can look up, records carry no TTL and a
+// name without records of the type is reported as NXDOMAIN.
+func (c dnsClient) exchangeSystem(ctx context.Context, query *dns.Msg) (*dns.Msg, time.Duration, error) {
+	question := query.Question[0]
+	host := strings.TrimSuffix(question.Name, ".")
+	header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: dns.ClassINET}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	resolver := net.DefaultResolver
+	var answers []dns.RR
+	var err error
+	start := time.Now()
+
+	switch question.Qtype {
+	case dns.TypeA, dns.TypeAAAA:
+		network := "ip4"
+		if question.Qtype == dns.TypeAAAA {
+			network = "ip6"
+		}
+		var ips []net.IP
+		ips, err = resolver.LookupIP(ctx, network, host)
+		for _, ip := range ips {
+			if question.Qtype == dns.TypeA {
+				answers = append(answers, &dns.A{Hdr: header, A: ip})
+			} else {
+				answers = append(answers, &dns.AAAA{Hdr: header, AAAA: ip})
+			}
+		}
+	case dns.TypeCNAME:
+		var cname string
+		cname, err = resolver.LookupCNAME(ctx, host)
+		if err == nil && !strings.EqualFold(dns.Fqdn(cname), question.Name) {
+			answers = append(answers, &dns.CNAME{Hdr: header, Target: dns.Fqdn(cname)})
+		}
+	case dns.TypeMX:
+		var mxs []*net.MX
+		mxs, err = resolver.LookupMX(ctx, host)
+		for _, mx := range mxs {
+			answers = append(answers, &dns.MX{Hdr: header, Preference: mx.Pref, Mx: dns.Fqdn(mx.Host)})
+		}
+	case dns.TypeTXT:
+		var txts []string
+		txts, err = resolver.LookupTXT(ctx, host)
+		for _, txt := range txts {
+			answers = append(answers, &dns.TXT{Hdr: header, Txt: []string{txt}})
+		}
+	case dns.TypeNS:
+		var nss []*net.NS
+		nss, err = resolver.LookupNS(ctx, host)
+		for _, ns := range nss {
+			answers = append(answers, &dns.NS{Hdr: header, Ns: dns.Fqdn(ns.Host)})
+		}
+	case dns.TypeSRV:
+		var srvs []*net.SRV
+		_, srvs, err = resolver.LookupSRV(ctx, "", "", host)
+		for _, srv := range srvs {
+			answers = append(answers, &dns.SRV{Hdr: header, Priority: srv.Priority, Weight: srv.Weight, Port: srv.Port, Target: dns.Fqdn(srv.Target)})
+		}
+	case dns.TypePTR:
+		ip := dnsReverseNameAddress(question.Name)
+		if ip == nil {
+			return nil, 0, fmt.Errorf("%s is not a reverse lookup name", question.Name)
+		}
+		var names []string
+		names, err = resolver.LookupAddr(ctx, ip.String())
+		for _, name := range names {
+			answers = append(answers, &dns.PTR{Hdr: header, Ptr: dns.Fqdn(name)})
+		}
+	default:
+		return nil, 0, fmt.Errorf("%s records cannot be looked up without /etc/resolv.conf, set resolver instead", dns.TypeToString[question.Qtype])
+	}
+	rtt := time.Since(start)
+
+	response := new(dns.Msg)
+	response.SetReply(query)
+	response.RecursionAvailable = true
+	var dnsErr *net.DNSError
+	switch {
+	case err == nil:
+		response.Answer = answers
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		response.Rcode = dns.RcodeNameError
+	default:
+		return nil, rtt, err
+	}
+	return response, rtt, nil
+}
+
+// dnsReverseNameAddress returns the address of an in-addr.arpa or ip6.arpa
+// reverse lookup name, or nil for any other name.
+func dnsReverseNameAddress(name string) net.IP {
+	name = strings.ToLower(dns.Fqdn(name))
+	var labels []string
+	switch {
+	case strings.HasSuffix(name, ".in-addr.arpa."):
+		labels = dns.SplitDomainName(strings.TrimSuffix(name, ".in-addr.arpa."))
+		if len(labels) != 4 {
+			return nil
+		}
+		slices.Reverse(labels)
+		return net.ParseIP(strings.Join(labels, ".")).To4()
+	case strings.HasSuffix(name, ".ip6.arpa."):
+		labels = dns.SplitDomainName(strings.TrimSuffix(name, ".ip6.arpa."))
+		if len(labels) != 32 {
+			return nil
+		}
+		slices.Reverse(labels)
+		var address strings.Builder
+		for i, nibble := range labels {
+			if i > 0 && i%4 == 0 {
+				address.WriteByte(':')
+			}
+			address.WriteString(nibble)
+		}
+		return net.ParseIP(address.String())
+	}
+	return nil
+}
+
+const (
+	dnsExpectAnswer   = "answer"
+	dnsExpectNXDomain = "nxdomain"
+	dnsExpectNoData   = "nodata"
+	dnsExpectServFail = "servfail"
+)
+
+// validateDnsExpect checks an expect value.
+func validateDnsExpect(expect string) error {
+	switch expect {
+	case dnsExpectAnswer, dnsExpectNXDomain, dnsExpectNoData, dnsExpectServFail:
+		return nil
+	default:
+		return fmt.Errorf("unsupported expect %q, must be one of: answer, nxdomain, nodata, servfail", expect)
+	}
+}
+
+// dnsOutcome classifies a response as an answer, NXDOMAIN, NODATA (the
+// name exists without records of the type), SERVFAIL or another response
+// code in lower case.
+func dnsOutcome(response *dns.Msg, qtype uint16) string {
+	switch response.Rcode {
+	case dns.RcodeSuccess:
+		if len(dnsAnswers(response, qtype)) > 0 {
+			return dnsExpectAnswer
+		}
+		return dnsExpectNoData
+	case dns.RcodeNameError:
+		return dnsExpectNXDomain
+	case dns.RcodeServerFailure:
+		return dnsExpectServFail
+	default:
+		return strings.ToLower(dns.RcodeToString[response.Rcode])
+	}
+}
+
+// lookup queries the records of a type, retrying until the response has the
+// expected outcome. The last response is returned along with its records of
+// the type.
+func (c dnsClient) lookup(ctx context.Context, name string, qtype uint16, expect string, retries int64, retryDelay time.Duration) (*dns.Msg, []dns.RR, time.Duration, error) {
+	var response *dns.Msg
+	var answers []dns.RR
+	var lookupErr error
+	var responseTime time.Duration
+
+	for i := int64(0); i <= retries; i++ {
+		response, responseTime, lookupErr = c.exchange(ctx, name, qtype)
+		if lookupErr == nil {
+			answers = dnsAnswers(response, qtype)
+			outcome := dnsOutcome(response, qtype)
+			switch {
+			case outcome == expect:
+			case expect == dnsExpectAnswer && outcome == dnsExpectNoData && qtype == dns.TypeCNAME:
+				// A name without an alias is its own canonical name
+			case expect == dnsExpectAnswer && outcome == dnsExpectNoData:
+				lookupErr = fmt.Errorf("no %s records found for %s", dns.TypeToString[qtype], name)
+			case expect == dnsExpectAnswer:
+				lookupErr = fmt.Errorf("server returned %s for %s", dns.RcodeToString[response.Rcode], name)
+			case outcome == dnsExpectAnswer:
+				lookupErr = fmt.Errorf("expected %s for %s but got %d %s records", strings.ToUpper(expect), name, len(answers), dns.TypeToString[qtype])
+			default:
+				lookupErr = fmt.Errorf("expected %s for %s but got %s", strings.ToUpper(expect), name, strings.ToUpper(outcome))
+			}
+		}
+
+		if lookupErr == nil {
+			break
+		}
+
+		if i < retries {
+			time.Sleep(retryDelay)
+		}
+	}
+
+	return response, answers, responseTime, lookupErr
+}
+
+// txtRecords returns the joined strings of the TXT records of a name, or
+// none when the name does not exist or has no TXT records. Only failed
+// queries are retried.
+func (c dnsClient) txtRecords(ctx context.Context, name string, retries int64, retryDelay time.Duration) ([]string, error) {
+	var lookupErr error
+	for i := int64(0); i <= retries; i++ {
+		var response *dns.Msg
+		response, _, lookupErr = c.exchange(ctx, name, dns.TypeTXT)
+		if lookupErr == nil {
+			switch dnsOutcome(response, dns.TypeTXT) {
+			case dnsExpectAnswer:
+				var records []string
+				for _, rr := range dnsAnswers(response, dns.TypeTXT) {
+					records = append(records, dnsRecordValue(rr))
+				}
+				return records, nil
+			case dnsExpectNXDomain, dnsExpectNoData:
+				return nil, nil
+			default:
+				lookupErr = fmt.Errorf("server returned %s for %s", dns.RcodeToString[response.Rcode], name)
+			}
+		}
+
+		if i < retries {
+			time.Sleep(retryDelay)
+		}
+	}
+
+	return nil, lookupErr
+}
+
+// dnsNameserver is an authoritative nameserver of a zone together with the
+// host:port address it is queried on. The address is empty when the
+// nameserver name did not resolve.
+type dnsNameserver struct {
+	name    string
+	address string
+}
+
+// zoneNameservers returns the nameservers of zone, or of the closest zone
+// enclosing hostname when zone is empty. Each nameserver is queried on its
+// first IPv4 address, falling back to IPv6, at port.
+func (c dnsClient) zoneNameservers(ctx context.Context, hostname, zone, port string) ([]dnsNameserver, error) {
+	name := dns.Fqdn(hostname)
+	if zone != "" {
+		name = dns.Fqdn(zone)
+	}
+
+	var hosts []string
+	for {
+		response, _, err := c.exchange(ctx, name, dns.TypeNS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up nameservers of %s: %w", name, err)
+		}
+		for _, rr := range dnsAnswers(response, dns.TypeNS) {
+			if ns, ok := rr.(*dns.NS); ok && strings.EqualFold(ns.Hdr.Name, name) {
+				hosts = append(hosts, ns.Ns)
+			}
+		}
+		if len(hosts) > 0 || zone != "" {
+			break
+		}
+
+		// Continue with the zone named by the SOA record of a negative
+		// response, or else with the parent name
+		next := ""
+		for _, rr := range response.Ns {
+			if soa, ok := rr.(*dns.SOA); ok && !strings.EqualFold(soa.Hdr.Name, name) && dns.IsSubDomain(soa.Hdr.Name, name) {
+				next = soa.Hdr.Name
+			}
+		}
+		if next == "" {
+			offset, end := dns.NextLabel(name, 0)
+			if end {
+				break
+			}
+			next = name[offset:]
+		}
+		name = next
+	}
+
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no nameservers found for %s", name)
+	}
+	sort.Strings(hosts)
+
+	nameservers := make([]dnsNameserver, 0, len(hosts))
+	for _, host := range hosts {
+		nameserver := dnsNameserver{name: host}
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			response, _, err := c.exchange(ctx, host, qtype)
+			if err != nil {
+				continue
+			}
+			if answers := dnsAnswers(response, qtype); len(answers) > 0 {
+				nameserver.address = net.JoinHostPort(dnsRecordValue(answers[0]), port)
+				break
+			}
+		}
+		nameservers = append(nameservers, nameserver)
+	}
+
+	return nameservers, nil
+}
+
+// exchangeWith sends the query to a single server. Over UDP a truncated
+// response is retried over TCP.
+func (c dnsClient) exchangeWith(ctx context.Context, server string, query *dns.Msg) (*dns.Msg, time.Duration, error) {
+	switch c.transport {
+	case dnsTransportDoH:
+		return c.exchangeHTTPS(ctx, server, query)
+	case dnsTransportDoT:
+		config := &tls.Config{}
+		if c.tlsConfig != nil {
+			config = c.tlsConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(server)
+		}
+		client := &dns.Client{Net: "tcp-tls", Timeout: c.timeout, TLSConfig: config}
+		return client.ExchangeContext(ctx, query, server)
+	case dnsTransportTCP:
+		client := &dns.Client{Net: "tcp", Timeout: c.timeout}
+		return client.ExchangeContext(ctx, query, server)
+	}
+
+	client := &dns.Client{Net: "udp", Timeout: c.timeout}
+	if opt := query.IsEdns0(); opt != nil {
+		client.UDPSize = opt.UDPSize()
+	}
+	response, rtt, err := client.ExchangeContext(ctx, query, server)
+	if err != nil {
+		return nil, rtt, err
+	}
+
+	if response.Truncated {
+		client.Net = "tcp"
+		return client.ExchangeContext(ctx, query, server)
+	}
+	return response, rtt, nil
+}
+
+// exchangeHTTPS sends the query as an RFC 8484 POST request.
+func (c dnsClient) exchangeHTTPS(ctx context.Context, url string, query *dns.Msg) (*dns.Msg, time.Duration, error) {
+	// The message ID is zero over HTTPS to keep responses cacheable
+	msg := query.Copy()
+	msg.Id = 0
+	packed, err := msg.Pack()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to pack query: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	client := c.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, time.Since(start), err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+	rtt := time.Since(start)
+	if err != nil {
+		return nil, rtt, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, rtt, fmt.Errorf("server returned HTTP %d", resp.StatusCode)
+	}
+
+	response := new(dns.Msg)
+	if err := response.Unpack(body); err != nil {
+		return nil, rtt, fmt.Errorf("invalid DNS response: %w", err)
+	}
+	response.Id = query.Id
+	return response, rtt, nil
+}
+
+// dnsAnswers returns the records of the answer section with the given type,
+// leaving out the CNAME records that lead to them.
+func dnsAnswers(msg *dns.Msg, qtype uint16) []dns.RR {
+	var answers []dns.RR
+	for _, rr := range msg.Answer {
+		if rr.Header().Rrtype == qtype {
+			answers = append(answers, rr)
+		}
+	}
+	return answers
+}
+
+// dnsRdata returns the record data in presentation format.
+func dnsRdata(rr dns.RR) string {
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
+
+// dnsRecordValue renders a record the way it is reported in last_result and
+// compared against expectations: the address or target name for address and
+// alias records, "preference exchange" for MX, the joined strings for TXT
+// and the presentation format of the record data for everything else.
+func dnsRecordValue(rr dns.RR) string {
+	switch record := rr.(type) {
+	case *dns.A:
+		return record.A.String()
+	case *dns.AAAA:
+		return record.AAAA.String()
+	case *dns.CNAME:
+		return record.Target
+	case *dns.NS:
+		return record.Ns
+	case *dns.PTR:
+		return record.Ptr
+	case *dns.MX:
+		return fmt.Sprintf("%d %s", record.Preference, record.Mx)
+	case *dns.TXT:
+		return strings.Join(record.Txt, "")
+	default:
+		return dnsRdata(rr)
+	}
+}
+
+// dnsRecordFields returns the type specific fields of a record.
+func dnsRecordFields(rr dns.RR) map[string]string {
+	switch record := rr.(type) {
+	case *dns.A:
+		return map[string]string{"address": record.A.String()}
+	case *dns.AAAA:
+		return map[string]string{"address": record.AAAA.String()}
+	case *dns.CNAME:
+		return map[string]string{"target": record.Target}
+	case *dns.NS:
+		return map[string]string{"host": record.Ns}
+	case *dns.PTR:
+		return map[string]string{"target": record.Ptr}
+	case *dns.MX:
+		return map[string]string{
+			"preference": strconv.Itoa(int(record.Preference)),
+			"exchange":   record.Mx,
+		}
+	case *dns.TXT:
+		return map[string]string{"text": strings.Join(record.Txt, "")}
+	case *dns.SRV:
+		return map[string]string{
+			"priority": strconv.Itoa(int(record.Priority)),
+			"weight":   strconv.Itoa(int(record.Weight)),
+			"port":     strconv.Itoa(int(record.Port)),
+			"target":   record.Target,
+		}
+	case *dns.CAA:
+		return map[string]string{
+			"flags": strconv.Itoa(int(record.Flag)),
+			"tag":   record.Tag,
+			"value": record.Value,
+		}
+	case *dns.SOA:
+		return map[string]string{
+			"mname":   record.Ns,
+			"rname":   record.Mbox,
+			"serial":  strconv.FormatUint(uint64(record.Serial), 10),
+			"refresh": strconv.FormatUint(uint64(record.Refresh), 10),
+			"retry":   strconv.FormatUint(uint64(record.Retry), 10),
+			"expire":  strconv.FormatUint(uint64(record.Expire), 10),
+			"minimum": strconv.FormatUint(uint64(record.Minttl), 10),
+		}
+	case *dns.DS:
+		return map[string]string{
+			"key_tag":     strconv.Itoa(int(record.KeyTag)),
+			"algorithm":   strconv.Itoa(int(record.Algorithm)),
+			"digest_type": strconv.Itoa(int(record.DigestType)),
+			"digest":      record.Digest,
+		}
+	case *dns.DNSKEY:
+		return map[string]string{
+			"flags":      strconv.Itoa(int(record.Flags)),
+			"protocol":   strconv.Itoa(int(record.Protocol)),
+			"algorithm":  strconv.Itoa(int(record.Algorithm)),
+			"public_key": record.PublicKey,
+			"key_tag":    strconv.Itoa(int(record.KeyTag())),
+		}
+	case *dns.HTTPS:
+		return svcbFields(&record.SVCB)
+	case *dns.SVCB:
+		return svcbFields(record)
+	case *dns.NAPTR:
+		return map[string]string{
+			"order":       strconv.Itoa(int(record.Order)),
+			"preference":  strconv.Itoa(int(record.Preference)),
+			"flags":       record.Flags,
+			"service":     record.Service,
+			"regexp":      record.Regexp,
+			"replacement": record.Replacement,
+		}
+	default:
+		return map[string]string{}
+	}
+}
+
+// svcbFields returns the fields of an SVCB or HTTPS record.
+func svcbFields(record *dns.SVCB) map[string]string {
+	params := make([]string, 0, len(record.Value))
+	for _, kv := range record.Value {
+		params = append(params, kv.Key().String()+"="+kv.String())
+	}
+
+	return map[string]string{
+		"priority": strconv.Itoa(int(record.Priority)),
+		"target":   record.Target,
+		"params":   strings.Join(params, " "),
+	}
+}
+
+// dnsCnameChain returns the CNAME records of a response followed from name
+// to the final target, in order.
+func dnsCnameChain(msg *dns.Msg, name string) []*dns.CNAME {
+	var chain []*dns.CNAME
+	for range len(msg.Answer) {
+		var next *dns.CNAME
+		for _, rr := range msg.Answer {
+			if cname, ok := rr.(*dns.CNAME); ok && strings.EqualFold(cname.Hdr.Name, name) {
+				next = cname
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		chain = append(chain, next)
+		name = next.Target
+	}
+	return chain
+}
+
+// dnsCnameHopAttrTypes describes a hop of a CNAME chain.
+var dnsCnameHopAttrTypes = map[string]attr.Type{
+	"name":   types.StringType,
+	"target": types.StringType,
+	"ttl":    types.Int64Type,
+}
+
+// dnsCnameChainValue converts a CNAME chain to a list of hops.
+func dnsCnameChainValue(chain []*dns.CNAME) types.List {
+	values := make([]attr.Value, 0, len(chain))
+	for _, cname := range chain {
+		values = append(values, types.ObjectValueMust(dnsCnameHopAttrTypes, map[string]attr.Value{
+			"name":   types.StringValue(cname.Hdr.Name),
+			"target": types.StringValue(cname.Target),
+			"ttl":    types.Int64Value(int64(cname.Hdr.Ttl)),
+		}))
+	}
+	return types.ListValueMust(types.ObjectType{AttrTypes: dnsCnameHopAttrTypes}, values)
+}
+
+// dnsReverseResult is the outcome of a forward-confirmed reverse DNS check
+// for a single address.
+type dnsReverseResult struct {
+	address          string
+	ptrNames         []string
+	forwardConfirmed bool
+	err              error
+}
+
+// forwardConfirmed looks up the PTR records of an address and checks that
+// at least one of the names resolves back to the address.
+func (c dnsClient) forwardConfirmed(ctx context.Context, address string, retries int64, retryDelay time.Duration) dnsReverseResult {
+	result := dnsReverseResult{address: address}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		result.err = fmt.Errorf("invalid address %s", address)
+		return result
+	}
+
+	_, ptrs, _, err := c.lookup(ctx, dnsQueryName(address, dns.TypePTR), dns.TypePTR, dnsExpectAnswer, retries, retryDelay)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	forwardType := dns.TypeAAAA
+	if ip.To4() != nil {
+		forwardType = dns.TypeA
+	}
+
+	var lastErr error
+	for _, rr := range ptrs {
+		name := dnsRecordValue(rr)
+		result.ptrNames = append(result.ptrNames, name)
+		if result.forwardConfirmed {
+			continue
+		}
+
+		_, forward, _, err := c.lookup(ctx, name, forwardType, dnsExpectAnswer, retries, retryDelay)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, rr := range forward {
+			if net.ParseIP(dnsRecordValue(rr)).Equal(ip) {
+				result.forwardConfirmed = true
+				break
+			}
+		}
+	}
+
+	if !result.forwardConfirmed {
+		result.err = fmt.Errorf("no PTR name of %s resolves back to it", address)
+		if lastErr != nil {
+			result.err = fmt.Errorf("%w: %w", result.err, lastErr)
+		}
+	}
+	return result
+}
+
+// dnsReverseAttrTypes describes the reverse DNS check of an address.
+var dnsReverseAttrTypes = map[string]attr.Type{
+	"address":           types.StringType,
+	"ptr_names":         types.ListType{ElemType: types.StringType},
+	"forward_confirmed": types.BoolType,
+	"error":             types.StringType,
+}
+
+// dnsReverseValues converts reverse DNS results to a list.
+func dnsReverseValues(results []dnsReverseResult) types.List {
+	values := make([]attr.Value, 0, len(results))
+	for _, result := range results {
+		names := make([]attr.Value, 0, len(result.ptrNames))
+		for _, name := range result.ptrNames {
+			names = append(names, types.StringValue(name))
+		}
+		errorMsg := ""
+		if result.err != nil {
+			errorMsg = result.err.Error()
+		}
+
+		values = append(values, types.ObjectValueMust(dnsReverseAttrTypes, map[string]attr.Value{
+			"address":           types.StringValue(result.address),
+			"ptr_names":         types.ListValueMust(types.StringType, names),
+			"forward_confirmed": types.BoolValue(result.forwardConfirmed),
+			"error":             types.StringValue(errorMsg),
+		}))
+	}
+	return types.ListValueMust(types.ObjectType{AttrTypes: dnsReverseAttrTypes}, values)
+}
+
+// dnsRecordAttrTypes describes an entry of a list of DNS records.
+var dnsRecordAttrTypes = map[string]attr.Type{
+	"name":   types.StringType,
+	"type":   types.StringType,
+	"class":  types.StringType,
+	"ttl":    types.Int64Type,
+	"value":  types.StringType,
+	"fields": types.MapType{ElemType: types.StringType},
+}
+
+// dnsRecordValues converts records to a list of DNS records.
+func dnsRecordValues(records []dns.RR) types.List {
+	values := make([]attr.Value, 0, len(records))
+	for _, rr := range records {
+		fields := make(map[string]attr.Value)
+		for k, v := range dnsRecordFields(rr) {
+			fields[k] = types.StringValue(v)
+		}
+
+		header := rr.Header()
+		values = append(values, types.ObjectValueMust(dnsRecordAttrTypes, map[string]attr.Value{
+			"name":   types.StringValue(header.Name),
+			"type":   types.StringValue(dns.TypeToString[header.Rrtype]),
+			"class":  types.StringValue(dns.ClassToString[header.Class]),
+			"ttl":    types.Int64Value(int64(header.Ttl)),
+			"value":  types.StringValue(dnsRecordValue(rr)),
+			"fields": types.MapValueMust(types.StringType, fields),
+		}))
+	}
+
+	return types.ListValueMust(types.ObjectType{AttrTypes: dnsRecordAttrTypes}, values)
+}
+
+// dnsRecordsSchemaAttribute returns the schema of a computed list of DNS
+// records.
+func dnsRecordsSchemaAttribute(description string) schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		MarkdownDescription: description,
+		Computed:            true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"name": schema.StringAttribute{
+					MarkdownDescription: "Owner name of the record",
+					Computed:            true,
+				},
+				"type": schema.StringAttribute{
+					MarkdownDescription: "Record type",
+					Computed:            true,
+				},
+				"class": schema.StringAttribute{
+					MarkdownDescription: "Record class, normally `IN`",
+					Computed:            true,
+				},
+				"ttl": schema.Int64Attribute{
+					MarkdownDescription: "Time to live in seconds",
+					Computed:            true,
+				},
+				"value": schema.StringAttribute{
+					MarkdownDescription: "Record data as used for `last_result` and `expect_result`",
+					Computed:            true,
+				},
+				"fields": schema.MapAttribute{
+					MarkdownDescription: "Type specific fields, e.g. `priority`, `weight`, `port` and `target` for SRV records",
+					ElementType:         types.StringType,
+					Computed:            true,
+				},
+			},
+		},
+	}
+}
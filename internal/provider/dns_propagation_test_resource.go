@@ -0,0 +1,555 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &DnsPropagationTestResource{}
+var _ resource.ResourceWithImportState = &DnsPropagationTestResource{}
+
+func NewDnsPropagationTestResource() resource.Resource {
+	return &DnsPropagationTestResource{}
+}
+
+// DnsPropagationTestResource defines the resource implementation.
+type DnsPropagationTestResource struct {
+	clientConfig *TerraProbeClientConfig
+}
+
+// DnsPropagationTestResourceModel describes the resource data model.
+type DnsPropagationTestResourceModel struct {
+	Name              types.String `tfsdk:"name"`
+	Hostname          types.String `tfsdk:"hostname"`
+	RecordType        types.String `tfsdk:"record_type"`
+	Resolvers         types.List   `tfsdk:"resolvers"`
+	Authoritative     types.Bool   `tfsdk:"authoritative"`
+	Zone              types.String `tfsdk:"zone"`
+	BootstrapResolver types.String `tfsdk:"bootstrap_resolver"`
+	ExpectResult      types.String `tfsdk:"expect_result"`
+	Quorum            types.Int64  `tfsdk:"quorum"`
+	Timeout           types.Int64  `tfsdk:"timeout"`
+	Retries           types.Int64  `tfsdk:"retries"`
+	RetryDelay        types.Int64  `tfsdk:"retry_delay"`
+	Id                types.String `tfsdk:"id"`
+
+	// Results
+	LastRun         types.String `tfsdk:"last_run"`
+	ResolverResults types.List   `tfsdk:"resolver_results"`
+	Consensus       types.List   `tfsdk:"consensus"`
+	TotalCount      types.Int64  `tfsdk:"total_count"`
+	AgreeingCount   types.Int64  `tfsdk:"agreeing_count"`
+	TestPassed      types.Bool   `tfsdk:"test_passed"`
+	Error           types.String `tfsdk:"error"`
+}
+
+// dnsPropagationResultAttrTypes describes the answer of a single resolver.
+var dnsPropagationResultAttrTypes = map[string]attr.Type{
+	"resolver":      types.StringType,
+	"nameserver":    types.StringType,
+	"records":       types.ListType{ElemType: types.StringType},
+	"response_time": types.Int64Type,
+	"agrees":        types.BoolType,
+	"error":         types.StringType,
+}
+
+func (r *DnsPropagationTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_dns_propagation_test"
+}
+
+func (r *DnsPropagationTestResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "DNS propagation test resource that queries a record from several resolvers or every authoritative nameserver concurrently and checks that they agree",
+
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Descriptive name for the test",
+				Required:            true,
+			},
+			"hostname": schema.StringAttribute{
+				MarkdownDescription: "Hostname or domain to resolve",
+				Required:            true,
+			},
+			"record_type": schema.StringAttribute{
+				MarkdownDescription: "DNS record type to query, any type supported by `terraprobe_dns_test`",
+				Required:            true,
+			},
+			"resolvers": schema.ListAttribute{
+				MarkdownDescription: "Resolvers to query, in any form accepted by the `resolver` attribute of `terraprobe_dns_test` (e.g., `8.8.8.8`, `tls://1.1.1.1`, `https://dns.quad9.net/dns-query`)",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"authoritative": schema.BoolAttribute{
+				MarkdownDescription: "Also query every authoritative nameserver of the zone, found through its NS records",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"zone": schema.StringAttribute{
+				MarkdownDescription: "Zone whose nameservers are queried when `authoritative` is set. Defaults to the closest zone enclosing `hostname`.",
+				Optional:            true,
+			},
+			"bootstrap_resolver": schema.StringAttribute{
+				MarkdownDescription: "Resolver used to look up the nameservers of the zone. Defaults to the system resolvers.",
+				Optional:            true,
+			},
+			"expect_result": schema.StringAttribute{
+				MarkdownDescription: "Value a resolver's records must contain for it to agree. Without it, resolvers agree when they return the most common set of records.",
+				Optional:            true,
+			},
+			"quorum": schema.Int64Attribute{
+				MarkdownDescription: "Number of resolvers that must agree for the test to pass",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means every resolver
+			},
+			"timeout": schema.Int64Attribute{
+				MarkdownDescription: "Timeout in seconds for each DNS query",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of retries for each DNS query",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retry_delay": schema.Int64Attribute{
+				MarkdownDescription: "Delay between retries in seconds",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+
+			// Results - these are computed values based on the last test run
+			"last_run": schema.StringAttribute{
+				MarkdownDescription: "Timestamp of the last test run",
+				Computed:            true,
+			},
+			"resolver_results": schema.ListNestedAttribute{
+				MarkdownDescription: "Answer of each resolver from the last test run, configured resolvers first",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"resolver": schema.StringAttribute{
+							MarkdownDescription: "Resolver or nameserver address queried",
+							Computed:            true,
+						},
+						"nameserver": schema.StringAttribute{
+							MarkdownDescription: "Name of the authoritative nameserver, empty for configured resolvers",
+							Computed:            true,
+						},
+						"records": schema.ListAttribute{
+							MarkdownDescription: "Sorted record values returned",
+							ElementType:         types.StringType,
+							Computed:            true,
+						},
+						"response_time": schema.Int64Attribute{
+							MarkdownDescription: "Query time in milliseconds",
+							Computed:            true,
+						},
+						"agrees": schema.BoolAttribute{
+							MarkdownDescription: "Whether the answer agrees with `expect_result` or the consensus",
+							Computed:            true,
+						},
+						"error": schema.StringAttribute{
+							MarkdownDescription: "Error message if the query failed",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"consensus": schema.ListAttribute{
+				MarkdownDescription: "Most common set of records returned",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"total_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of resolvers queried",
+				Computed:            true,
+			},
+			"agreeing_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of resolvers that agree",
+				Computed:            true,
+			},
+			"test_passed": schema.BoolAttribute{
+				MarkdownDescription: "Whether the quorum of resolvers agrees",
+				Computed:            true,
+			},
+			"error": schema.StringAttribute{
+				MarkdownDescription: "Error message if the test failed",
+				Computed:            true,
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Test identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *DnsPropagationTestResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	clientConfig, ok := req.ProviderData.(*TerraProbeClientConfig)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *TerraProbeClientConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.clientConfig = clientConfig
+}
+
+func (r *DnsPropagationTestResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data DnsPropagationTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate a unique identifier for this test
+	data.Id = types.StringValue(fmt.Sprintf("dns-propagation-test-%s", time.Now().Format("20060102150405")))
+
+	// Run the DNS propagation test
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("DNS Propagation Test Error", err.Error())
+		return
+	}
+
+	// Set the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Write logs
+	tflog.Trace(ctx, "created DNS propagation test resource")
+	tflog.Debug(ctx, fmt.Sprintf("DNS Propagation Test Result: %t - %d/%d resolvers agree", data.TestPassed.ValueBool(), data.AgreeingCount.ValueInt64(), data.TotalCount.ValueInt64()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *DnsPropagationTestResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data DnsPropagationTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the DNS propagation test again during Read
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("DNS Propagation Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *DnsPropagationTestResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data DnsPropagationTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the DNS propagation test with updated parameters
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("DNS Propagation Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *DnsPropagationTestResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data DnsPropagationTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Nothing special to do for delete, as this is a stateless resource
+	// The resource will be removed from Terraform state
+}
+
+func (r *DnsPropagationTestResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// dnsPropagationTarget is a resolver or nameserver to query and the outcome
+// of the query.
+type dnsPropagationTarget struct {
+	resolver   string
+	nameserver string
+	client     dnsClient
+
+	records      []string
+	responseTime time.Duration
+	err          error
+	agrees       bool
+}
+
+// runTest runs the DNS propagation test and updates the resource model with the results.
+func (r *DnsPropagationTestResource) runTest(ctx context.Context, data *DnsPropagationTestResourceModel) error {
+	// Get timeout from resource or default from provider
+	timeout := time.Second * 5
+	if !data.Timeout.IsNull() && data.Timeout.ValueInt64() > 0 {
+		timeout = time.Duration(data.Timeout.ValueInt64()) * time.Second
+	}
+
+	// Get retries from resource or default from provider
+	retries := r.clientConfig.Retries
+	if !data.Retries.IsNull() && data.Retries.ValueInt64() > 0 {
+		retries = data.Retries.ValueInt64()
+	}
+
+	// Get retry delay from resource or default from provider
+	retryDelay := r.clientConfig.RetryDelay
+	if !data.RetryDelay.IsNull() && data.RetryDelay.ValueInt64() > 0 {
+		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
+	}
+
+	qtype, supported := dnsRecordTypes[strings.ToUpper(data.RecordType.ValueString())]
+	if !supported {
+		return fmt.Errorf("unsupported DNS record type: %s (supported: %s)", data.RecordType.ValueString(), strings.Join(dnsRecordTypeNames(), ", "))
+	}
+	name := dnsQueryName(data.Hostname.ValueString(), qtype)
+
+	if data.Quorum.ValueInt64() < 0 {
+		return fmt.Errorf("quorum must not be negative")
+	}
+
+	var resolvers []string
+	if !data.Resolvers.IsNull() {
+		if diags := data.Resolvers.ElementsAs(ctx, &resolvers, false); diags.HasError() {
+			return fmt.Errorf("failed to read resolvers")
+		}
+	}
+	if len(resolvers) == 0 && !data.Authoritative.ValueBool() {
+		return fmt.Errorf("at least one resolver is required unless authoritative is set")
+	}
+
+	// Set up a client for every configured resolver
+	var targets []*dnsPropagationTarget
+	for _, resolver := range resolvers {
+		server, transport, err := parseDnsResolver(resolver, "")
+		if err != nil {
+			return err
+		}
+		client := dnsClient{servers: []string{server}, transport: transport, timeout: timeout}
+
+		if transport == dnsTransportDoH {
+			httpClient, closeClient, err := r.clientConfig.newHttpClient(httpClientOptions{Timeout: timeout})
+			if err != nil {
+				return err
+			}
+			defer closeClient()
+			client.httpClient = httpClient
+		}
+
+		targets = append(targets, &dnsPropagationTarget{resolver: resolver, client: client})
+	}
+
+	// Add the authoritative nameservers of the zone
+	if data.Authoritative.ValueBool() {
+		bootstrap, transport, err := parseDnsResolver(data.BootstrapResolver.ValueString(), "")
+		if err != nil {
+			return err
+		}
+		bootstrapClient := dnsClient{transport: transport, timeout: timeout}
+		if bootstrap != "" {
+			bootstrapClient.servers = []string{bootstrap}
+		} else {
+			bootstrapClient.servers = systemDnsServers()
+		}
+		if transport == dnsTransportDoH {
+			httpClient, closeClient, err := r.clientConfig.newHttpClient(httpClientOptions{Timeout: timeout})
+			if err != nil {
+				return err
+			}
+			defer closeClient()
+			bootstrapClient.httpClient = httpClient
+		}
+
+		nameservers, err := bootstrapClient.zoneNameservers(ctx, data.Hostname.ValueString(), data.Zone.ValueString(), "53")
+		if err != nil {
+			// The nameservers being unavailable is a test failure, not a configuration error
+			data.ResolverResults = types.ListValueMust(types.ObjectType{AttrTypes: dnsPropagationResultAttrTypes}, []attr.Value{})
+			data.Consensus = types.ListValueMust(types.StringType, []attr.Value{})
+			data.TotalCount = types.Int64Value(0)
+			data.AgreeingCount = types.Int64Value(0)
+			data.TestPassed = types.BoolValue(false)
+			data.Error = types.StringValue(fmt.Sprintf("Nameserver discovery failed: %s", err))
+			return nil // Don't return error as we want to keep the error in the state
+		}
+
+		for _, nameserver := range nameservers {
+			target := &dnsPropagationTarget{
+				resolver:   nameserver.address,
+				nameserver: nameserver.name,
+				client:     dnsClient{servers: []string{nameserver.address}, transport: dnsTransportUDP, timeout: timeout},
+			}
+			if nameserver.address == "" {
+				target.err = fmt.Errorf("failed to resolve the address of %s", nameserver.name)
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	// Query every resolver concurrently
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		if target.err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, answers, responseTime, err := target.client.lookup(ctx, name, qtype, dnsExpectAnswer, retries, retryDelay)
+			target.responseTime = responseTime
+			target.err = err
+			for _, rr := range answers {
+				target.records = append(target.records, dnsRecordValue(rr))
+			}
+			slices.Sort(target.records)
+		}()
+	}
+	wg.Wait()
+
+	// Find the most common answer, keeping the first one seen on ties
+	counts := make(map[string]int)
+	var consensus []string
+	for _, target := range targets {
+		if target.err != nil {
+			continue
+		}
+		key := strings.Join(target.records, "\n")
+		counts[key]++
+		if consensus == nil || counts[key] > counts[strings.Join(consensus, "\n")] {
+			consensus = target.records
+		}
+	}
+
+	// Decide which resolvers agree
+	agreeing := 0
+	var disagreeing []string
+	resultValues := make([]attr.Value, 0, len(targets))
+	for _, target := range targets {
+		switch {
+		case target.err != nil:
+			target.agrees = false
+		case !data.ExpectResult.IsNull() && data.ExpectResult.ValueString() != "":
+			target.agrees = slices.Contains(target.records, data.ExpectResult.ValueString())
+		default:
+			target.agrees = slices.Equal(target.records, consensus)
+		}
+
+		label := target.resolver
+		if target.nameserver != "" {
+			label = target.nameserver
+		}
+
+		errorMsg := ""
+		switch {
+		case target.agrees:
+			agreeing++
+		case target.err != nil:
+			errorMsg = target.err.Error()
+			disagreeing = append(disagreeing, fmt.Sprintf("%s (%s)", label, errorMsg))
+		default:
+			disagreeing = append(disagreeing, fmt.Sprintf("%s (%s)", label, strings.Join(target.records, ", ")))
+		}
+
+		records := make([]attr.Value, 0, len(target.records))
+		for _, record := range target.records {
+			records = append(records, types.StringValue(record))
+		}
+
+		resultValues = append(resultValues, types.ObjectValueMust(dnsPropagationResultAttrTypes, map[string]attr.Value{
+			"resolver":      types.StringValue(target.resolver),
+			"nameserver":    types.StringValue(target.nameserver),
+			"records":       types.ListValueMust(types.StringType, records),
+			"response_time": types.Int64Value(int64(target.responseTime / time.Millisecond)),
+			"agrees":        types.BoolValue(target.agrees),
+			"error":         types.StringValue(errorMsg),
+		}))
+	}
+
+	consensusValues := make([]attr.Value, 0, len(consensus))
+	for _, record := range consensus {
+		consensusValues = append(consensusValues, types.StringValue(record))
+	}
+
+	quorum := len(targets)
+	if data.Quorum.ValueInt64() > 0 {
+		quorum = int(data.Quorum.ValueInt64())
+	}
+
+	// Update the test results
+	data.ResolverResults = types.ListValueMust(types.ObjectType{AttrTypes: dnsPropagationResultAttrTypes}, resultValues)
+	data.Consensus = types.ListValueMust(types.StringType, consensusValues)
+	data.TotalCount = types.Int64Value(int64(len(targets)))
+	data.AgreeingCount = types.Int64Value(int64(agreeing))
+
+	// Set the test result
+	passed := agreeing >= quorum
+	data.TestPassed = types.BoolValue(passed)
+
+	// Set error message if test failed
+	if !passed {
+		data.Error = types.StringValue(fmt.Sprintf("%d of %d resolvers agree, %d required. Disagreeing: %s", agreeing, len(targets), quorum, strings.Join(disagreeing, "; ")))
+	} else {
+		data.Error = types.StringValue("")
+	}
+
+	return nil
+}
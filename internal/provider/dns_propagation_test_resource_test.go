@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TestDnsPropagationTestResource_runTest tests agreement and quorum across resolvers.
+func TestDnsPropagationTestResource_runTest(t *testing.T) {
+	updated := startTestDnsServer(t, "www.example.com. 300 IN A 192.0.2.20")
+	updatedToo := startTestDnsServer(t, "www.example.com. 300 IN A 192.0.2.20")
+	stale := startTestDnsServer(t, "www.example.com. 300 IN A 192.0.2.10")
+
+	r := &DnsPropagationTestResource{clientConfig: &TerraProbeClientConfig{}}
+	ctx := context.Background()
+
+	stringList := func(values ...string) types.List {
+		elements := make([]attr.Value, 0, len(values))
+		for _, v := range values {
+			elements = append(elements, types.StringValue(v))
+		}
+		return types.ListValueMust(types.StringType, elements)
+	}
+
+	newModel := func(resolvers ...string) *DnsPropagationTestResourceModel {
+		return &DnsPropagationTestResourceModel{
+			Name:          types.StringValue("Propagation"),
+			Hostname:      types.StringValue("www.example.com"),
+			RecordType:    types.StringValue("A"),
+			Resolvers:     stringList(resolvers...),
+			Authoritative: types.BoolValue(false),
+			Quorum:        types.Int64Value(0),
+			Timeout:       types.Int64Value(2),
+		}
+	}
+
+	t.Run("all resolvers agree", func(t *testing.T) {
+		model := newModel(updated, updatedToo)
+		model.ExpectResult = types.StringValue("192.0.2.20")
+
+		if err := r.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !model.TestPassed.ValueBool() {
+			t.Errorf("expected test to pass, got error: %s", model.Error.ValueString())
+		}
+		if model.AgreeingCount.ValueInt64() != 2 || len(model.ResolverResults.Elements()) != 2 {
+			t.Errorf("expected 2 agreeing resolvers, got %d of %v", model.AgreeingCount.ValueInt64(), model.ResolverResults)
+		}
+	})
+
+	t.Run("stale resolver fails without quorum", func(t *testing.T) {
+		model := newModel(updated, updatedToo, stale)
+
+		if err := r.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if model.TestPassed.ValueBool() {
+			t.Fatalf("expected test to fail with a stale resolver")
+		}
+		if !strings.Contains(model.Error.ValueString(), stale+" (192.0.2.10)") {
+			t.Errorf("expected error to name the stale resolver, got %q", model.Error.ValueString())
+		}
+		if !model.Consensus.Equal(stringList("192.0.2.20")) {
+			t.Errorf("unexpected consensus %v", model.Consensus)
+		}
+	})
+
+	t.Run("quorum tolerates a stale resolver", func(t *testing.T) {
+		model := newModel(updated, stale, updatedToo)
+		model.ExpectResult = types.StringValue("192.0.2.20")
+		model.Quorum = types.Int64Value(2)
+
+		if err := r.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !model.TestPassed.ValueBool() {
+			t.Errorf("expected test to pass with quorum 2, got error: %s", model.Error.ValueString())
+		}
+	})
+
+	t.Run("unreachable resolver disagrees", func(t *testing.T) {
+		listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		silent := listener.LocalAddr().String()
+		defer func() { _ = listener.Close() }()
+
+		model := newModel(updated, silent)
+		model.Timeout = types.Int64Value(1)
+
+		if err := r.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if model.TestPassed.ValueBool() || model.AgreeingCount.ValueInt64() != 1 {
+			t.Errorf("expected 1 agreeing resolver and a failed test, got %d: %s", model.AgreeingCount.ValueInt64(), model.Error.ValueString())
+		}
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		for _, model := range []*DnsPropagationTestResourceModel{
+			newModel(),
+			newModel("quic://dns.example.com"),
+			{Hostname: types.StringValue("www.example.com"), RecordType: types.StringValue("BOGUS"), Resolvers: stringList(updated)},
+		} {
+			if err := r.runTest(ctx, model); err == nil {
+				t.Errorf("expected configuration error for %+v", model)
+			}
+		}
+	})
+}
+
+// TestDnsClient_zoneNameservers tests finding the nameservers of the zone
+// enclosing a name.
+func TestDnsClient_zoneNameservers(t *testing.T) {
+	server := startTestDnsServer(t,
+		"example.com. 3600 IN NS ns2.example.com.",
+		"example.com. 3600 IN NS ns1.example.com.",
+		"ns1.example.com. 3600 IN A 192.0.2.53",
+		"ns2.example.com. 3600 IN AAAA 2001:db8::53",
+		"www.example.com. 300 IN A 192.0.2.20",
+	)
+	client := dnsClient{servers: []string{server}, transport: dnsTransportUDP, timeout: 2 * time.Second}
+
+	nameservers, err := client.zoneNameservers(context.Background(), "www.example.com", "", "53")
+	if err != nil {
+		t.Fatalf("zoneNameservers failed: %v", err)
+	}
+
+	expected := []dnsNameserver{
+		{name: "ns1.example.com.", address: "192.0.2.53:53"},
+		{name: "ns2.example.com.", address: "[2001:db8::53]:53"},
+	}
+	if len(nameservers) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, nameservers)
+	}
+	for i := range expected {
+		if nameservers[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], nameservers[i])
+		}
+	}
+
+	if _, err := client.zoneNameservers(context.Background(), "www.example.com", "example.org", "53"); err == nil {
+		t.Errorf("expected error for a zone without nameservers")
+	}
+}
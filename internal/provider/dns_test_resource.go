@@ -3,17 +3,22 @@ package provider
 import (
 	"context"
 	"fmt"
-	"net"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/miekg/dns"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
@@ -31,22 +36,44 @@ type DnsTestResource struct {
 
 // DnsTestResourceModel describes the resource data model.
 type DnsTestResourceModel struct {
-	Name         types.String `tfsdk:"name"`
-	Hostname     types.String `tfsdk:"hostname"`
-	RecordType   types.String `tfsdk:"record_type"`
-	ExpectResult types.String `tfsdk:"expect_result"`
-	Resolver     types.String `tfsdk:"resolver"`
-	Timeout      types.Int64  `tfsdk:"timeout"`
-	Retries      types.Int64  `tfsdk:"retries"`
-	RetryDelay   types.Int64  `tfsdk:"retry_delay"`
-	Id           types.String `tfsdk:"id"`
+	Name                types.String `tfsdk:"name"`
+	Hostname            types.String `tfsdk:"hostname"`
+	RecordType          types.String `tfsdk:"record_type"`
+	Expect              types.String `tfsdk:"expect"`
+	ExpectResult        types.String `tfsdk:"expect_result"`
+	ExpectResults       types.List   `tfsdk:"expect_results"`
+	ExpectExact         types.List   `tfsdk:"expect_exact"`
+	ExpectCount         types.Int64  `tfsdk:"expect_count"`
+	ExpectRegex         types.String `tfsdk:"expect_regex"`
+	ExpectTtlMax        types.Int64  `tfsdk:"expect_ttl_max"`
+	ExpectTtlMin        types.Int64  `tfsdk:"expect_ttl_min"`
+	MaxCnameChainLength types.Int64  `tfsdk:"max_cname_chain_length"`
+	ExpectCnameTarget   types.String `tfsdk:"expect_cname_target"`
+	VerifyReverse       types.Bool   `tfsdk:"verify_reverse"`
+	Resolver            types.String `tfsdk:"resolver"`
+	Transport           types.String `tfsdk:"transport"`
+	EdnsBufferSize      types.Int64  `tfsdk:"edns_buffer_size"`
+	DnssecOk            types.Bool   `tfsdk:"dnssec_ok"`
+	ValidateDnssec      types.Bool   `tfsdk:"validate_dnssec"`
+	TrustAnchors        types.List   `tfsdk:"trust_anchors"`
+	Timeout             types.Int64  `tfsdk:"timeout"`
+	Retries             types.Int64  `tfsdk:"retries"`
+	RetryDelay          types.Int64  `tfsdk:"retry_delay"`
+	Id                  types.String `tfsdk:"id"`
 
 	// Results
-	LastRun        types.String `tfsdk:"last_run"`
-	LastResult     types.String `tfsdk:"last_result"`
-	LastResultTime types.Int64  `tfsdk:"last_result_time"`
-	TestPassed     types.Bool   `tfsdk:"test_passed"`
-	Error          types.String `tfsdk:"error"`
+	LastRun                  types.String `tfsdk:"last_run"`
+	LastResult               types.String `tfsdk:"last_result"`
+	LastRecords              types.List   `tfsdk:"last_records"`
+	LastCnameChain           types.List   `tfsdk:"last_cname_chain"`
+	LastReverseResults       types.List   `tfsdk:"last_reverse_results"`
+	LastResultTime           types.Int64  `tfsdk:"last_result_time"`
+	Rcode                    types.String `tfsdk:"rcode"`
+	LastAuthenticatedData    types.Bool   `tfsdk:"last_authenticated_data"`
+	LastDnssecValid          types.Bool   `tfsdk:"last_dnssec_valid"`
+	LastRrsigDaysUntilExpiry types.Int64  `tfsdk:"last_rrsig_days_until_expiry"`
+	TestPassed               types.Bool   `tfsdk:"test_passed"`
+	Error                    types.String `tfsdk:"error"`
 }
 
 func (r *DnsTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -67,15 +94,88 @@ func (r *DnsTestResource) Schema(ctx context.Context, req resource.SchemaRequest
 				Required:            true,
 			},
 			"record_type": schema.StringAttribute{
-				MarkdownDescription: "DNS record type to query (A, AAAA, CNAME, MX, TXT, etc.)",
+				MarkdownDescription: "DNS record type to query: `A`, `AAAA`, `CNAME`, `MX`, `TXT`, `NS`, `SRV`, `CAA`, `SOA`, `PTR`, `DS`, `DNSKEY`, `HTTPS`, `SVCB` or `NAPTR`. PTR queries accept an IP address as `hostname`.",
 				Required:            true,
 			},
+			"expect": schema.StringAttribute{
+				MarkdownDescription: "Expected outcome of the query: `answer` (records of the type), `nxdomain` (the name does not exist), `nodata` (the name exists without records of the type) or `servfail`. Use `nxdomain` to assert that decommissioned names no longer resolve.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(dnsExpectAnswer),
+			},
 			"expect_result": schema.StringAttribute{
 				MarkdownDescription: "Expected result in the DNS response (IP address, hostname, etc.)",
 				Optional:            true,
 			},
+			"expect_results": schema.ListAttribute{
+				MarkdownDescription: "Values that must all be present among the records, compared like `expect_result`",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"expect_exact": schema.ListAttribute{
+				MarkdownDescription: "Values the records must match exactly, ignoring order. Fails on missing values and on unexpected ones, such as stale records left behind after a migration.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"expect_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of records that must be returned",
+				Optional:            true,
+			},
+			"expect_regex": schema.StringAttribute{
+				MarkdownDescription: "Regular expression that at least one record value must match",
+				Optional:            true,
+			},
+			"expect_ttl_max": schema.Int64Attribute{
+				MarkdownDescription: "Maximum TTL in seconds allowed for every record, e.g. to verify TTLs were lowered before a cutover",
+				Optional:            true,
+			},
+			"expect_ttl_min": schema.Int64Attribute{
+				MarkdownDescription: "Minimum TTL in seconds required for every record",
+				Optional:            true,
+			},
+			"max_cname_chain_length": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of CNAME records followed to reach the answer",
+				Optional:            true,
+			},
+			"expect_cname_target": schema.StringAttribute{
+				MarkdownDescription: "Name that one of the CNAME records in the chain must point to, or a domain the target must be under (e.g., `cdn.example.net` matches `edge1.cdn.example.net.`)",
+				Optional:            true,
+			},
+			"verify_reverse": schema.BoolAttribute{
+				MarkdownDescription: "Look up the PTR records of every returned address and require forward-confirmed reverse DNS: a PTR name must resolve back to the address. Only valid for `A` and `AAAA` queries.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
 			"resolver": schema.StringAttribute{
-				MarkdownDescription: "DNS resolver to use (e.g., 8.8.8.8, 1.1.1.1)",
+				MarkdownDescription: "DNS resolver to use. Without it, A, AAAA, CNAME, MX, TXT and NS records are looked up through the operating system resolver, which honours the hosts file and search domains. Other record types, and tests using `transport`, `edns_buffer_size`, `dnssec_ok`, `validate_dnssec`, an `expect` other than `answer`, TTL or CNAME chain assertions, query the nameservers in `/etc/resolv.conf` instead. Accepts `host`, `host:port`, `[v6]:port`, `tls://host[:port]` for DNS-over-TLS or an `https://` URL for DNS-over-HTTPS (e.g., `1.1.1.1`, `[2606:4700:4700::1111]:53`, `tls://dns.google`, `https://cloudflare-dns.com/dns-query`)",
+				Optional:            true,
+			},
+			"transport": schema.StringAttribute{
+				MarkdownDescription: "Transport for the query: `udp` (falls back to TCP for truncated responses), `tcp`, `dot` (DNS-over-TLS, port 853) or `doh` (DNS-over-HTTPS). Defaults to the transport implied by `resolver`, or `udp`. `dot` and `doh` require `resolver`.",
+				Optional:            true,
+			},
+			"edns_buffer_size": schema.Int64Attribute{
+				MarkdownDescription: "Enable EDNS0 and advertise this UDP buffer size (512-65535)",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means EDNS0 is only enabled for dnssec_ok
+			},
+			"dnssec_ok": schema.BoolAttribute{
+				MarkdownDescription: "Set the EDNS0 DO bit to request DNSSEC records, using a 1232 byte buffer unless `edns_buffer_size` is set",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"validate_dnssec": schema.BoolAttribute{
+				MarkdownDescription: "Request DNSSEC records and validate the chain of trust from `trust_anchors` down to the answer and each CNAME leading to it. The test fails when the answer or a CNAME is unsigned, a signature is invalid or expired, or a delegation is insecure.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"trust_anchors": schema.ListAttribute{
+				MarkdownDescription: "DS or DNSKEY records in zone file format that validation starts from, e.g. `example.com. IN DS 12345 13 2 ...` for a zone not signed from the root. Defaults to the root zone key signing keys published by IANA.",
+				ElementType:         types.StringType,
 				Optional:            true,
 			},
 			"timeout": schema.Int64Attribute{
@@ -103,13 +203,75 @@ func (r *DnsTestResource) Schema(ctx context.Context, req resource.SchemaRequest
 				Computed:            true,
 			},
 			"last_result": schema.StringAttribute{
-				MarkdownDescription: "Result from the last DNS query",
+				MarkdownDescription: "Result from the last DNS query, the values of `last_records` joined with `, `. For `CNAME` queries, the final target of the chain, or the hostname itself when it has no alias.",
+				Computed:            true,
+			},
+			"last_records": dnsRecordsSchemaAttribute("Records of the requested type returned by the last DNS query"),
+			"last_cname_chain": schema.ListNestedAttribute{
+				MarkdownDescription: "CNAME records followed from `hostname` to the final answer, in order",
 				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							MarkdownDescription: "Owner name of the CNAME record",
+							Computed:            true,
+						},
+						"target": schema.StringAttribute{
+							MarkdownDescription: "Name the record points to",
+							Computed:            true,
+						},
+						"ttl": schema.Int64Attribute{
+							MarkdownDescription: "Time to live in seconds",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"last_reverse_results": schema.ListNestedAttribute{
+				MarkdownDescription: "Reverse DNS check of every returned address when `verify_reverse` is set",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"address": schema.StringAttribute{
+							MarkdownDescription: "Address checked",
+							Computed:            true,
+						},
+						"ptr_names": schema.ListAttribute{
+							MarkdownDescription: "Names returned by the PTR lookup",
+							ElementType:         types.StringType,
+							Computed:            true,
+						},
+						"forward_confirmed": schema.BoolAttribute{
+							MarkdownDescription: "Whether a PTR name resolves back to the address",
+							Computed:            true,
+						},
+						"error": schema.StringAttribute{
+							MarkdownDescription: "Error message if the check failed",
+							Computed:            true,
+						},
+					},
+				},
 			},
 			"last_result_time": schema.Int64Attribute{
 				MarkdownDescription: "Query time in milliseconds from the last test run",
 				Computed:            true,
 			},
+			"rcode": schema.StringAttribute{
+				MarkdownDescription: "Response code of the last DNS query, e.g. `NOERROR`, `NXDOMAIN` or `SERVFAIL`. Empty when no response was received.",
+				Computed:            true,
+			},
+			"last_authenticated_data": schema.BoolAttribute{
+				MarkdownDescription: "Whether the resolver set the AD flag, vouching that it validated the answer",
+				Computed:            true,
+			},
+			"last_dnssec_valid": schema.BoolAttribute{
+				MarkdownDescription: "Whether the answer passed DNSSEC validation, always false unless `validate_dnssec` is set",
+				Computed:            true,
+			},
+			"last_rrsig_days_until_expiry": schema.Int64Attribute{
+				MarkdownDescription: "Days until the first RRSIG over the answer expires, negative once expired and 0 unless `validate_dnssec` is set",
+				Computed:            true,
+			},
 			"test_passed": schema.BoolAttribute{
 				MarkdownDescription: "Whether the test passed",
 				Computed:            true,
@@ -247,8 +409,6 @@ func (r *DnsTestResource) ImportState(ctx context.Context, req resource.ImportSt
 }
 
 // runTest performs the DNS lookup test.
-//
-//nolint:unparam
 func (r *DnsTestResource) runTest(ctx context.Context, data *DnsTestResourceModel) error {
 	// Get timeout from resource or default from provider
 	timeout := time.Second * 5
@@ -268,87 +428,122 @@ func (r *DnsTestResource) runTest(ctx context.Context, data *DnsTestResourceMode
 		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
 	}
 
-	// Set up DNS resolver
-	resolver := net.DefaultResolver
-	if !data.Resolver.IsNull() && data.Resolver.ValueString() != "" {
-		resolver = &net.Resolver{
-			PreferGo: true,
-			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-				d := net.Dialer{
-					Timeout: timeout,
-				}
-				return d.DialContext(ctx, "udp", data.Resolver.ValueString()+":53")
-			},
+	// Read the expectations
+	var expectResults, expectExact []string
+	if !data.ExpectResults.IsNull() {
+		if diags := data.ExpectResults.ElementsAs(ctx, &expectResults, false); diags.HasError() {
+			return fmt.Errorf("failed to read expect_results")
+		}
+	}
+	if !data.ExpectExact.IsNull() {
+		if diags := data.ExpectExact.ElementsAs(ctx, &expectExact, false); diags.HasError() {
+			return fmt.Errorf("failed to read expect_exact")
 		}
 	}
 
-	// Perform the DNS lookup with retries
-	var result []string
-	var lookupErr error
-	var responseTime time.Duration
+	var expectRegex *regexp.Regexp
+	if !data.ExpectRegex.IsNull() && data.ExpectRegex.ValueString() != "" {
+		re, err := regexp.Compile(data.ExpectRegex.ValueString())
+		if err != nil {
+			return fmt.Errorf("invalid expect_regex: %w", err)
+		}
+		expectRegex = re
+	}
 
-	recordType := data.RecordType.ValueString()
-	for i := int64(0); i <= retries; i++ {
-		start := time.Now()
+	expect := dnsExpectAnswer
+	if !data.Expect.IsNull() && data.Expect.ValueString() != "" {
+		expect = data.Expect.ValueString()
+	}
+	if err := validateDnsExpect(expect); err != nil {
+		return err
+	}
 
-		// Different lookup methods based on record type
-		switch recordType {
+	if data.VerifyReverse.ValueBool() {
+		switch strings.ToUpper(data.RecordType.ValueString()) {
 		case "A", "AAAA":
-			var ips []net.IP
-
-			if recordType == "A" {
-				// A record - return IPv4 addresses
-				ips, lookupErr = resolver.LookupIP(ctx, "ip4", data.Hostname.ValueString())
-			} else {
-				// AAAA record - return IPv6 addresses
-				ips, lookupErr = resolver.LookupIP(ctx, "ip6", data.Hostname.ValueString())
-			}
+		default:
+			return fmt.Errorf("verify_reverse requires record_type A or AAAA")
+		}
+	}
 
-			if lookupErr == nil {
-				result = make([]string, len(ips))
-				for i, ip := range ips {
-					result[i] = ip.String()
-				}
-			}
-		case "CNAME":
-			var cname string
-			cname, lookupErr = resolver.LookupCNAME(ctx, data.Hostname.ValueString())
-			if lookupErr == nil {
-				result = []string{cname}
-			}
-		case "MX":
-			var mxs []*net.MX
-			mxs, lookupErr = resolver.LookupMX(ctx, data.Hostname.ValueString())
-			if lookupErr == nil {
-				result = make([]string, len(mxs))
-				for i, mx := range mxs {
-					result[i] = fmt.Sprintf("%d %s", mx.Pref, mx.Host)
-				}
-			}
-		case "TXT":
-			result, lookupErr = resolver.LookupTXT(ctx, data.Hostname.ValueString())
-		case "NS":
-			var nss []*net.NS
-			nss, lookupErr = resolver.LookupNS(ctx, data.Hostname.ValueString())
-			if lookupErr == nil {
-				result = make([]string, len(nss))
-				for i, ns := range nss {
-					result[i] = ns.Host
-				}
+	var anchors []dns.RR
+	if data.ValidateDnssec.ValueBool() {
+		if expect != dnsExpectAnswer {
+			return fmt.Errorf("validate_dnssec requires expect = %q", dnsExpectAnswer)
+		}
+
+		records := defaultTrustAnchors
+		if !data.TrustAnchors.IsNull() {
+			if diags := data.TrustAnchors.ElementsAs(ctx, &records, false); diags.HasError() {
+				return fmt.Errorf("failed to read trust_anchors")
 			}
-		default:
-			lookupErr = fmt.Errorf("unsupported DNS record type: %s", recordType)
 		}
+		parsed, err := parseTrustAnchors(records)
+		if err != nil {
+			return err
+		}
+		anchors = parsed
+	}
 
-		responseTime = time.Since(start)
+	qtype, supported := dnsRecordTypes[strings.ToUpper(data.RecordType.ValueString())]
+
+	// Set up the servers to query
+	client := dnsClient{
+		timeout:  timeout,
+		dnssecOK: data.DnssecOk.ValueBool() || data.ValidateDnssec.ValueBool(),
+	}
 
-		if lookupErr == nil {
-			break
+	if size := data.EdnsBufferSize.ValueInt64(); size != 0 {
+		if size < dns.MinMsgSize || size > dns.MaxMsgSize {
+			return fmt.Errorf("edns_buffer_size must be between %d and %d, got %d", dns.MinMsgSize, dns.MaxMsgSize, size)
 		}
+		client.ednsBufferSize = uint16(size)
+	}
+
+	server, transport, err := parseDnsResolver(data.Resolver.ValueString(), data.Transport.ValueString())
+	if err != nil {
+		return err
+	}
+	client.transport = transport
+
+	// Without a resolver, the record types supported before queries went
+	// over the wire keep going through the operating system resolver, so
+	// that the hosts file and search domains still apply
+	if server != "" {
+		client.servers = []string{server}
+	} else if !dnsSystemRecordTypes[qtype] || dnsNeedsResponse(data, expect) {
+		client.servers = systemDnsServers()
+	}
 
-		if i < retries {
-			time.Sleep(retryDelay)
+	if client.transport == dnsTransportDoH {
+		httpClient, closeClient, err := r.clientConfig.newHttpClient(httpClientOptions{Timeout: timeout})
+		if err != nil {
+			return err
 		}
+		defer closeClient()
+		client.httpClient = httpClient
+	}
+
+	// Perform the DNS query with retries
+	var response *dns.Msg
+	var answers []dns.RR
+	var lookupErr error
+	var responseTime time.Duration
+
+	if supported {
+		response, answers, responseTime, lookupErr = client.lookup(ctx, dnsQueryName(data.Hostname.ValueString(), qtype), qtype, expect, retries, retryDelay)
+	} else {
+		lookupErr = fmt.Errorf("unsupported DNS record type: %s (supported: %s)", data.RecordType.ValueString(), strings.Join(dnsRecordTypeNames(), ", "))
+	}
+
+	data.Rcode = types.StringValue("")
+	data.LastCnameChain = dnsCnameChainValue(nil)
+	data.LastReverseResults = dnsReverseValues(nil)
+	var chain []*dns.CNAME
+	if response != nil {
+		data.Rcode = types.StringValue(dns.RcodeToString[response.Rcode])
+		chain = dnsCnameChain(response, dnsQueryName(data.Hostname.ValueString(), qtype))
+		data.LastCnameChain = dnsCnameChainValue(chain)
 	}
 
 	// Handle DNS lookup errors
@@ -357,53 +552,158 @@ func (r *DnsTestResource) runTest(ctx context.Context, data *DnsTestResourceMode
 		data.TestPassed = types.BoolValue(false)
 		data.LastResultTime = types.Int64Value(int64(responseTime / time.Millisecond))
 		data.LastResult = types.StringValue("")
+		data.LastRecords = dnsRecordValues(nil)
+		data.LastAuthenticatedData = types.BoolValue(false)
+		data.LastDnssecValid = types.BoolValue(false)
+		data.LastRrsigDaysUntilExpiry = types.Int64Value(0)
 		return nil // Don't return error as we want to keep the error in the state
 	}
 
-	// Join the results into a comma-separated string
-	resultStr := ""
-	if len(result) > 0 {
-		resultStr = result[0]
-		for i := 1; i < len(result); i++ {
-			resultStr += ", " + result[i]
+	result := make([]string, len(answers))
+	for i, rr := range answers {
+		result[i] = dnsRecordValue(rr)
+	}
+
+	// CNAME tests report the canonical name: the final target of the chain,
+	// or the name itself when it has no alias
+	if qtype == dns.TypeCNAME {
+		canonical := dnsQueryName(data.Hostname.ValueString(), qtype)
+		if len(chain) > 0 {
+			canonical = chain[len(chain)-1].Target
 		}
+		result = []string{canonical}
 	}
 
 	// Update the test results
 	data.LastResultTime = types.Int64Value(int64(responseTime / time.Millisecond))
-	data.LastResult = types.StringValue(resultStr)
+	data.LastResult = types.StringValue(strings.Join(result, ", "))
+	data.LastRecords = dnsRecordValues(answers)
+	data.LastAuthenticatedData = types.BoolValue(response.AuthenticatedData)
+
+	// Check the records against the expectations
+	var errorMsg strings.Builder
+
+	if !data.MaxCnameChainLength.IsNull() && int64(len(chain)) > data.MaxCnameChainLength.ValueInt64() {
+		errorMsg.WriteString(fmt.Sprintf("CNAME chain has %d records, more than the maximum of %d. ", len(chain), data.MaxCnameChainLength.ValueInt64()))
+	}
 
-	// Check if the test passed
-	passed := true
-	var errorMsg string
+	if !data.ExpectCnameTarget.IsNull() && data.ExpectCnameTarget.ValueString() != "" {
+		expected := dns.Fqdn(data.ExpectCnameTarget.ValueString())
+		if !slices.ContainsFunc(chain, func(cname *dns.CNAME) bool { return dns.IsSubDomain(expected, cname.Target) }) {
+			errorMsg.WriteString(fmt.Sprintf("No CNAME record in the chain points to %s. ", expected))
+		}
+	}
+
+	// Check forward-confirmed reverse DNS of every address
+	if data.VerifyReverse.ValueBool() {
+		reverse := make([]dnsReverseResult, 0, len(result))
+		for _, address := range result {
+			check := client.forwardConfirmed(ctx, address, retries, retryDelay)
+			if !check.forwardConfirmed {
+				errorMsg.WriteString(fmt.Sprintf("Reverse DNS check failed for %s: %s. ", address, check.err))
+			}
+			reverse = append(reverse, check)
+		}
+		data.LastReverseResults = dnsReverseValues(reverse)
+	}
+
+	// Validate the chain of trust down to the answer
+	data.LastDnssecValid = types.BoolValue(false)
+	data.LastRrsigDaysUntilExpiry = types.Int64Value(0)
+	if data.ValidateDnssec.ValueBool() {
+		validator := newDnssecValidator(client, anchors)
+		sigs, err := validator.validateAnswer(ctx, response, qtype)
+		data.LastRrsigDaysUntilExpiry = types.Int64Value(rrsigDaysUntilExpiry(sigs, validator.now))
+		if err != nil {
+			errorMsg.WriteString(fmt.Sprintf("DNSSEC validation failed: %s. ", err))
+		} else {
+			data.LastDnssecValid = types.BoolValue(true)
+		}
+	}
 
 	// If an expected result is specified, check if it's in the actual results
 	if !data.ExpectResult.IsNull() && data.ExpectResult.ValueString() != "" {
-		expectResult := data.ExpectResult.ValueString()
-		found := false
+		if !slices.Contains(result, data.ExpectResult.ValueString()) {
+			errorMsg.WriteString(fmt.Sprintf("Expected result '%s' not found in DNS response. ", data.ExpectResult.ValueString()))
+		}
+	}
 
-		for _, res := range result {
-			if res == expectResult {
-				found = true
-				break
-			}
+	for _, expected := range expectResults {
+		if !slices.Contains(result, expected) {
+			errorMsg.WriteString(fmt.Sprintf("Expected result '%s' not found in DNS response. ", expected))
+		}
+	}
+
+	if !data.ExpectExact.IsNull() {
+		missing, unexpected := dnsValueSetDiff(expectExact, result)
+		if len(missing) > 0 {
+			errorMsg.WriteString(fmt.Sprintf("Missing records: %s. ", strings.Join(missing, ", ")))
 		}
+		if len(unexpected) > 0 {
+			errorMsg.WriteString(fmt.Sprintf("Unexpected records: %s. ", strings.Join(unexpected, ", ")))
+		}
+	}
+
+	if !data.ExpectCount.IsNull() && int64(len(answers)) != data.ExpectCount.ValueInt64() {
+		errorMsg.WriteString(fmt.Sprintf("Expected %d records but got %d. ", data.ExpectCount.ValueInt64(), len(answers)))
+	}
 
-		if !found {
-			passed = false
-			errorMsg = fmt.Sprintf("Expected result '%s' not found in DNS response", expectResult)
+	if expectRegex != nil && !slices.ContainsFunc(result, expectRegex.MatchString) {
+		errorMsg.WriteString(fmt.Sprintf("No record matches '%s'. ", expectRegex.String()))
+	}
+
+	for _, rr := range answers {
+		ttl := int64(rr.Header().Ttl)
+		if !data.ExpectTtlMax.IsNull() && ttl > data.ExpectTtlMax.ValueInt64() {
+			errorMsg.WriteString(fmt.Sprintf("TTL of %s is %d, above the maximum of %d. ", dnsRecordValue(rr), ttl, data.ExpectTtlMax.ValueInt64()))
+		}
+		if !data.ExpectTtlMin.IsNull() && ttl < data.ExpectTtlMin.ValueInt64() {
+			errorMsg.WriteString(fmt.Sprintf("TTL of %s is %d, below the minimum of %d. ", dnsRecordValue(rr), ttl, data.ExpectTtlMin.ValueInt64()))
 		}
 	}
 
 	// Set the test result
+	passed := errorMsg.Len() == 0
 	data.TestPassed = types.BoolValue(passed)
 
 	// Set error message if test failed
 	if !passed {
-		data.Error = types.StringValue(errorMsg)
+		data.Error = types.StringValue(errorMsg.String())
 	} else {
 		data.Error = types.StringValue("")
 	}
 
 	return nil
 }
+
+// dnsNeedsResponse reports whether a test uses settings or assertions that
+// need the DNS response itself, which the operating system resolver does
+// not expose.
+func dnsNeedsResponse(data *DnsTestResourceModel, expect string) bool {
+	return data.Transport.ValueString() != "" ||
+		data.EdnsBufferSize.ValueInt64() != 0 ||
+		data.DnssecOk.ValueBool() ||
+		data.ValidateDnssec.ValueBool() ||
+		expect != dnsExpectAnswer ||
+		!data.ExpectTtlMax.IsNull() ||
+		!data.ExpectTtlMin.IsNull() ||
+		!data.MaxCnameChainLength.IsNull() ||
+		data.ExpectCnameTarget.ValueString() != ""
+}
+
+// dnsValueSetDiff compares record values as sets and returns the expected
+// values that are missing and the actual values that were not expected.
+func dnsValueSetDiff(expected, actual []string) ([]string, []string) {
+	var missing, unexpected []string
+	for _, value := range expected {
+		if !slices.Contains(actual, value) {
+			missing = append(missing, value)
+		}
+	}
+	for _, value := range actual {
+		if !slices.Contains(expected, value) && !slices.Contains(unexpected, value) {
+			unexpected = append(unexpected, value)
+		}
+	}
+	return missing, unexpected
+}
@@ -2,13 +2,23 @@ package provider
 
 import (
 	"context"
+	"crypto"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/miekg/dns"
 )
 
 // TestDnsTestResource_runTest tests the DNS test resource's runTest function.
@@ -135,6 +145,931 @@ func TestDnsTestResource_runTest(t *testing.T) {
 	})
 }
 
+// startTestDnsServer serves the records, given in zone file format, over UDP
+// and TCP on the same port of 127.0.0.1 and returns the server address.
+func startTestDnsServer(t *testing.T, records ...string) string {
+	t.Helper()
+
+	handler := newTestDnsHandler(t, records...)
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on UDP: %v", err)
+	}
+	listener, err := net.Listen("tcp", packetConn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("failed to listen on TCP: %v", err)
+	}
+
+	serveTestDns(t, &dns.Server{PacketConn: packetConn, Handler: handler})
+	serveTestDns(t, &dns.Server{Listener: listener, Handler: handler})
+
+	return packetConn.LocalAddr().String()
+}
+
+// serveTestDns runs the server until the test finishes.
+func serveTestDns(t *testing.T, server *dns.Server) {
+	t.Helper()
+
+	started := make(chan struct{})
+	server.NotifyStartedFunc = func() { close(started) }
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = server.Shutdown() })
+}
+
+// newTestDnsHandler answers queries from the records, given in zone file
+// format. CNAME records are followed within the served records, unknown
+// names are answered with NXDOMAIN, EDNS0 options are echoed back, RRSIG
+// records are included when the DO bit is set and UDP responses larger than
+// the requester's buffer size are truncated.
+func newTestDnsHandler(t *testing.T, records ...string) dns.Handler {
+	t.Helper()
+
+	zone := make(map[string][]dns.RR)
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			t.Fatalf("invalid test record %q: %v", record, err)
+		}
+		name := strings.ToLower(rr.Header().Name)
+		zone[name] = append(zone[name], rr)
+	}
+
+	return dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		msg := new(dns.Msg)
+		msg.SetReply(req)
+		msg.Authoritative = true
+
+		question := req.Question[0]
+		name := strings.ToLower(question.Name)
+		dnssecOK := req.IsEdns0() != nil && req.IsEdns0().Do()
+		if _, ok := zone[name]; !ok {
+			msg.Rcode = dns.RcodeNameError
+		}
+
+		// Follow CNAME records to the requested type
+		for range 8 {
+			var target string
+			for _, rr := range zone[name] {
+				switch {
+				case rr.Header().Rrtype == question.Qtype:
+					msg.Answer = append(msg.Answer, rr)
+				case rr.Header().Rrtype == dns.TypeCNAME:
+					msg.Answer = append(msg.Answer, rr)
+					if cname, ok := rr.(*dns.CNAME); ok {
+						target = strings.ToLower(cname.Target)
+					}
+				case dnssecOK && rr.Header().Rrtype == dns.TypeRRSIG:
+					if sig, ok := rr.(*dns.RRSIG); ok && (sig.TypeCovered == question.Qtype || sig.TypeCovered == dns.TypeCNAME) {
+						msg.Answer = append(msg.Answer, rr)
+					}
+				}
+			}
+			if target == "" || question.Qtype == dns.TypeCNAME {
+				break
+			}
+			name = target
+		}
+
+		bufferSize := dns.MinMsgSize
+		if opt := req.IsEdns0(); opt != nil {
+			bufferSize = int(opt.UDPSize())
+			msg.SetEdns0(opt.UDPSize(), opt.Do())
+		}
+
+		if _, udp := w.RemoteAddr().(*net.UDPAddr); udp && msg.Len() > bufferSize {
+			msg.Answer = nil
+			msg.Truncated = true
+		}
+
+		_ = w.WriteMsg(msg)
+	})
+}
+
+// TestDnsClient_recordTypes tests querying and decoding the supported record types.
+func TestDnsClient_recordTypes(t *testing.T) {
+	var manyTxt []string
+	for i := range 20 {
+		manyTxt = append(manyTxt, fmt.Sprintf(`big.example.com. 300 IN TXT "record %02d padding padding padding padding"`, i))
+	}
+
+	server := startTestDnsServer(t, append(manyTxt,
+		"example.com. 3600 IN SOA ns1.example.com. hostmaster.example.com. 2024010101 7200 900 1209600 300",
+		`example.com. 300 IN CAA 0 issue "letsencrypt.org"`,
+		"example.com. 300 IN HTTPS 1 . alpn=h2,h3",
+		"_sip._tcp.example.com. 300 IN SRV 10 60 5060 sip.example.com.",
+		`example.com. 300 IN NAPTR 100 10 "S" "SIP+D2T" "" _sip._tcp.example.com.`,
+		"www.example.com. 60 IN CNAME web.example.com.",
+		"web.example.com. 120 IN A 192.0.2.10",
+		"10.2.0.192.in-addr.arpa. 300 IN PTR web.example.com.",
+	)...)
+
+	client := dnsClient{servers: []string{server}, timeout: 2 * time.Second}
+
+	tests := []struct {
+		hostname   string
+		recordType string
+		value      string
+		fields     map[string]string
+	}{
+		{"example.com", "SOA", "ns1.example.com. hostmaster.example.com. 2024010101 7200 900 1209600 300", map[string]string{"serial": "2024010101", "minimum": "300"}},
+		{"example.com", "CAA", `0 issue "letsencrypt.org"`, map[string]string{"tag": "issue", "value": "letsencrypt.org"}},
+		{"example.com", "HTTPS", `1 . alpn="h2,h3"`, map[string]string{"priority": "1", "target": ".", "params": "alpn=h2,h3"}},
+		{"_sip._tcp.example.com", "SRV", "10 60 5060 sip.example.com.", map[string]string{"port": "5060", "target": "sip.example.com."}},
+		{"example.com", "NAPTR", `100 10 "S" "SIP+D2T" "" _sip._tcp.example.com.`, map[string]string{"service": "SIP+D2T"}},
+		{"www.example.com", "A", "192.0.2.10", map[string]string{"address": "192.0.2.10"}},
+		{"192.0.2.10", "PTR", "web.example.com.", map[string]string{"target": "web.example.com."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.recordType, func(t *testing.T) {
+			qtype := dnsRecordTypes[tt.recordType]
+			response, _, err := client.exchange(context.Background(), dnsQueryName(tt.hostname, qtype), qtype)
+			if err != nil {
+				t.Fatalf("exchange failed: %v", err)
+			}
+
+			answers := dnsAnswers(response, qtype)
+			if len(answers) != 1 {
+				t.Fatalf("expected 1 answer, got %d: %v", len(answers), response.Answer)
+			}
+			if value := dnsRecordValue(answers[0]); value != tt.value {
+				t.Errorf("expected value %q, got %q", tt.value, value)
+			}
+			fields := dnsRecordFields(answers[0])
+			for k, v := range tt.fields {
+				if fields[k] != v {
+					t.Errorf("expected field %s=%q, got %q", k, v, fields[k])
+				}
+			}
+		})
+	}
+
+	t.Run("truncated response is retried over TCP", func(t *testing.T) {
+		response, _, err := client.exchange(context.Background(), "big.example.com.", dns.TypeTXT)
+		if err != nil {
+			t.Fatalf("exchange failed: %v", err)
+		}
+		if len(dnsAnswers(response, dns.TypeTXT)) != 20 {
+			t.Errorf("expected 20 TXT records, got %d", len(response.Answer))
+		}
+	})
+
+	t.Run("structured records", func(t *testing.T) {
+		response, _, err := client.exchange(context.Background(), "www.example.com.", dns.TypeA)
+		if err != nil {
+			t.Fatalf("exchange failed: %v", err)
+		}
+
+		records := dnsRecordValues(dnsAnswers(response, dns.TypeA)).Elements()
+		if len(records) != 1 {
+			t.Fatalf("expected 1 record, got %d", len(records))
+		}
+		record, ok := records[0].(types.Object)
+		if !ok {
+			t.Fatalf("unexpected record value %T", records[0])
+		}
+		if !record.Attributes()["ttl"].Equal(types.Int64Value(120)) || !record.Attributes()["name"].Equal(types.StringValue("web.example.com.")) {
+			t.Errorf("unexpected record %v", record)
+		}
+	})
+}
+
+// TestParseDnsResolver tests resolver and transport parsing.
+func TestParseDnsResolver(t *testing.T) {
+	tests := []struct {
+		resolver  string
+		transport string
+		server    string
+		used      string
+		wantErr   bool
+	}{
+		{"", "", "", "udp", false},
+		{"", "tcp", "", "tcp", false},
+		{"1.1.1.1", "", "1.1.1.1:53", "udp", false},
+		{"10.0.0.2:5353", "tcp", "10.0.0.2:5353", "tcp", false},
+		{"2606:4700:4700::1111", "", "[2606:4700:4700::1111]:53", "udp", false},
+		{"[2606:4700:4700::1111]", "", "[2606:4700:4700::1111]:53", "udp", false},
+		{"[2606:4700:4700::1111]:5353", "", "[2606:4700:4700::1111]:5353", "udp", false},
+		{"tls://dns.google", "", "dns.google:853", "dot", false},
+		{"tls://1.1.1.1:8853", "dot", "1.1.1.1:8853", "dot", false},
+		{"dns.google", "dot", "dns.google:853", "dot", false},
+		{"https://cloudflare-dns.com/dns-query", "", "https://cloudflare-dns.com/dns-query", "doh", false},
+		{"dns.google", "doh", "https://dns.google:443/dns-query", "doh", false},
+		{"https://cloudflare-dns.com/dns-query", "udp", "", "", true},
+		{"tls://dns.google", "doh", "", "", true},
+		{"quic://dns.adguard.com", "", "", "", true},
+		{"1.1.1.1", "quic", "", "", true},
+		{"", "dot", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resolver+"/"+tt.transport, func(t *testing.T) {
+			server, used, err := parseDnsResolver(tt.resolver, tt.transport)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got server %q transport %q", server, used)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if server != tt.server || used != tt.used {
+				t.Errorf("expected %q over %s, got %q over %s", tt.server, tt.used, server, used)
+			}
+		})
+	}
+}
+
+// TestDnsClient_systemResolver tests answering queries through the operating
+// system resolver when no nameservers are configured.
+func TestDnsClient_systemResolver(t *testing.T) {
+	client := dnsClient{timeout: 2 * time.Second}
+
+	response, _, err := client.exchange(context.Background(), "localhost.", dns.TypeA)
+	if err != nil {
+		t.Fatalf("failed to look up localhost: %v", err)
+	}
+	answers := dnsAnswers(response, dns.TypeA)
+	if len(answers) == 0 || dnsRecordValue(answers[0]) != "127.0.0.1" {
+		t.Errorf("expected 127.0.0.1 for localhost, got %v", response.Answer)
+	}
+
+	if _, _, err := client.exchange(context.Background(), "example.com.", dns.TypeSOA); err == nil {
+		t.Errorf("expected error for a record type the system resolver cannot look up")
+	}
+
+	for _, address := range []string{"192.0.2.10", "2001:db8::1"} {
+		name, err := dns.ReverseAddr(address)
+		if err != nil {
+			t.Fatalf("failed to build reverse name: %v", err)
+		}
+		if ip := dnsReverseNameAddress(name); ip == nil || !ip.Equal(net.ParseIP(address)) {
+			t.Errorf("expected %s from %s, got %v", address, name, ip)
+		}
+	}
+	if ip := dnsReverseNameAddress("www.example.com."); ip != nil {
+		t.Errorf("expected no address for a forward name, got %v", ip)
+	}
+}
+
+// TestDnsTestResource_systemResolver tests that the original record types
+// are looked up through the operating system resolver when no resolver is
+// set, so that hosts file entries resolve.
+func TestDnsTestResource_systemResolver(t *testing.T) {
+	r := &DnsTestResource{clientConfig: &TerraProbeClientConfig{}}
+
+	model := &DnsTestResourceModel{
+		Hostname:      types.StringValue("localhost"),
+		RecordType:    types.StringValue("A"),
+		ExpectResult:  types.StringValue("127.0.0.1"),
+		ExpectResults: types.ListNull(types.StringType),
+		ExpectExact:   types.ListNull(types.StringType),
+	}
+	if err := r.runTest(context.Background(), model); err != nil {
+		t.Fatalf("runTest failed: %v", err)
+	}
+	if !model.TestPassed.ValueBool() {
+		t.Errorf("expected localhost to resolve through the system resolver, got error %q", model.Error.ValueString())
+	}
+}
+
+// TestDnsTestResource_transports tests querying a resolver on a custom port
+// over each transport.
+func TestDnsTestResource_transports(t *testing.T) {
+	records := []string{"app.example.com. 300 IN A 192.0.2.20"}
+	server := startTestDnsServer(t, records...)
+
+	r := &DnsTestResource{clientConfig: &TerraProbeClientConfig{}}
+
+	for _, transport := range []string{"udp", "tcp"} {
+		t.Run(transport, func(t *testing.T) {
+			model := &DnsTestResourceModel{
+				Name:           types.StringValue("Custom port"),
+				Hostname:       types.StringValue("app.example.com"),
+				RecordType:     types.StringValue("A"),
+				Resolver:       types.StringValue(server),
+				Transport:      types.StringValue(transport),
+				ExpectResult:   types.StringValue("192.0.2.20"),
+				Timeout:        types.Int64Value(2),
+				EdnsBufferSize: types.Int64Value(0),
+				DnssecOk:       types.BoolValue(false),
+				Retries:        types.Int64Value(0),
+				RetryDelay:     types.Int64Value(0),
+			}
+
+			if err := r.runTest(context.Background(), model); err != nil {
+				t.Fatalf("runTest failed: %v", err)
+			}
+			if !model.TestPassed.ValueBool() {
+				t.Errorf("expected test to pass, got error: %s", model.Error.ValueString())
+			}
+		})
+	}
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		for _, model := range []*DnsTestResourceModel{
+			{Resolver: types.StringValue("https://dns.example.com/dns-query"), Transport: types.StringValue("tcp")},
+			{Resolver: types.StringValue(server), EdnsBufferSize: types.Int64Value(100)},
+		} {
+			model.Hostname = types.StringValue("app.example.com")
+			model.RecordType = types.StringValue("A")
+			if err := r.runTest(context.Background(), model); err == nil {
+				t.Errorf("expected configuration error for %+v", model)
+			}
+		}
+	})
+
+	// The TLS certificate of httptest servers is valid for 127.0.0.1
+	tlsServer := httptest.NewUnstartedServer(http.NotFoundHandler())
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+	transport, ok := tlsServer.Client().Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("unexpected transport %T", tlsServer.Client().Transport)
+	}
+
+	t.Run("dot", func(t *testing.T) {
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsServer.TLS)
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		serveTestDns(t, &dns.Server{Listener: listener, Net: "tcp-tls", Handler: newTestDnsHandler(t, records...)})
+
+		address, used, err := parseDnsResolver("tls://"+listener.Addr().String(), "")
+		if err != nil {
+			t.Fatalf("parseDnsResolver failed: %v", err)
+		}
+		client := dnsClient{servers: []string{address}, transport: used, timeout: 2 * time.Second, tlsConfig: transport.TLSClientConfig}
+
+		response, _, err := client.exchange(context.Background(), "app.example.com.", dns.TypeA)
+		if err != nil {
+			t.Fatalf("exchange failed: %v", err)
+		}
+		if len(dnsAnswers(response, dns.TypeA)) != 1 {
+			t.Errorf("expected 1 answer, got %v", response.Answer)
+		}
+	})
+
+	t.Run("doh", func(t *testing.T) {
+		var contentType string
+		tlsServer.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			contentType = req.Header.Get("Content-Type")
+			body, _ := io.ReadAll(req.Body)
+			query := new(dns.Msg)
+			if err := query.Unpack(body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			response, err := dns.Exchange(query, server)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			packed, _ := response.Pack()
+			w.Header().Set("Content-Type", "application/dns-message")
+			_, _ = w.Write(packed)
+		})
+
+		client := dnsClient{servers: []string{tlsServer.URL + "/dns-query"}, transport: dnsTransportDoH, timeout: 2 * time.Second, httpClient: tlsServer.Client()}
+
+		response, _, err := client.exchange(context.Background(), "app.example.com.", dns.TypeA)
+		if err != nil {
+			t.Fatalf("exchange failed: %v", err)
+		}
+		if len(dnsAnswers(response, dns.TypeA)) != 1 {
+			t.Errorf("expected 1 answer, got %v", response.Answer)
+		}
+		if contentType != "application/dns-message" {
+			t.Errorf("unexpected request content type %q", contentType)
+		}
+
+		client.servers = []string{tlsServer.URL + "/missing"}
+		tlsServer.Config.Handler = http.NotFoundHandler()
+		if _, _, err := client.exchange(context.Background(), "app.example.com.", dns.TypeA); err == nil {
+			t.Errorf("expected error for HTTP 404")
+		}
+	})
+
+	t.Run("edns", func(t *testing.T) {
+		client := dnsClient{servers: []string{server}, timeout: 2 * time.Second, dnssecOK: true}
+		response, _, err := client.exchange(context.Background(), "app.example.com.", dns.TypeA)
+		if err != nil {
+			t.Fatalf("exchange failed: %v", err)
+		}
+		opt := response.IsEdns0()
+		if opt == nil || !opt.Do() || opt.UDPSize() != defaultEdnsBufferSize {
+			t.Errorf("expected EDNS0 with DO bit and default buffer size, got %v", opt)
+		}
+
+		client = dnsClient{servers: []string{server}, timeout: 2 * time.Second, ednsBufferSize: 4096}
+		response, _, err = client.exchange(context.Background(), "app.example.com.", dns.TypeA)
+		if err != nil {
+			t.Fatalf("exchange failed: %v", err)
+		}
+		opt = response.IsEdns0()
+		if opt == nil || opt.Do() || opt.UDPSize() != 4096 {
+			t.Errorf("expected EDNS0 without DO bit and 4096 byte buffer, got %v", opt)
+		}
+	})
+}
+
+// TestDnsTestResource_expectations tests set, count, regex and TTL matching.
+func TestDnsTestResource_expectations(t *testing.T) {
+	server := startTestDnsServer(t,
+		"lb.example.com. 60 IN A 192.0.2.1",
+		"lb.example.com. 60 IN A 192.0.2.2",
+		"lb.example.com. 3600 IN A 198.51.100.9",
+	)
+
+	r := &DnsTestResource{clientConfig: &TerraProbeClientConfig{}}
+	stringList := func(values ...string) types.List {
+		elements := make([]attr.Value, 0, len(values))
+		for _, v := range values {
+			elements = append(elements, types.StringValue(v))
+		}
+		return types.ListValueMust(types.StringType, elements)
+	}
+
+	tests := []struct {
+		name      string
+		configure func(*DnsTestResourceModel)
+		passed    bool
+		errorPart string
+	}{
+		{"all present", func(m *DnsTestResourceModel) {
+			m.ExpectResults = stringList("192.0.2.2", "192.0.2.1")
+		}, true, ""},
+		{"one missing", func(m *DnsTestResourceModel) {
+			m.ExpectResults = stringList("192.0.2.1", "192.0.2.3")
+		}, false, "'192.0.2.3' not found"},
+		{"exact match", func(m *DnsTestResourceModel) {
+			m.ExpectExact = stringList("198.51.100.9", "192.0.2.2", "192.0.2.1")
+		}, true, ""},
+		{"stale record", func(m *DnsTestResourceModel) {
+			m.ExpectExact = stringList("192.0.2.1", "192.0.2.2")
+		}, false, "Unexpected records: 198.51.100.9"},
+		{"count", func(m *DnsTestResourceModel) {
+			m.ExpectCount = types.Int64Value(2)
+		}, false, "Expected 2 records but got 3"},
+		{"regex", func(m *DnsTestResourceModel) {
+			m.ExpectRegex = types.StringValue(`^198\.51\.100\.`)
+		}, true, ""},
+		{"regex without match", func(m *DnsTestResourceModel) {
+			m.ExpectRegex = types.StringValue(`^203\.`)
+		}, false, "No record matches"},
+		{"ttl not lowered", func(m *DnsTestResourceModel) {
+			m.ExpectTtlMax = types.Int64Value(300)
+		}, false, "TTL of 198.51.100.9 is 3600, above the maximum of 300"},
+		{"ttl minimum", func(m *DnsTestResourceModel) {
+			m.ExpectTtlMin = types.Int64Value(60)
+		}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &DnsTestResourceModel{
+				Hostname:      types.StringValue("lb.example.com"),
+				RecordType:    types.StringValue("A"),
+				Resolver:      types.StringValue(server),
+				ExpectResults: types.ListNull(types.StringType),
+				ExpectExact:   types.ListNull(types.StringType),
+			}
+			tt.configure(model)
+
+			if err := r.runTest(context.Background(), model); err != nil {
+				t.Fatalf("runTest failed: %v", err)
+			}
+			if model.TestPassed.ValueBool() != tt.passed {
+				t.Fatalf("expected passed=%t, got error %q", tt.passed, model.Error.ValueString())
+			}
+			if !strings.Contains(model.Error.ValueString(), tt.errorPart) {
+				t.Errorf("expected error to contain %q, got %q", tt.errorPart, model.Error.ValueString())
+			}
+		})
+	}
+
+	t.Run("invalid regex", func(t *testing.T) {
+		model := &DnsTestResourceModel{
+			Hostname:    types.StringValue("lb.example.com"),
+			RecordType:  types.StringValue("A"),
+			Resolver:    types.StringValue(server),
+			ExpectRegex: types.StringValue("("),
+		}
+		if err := r.runTest(context.Background(), model); err == nil {
+			t.Errorf("expected configuration error for invalid regex")
+		}
+	})
+}
+
+// TestDnsTestResource_negative tests expecting NXDOMAIN, NODATA and
+// SERVFAIL responses.
+func TestDnsTestResource_negative(t *testing.T) {
+	server := startTestDnsServer(t, "app.example.com. 300 IN A 192.0.2.30")
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on UDP: %v", err)
+	}
+	serveTestDns(t, &dns.Server{PacketConn: packetConn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		msg := new(dns.Msg)
+		msg.SetRcode(req, dns.RcodeServerFailure)
+		_ = w.WriteMsg(msg)
+	})})
+	broken := packetConn.LocalAddr().String()
+
+	tests := []struct {
+		name       string
+		resolver   string
+		hostname   string
+		recordType string
+		expect     string
+		passed     bool
+		rcode      string
+		errorPart  string
+	}{
+		{"decommissioned name", server, "old.example.com", "A", "nxdomain", true, "NXDOMAIN", ""},
+		{"name still resolves", server, "app.example.com", "A", "nxdomain", false, "NOERROR", "expected NXDOMAIN for app.example.com. but got 1 A records"},
+		{"no records of the type", server, "app.example.com", "AAAA", "nodata", true, "NOERROR", ""},
+		{"nodata for a missing name", server, "old.example.com", "AAAA", "nodata", false, "NXDOMAIN", "expected NODATA for old.example.com. but got NXDOMAIN"},
+		{"answer for a missing name", server, "old.example.com", "A", "answer", false, "NXDOMAIN", "server returned NXDOMAIN"},
+		{"expected servfail", broken, "app.example.com", "A", "servfail", true, "SERVFAIL", ""},
+		{"unexpected servfail", broken, "app.example.com", "A", "answer", false, "SERVFAIL", "server returned SERVFAIL"},
+	}
+
+	r := &DnsTestResource{clientConfig: &TerraProbeClientConfig{}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &DnsTestResourceModel{
+				Hostname:   types.StringValue(tt.hostname),
+				RecordType: types.StringValue(tt.recordType),
+				Resolver:   types.StringValue(tt.resolver),
+				Expect:     types.StringValue(tt.expect),
+			}
+
+			if err := r.runTest(context.Background(), model); err != nil {
+				t.Fatalf("runTest failed: %v", err)
+			}
+			if model.TestPassed.ValueBool() != tt.passed {
+				t.Fatalf("expected passed=%t, got error %q", tt.passed, model.Error.ValueString())
+			}
+			if model.Rcode.ValueString() != tt.rcode {
+				t.Errorf("expected rcode %s, got %s", tt.rcode, model.Rcode.ValueString())
+			}
+			if !strings.Contains(model.Error.ValueString(), tt.errorPart) {
+				t.Errorf("expected error to contain %q, got %q", tt.errorPart, model.Error.ValueString())
+			}
+		})
+	}
+
+	t.Run("invalid expect", func(t *testing.T) {
+		model := &DnsTestResourceModel{
+			Hostname:   types.StringValue("app.example.com"),
+			RecordType: types.StringValue("A"),
+			Resolver:   types.StringValue(server),
+			Expect:     types.StringValue("refused"),
+		}
+		if err := r.runTest(context.Background(), model); err == nil {
+			t.Errorf("expected configuration error for an unsupported expect")
+		}
+	})
+}
+
+// TestDnsTestResource_cnameAndReverse tests CNAME chain assertions and
+// forward-confirmed reverse DNS.
+func TestDnsTestResource_cnameAndReverse(t *testing.T) {
+	server := startTestDnsServer(t,
+		"www.example.com. 300 IN CNAME www.example.com.cdn.example.net.",
+		"www.example.com.cdn.example.net. 60 IN CNAME edge.cdn.example.net.",
+		"edge.cdn.example.net. 20 IN A 192.0.2.40",
+		"mail.example.com. 300 IN A 192.0.2.25",
+		"25.2.0.192.in-addr.arpa. 300 IN PTR mail.example.com.",
+		"spoofed.example.com. 300 IN A 192.0.2.26",
+		"26.2.0.192.in-addr.arpa. 300 IN PTR other.example.com.",
+		"other.example.com. 300 IN A 192.0.2.99",
+		"noptr.example.com. 300 IN A 192.0.2.27",
+	)
+
+	r := &DnsTestResource{clientConfig: &TerraProbeClientConfig{}}
+
+	newModel := func(hostname string) *DnsTestResourceModel {
+		return &DnsTestResourceModel{
+			Hostname:   types.StringValue(hostname),
+			RecordType: types.StringValue("A"),
+			Resolver:   types.StringValue(server),
+		}
+	}
+
+	t.Run("chain", func(t *testing.T) {
+		model := newModel("www.example.com")
+		model.MaxCnameChainLength = types.Int64Value(2)
+		model.ExpectCnameTarget = types.StringValue("cdn.example.net")
+
+		if err := r.runTest(context.Background(), model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !model.TestPassed.ValueBool() {
+			t.Fatalf("expected test to pass, got error: %s", model.Error.ValueString())
+		}
+
+		hops := model.LastCnameChain.Elements()
+		if len(hops) != 2 {
+			t.Fatalf("expected 2 CNAME hops, got %v", model.LastCnameChain)
+		}
+		last, ok := hops[1].(types.Object)
+		if !ok {
+			t.Fatalf("unexpected hop value %T", hops[1])
+		}
+		if !last.Attributes()["target"].Equal(types.StringValue("edge.cdn.example.net.")) || !last.Attributes()["ttl"].Equal(types.Int64Value(60)) {
+			t.Errorf("unexpected last hop %v", last)
+		}
+	})
+
+	t.Run("chain too long", func(t *testing.T) {
+		model := newModel("www.example.com")
+		model.MaxCnameChainLength = types.Int64Value(1)
+
+		if err := r.runTest(context.Background(), model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if model.TestPassed.ValueBool() || !strings.Contains(model.Error.ValueString(), "CNAME chain has 2 records") {
+			t.Errorf("expected chain length failure, got %q", model.Error.ValueString())
+		}
+	})
+
+	t.Run("missing intermediate target", func(t *testing.T) {
+		model := newModel("www.example.com")
+		model.ExpectCnameTarget = types.StringValue("othercdn.example.org")
+
+		if err := r.runTest(context.Background(), model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if model.TestPassed.ValueBool() || !strings.Contains(model.Error.ValueString(), "points to othercdn.example.org.") {
+			t.Errorf("expected CNAME target failure, got %q", model.Error.ValueString())
+		}
+	})
+
+	t.Run("CNAME record type", func(t *testing.T) {
+		tests := []struct {
+			hostname  string
+			canonical string
+		}{
+			{"www.example.com", "www.example.com.cdn.example.net."},
+			{"mail.example.com", "mail.example.com."}, // no alias
+		}
+		for _, tt := range tests {
+			model := newModel(tt.hostname)
+			model.RecordType = types.StringValue("CNAME")
+			model.ExpectResult = types.StringValue(tt.canonical)
+
+			if err := r.runTest(context.Background(), model); err != nil {
+				t.Fatalf("runTest failed: %v", err)
+			}
+			if !model.TestPassed.ValueBool() {
+				t.Errorf("expected %s to pass, got error: %s", tt.hostname, model.Error.ValueString())
+			}
+			if model.LastResult.ValueString() != tt.canonical {
+				t.Errorf("expected last_result %q for %s, got %q", tt.canonical, tt.hostname, model.LastResult.ValueString())
+			}
+		}
+	})
+
+	tests := []struct {
+		hostname  string
+		passed    bool
+		ptrNames  int
+		errorPart string
+	}{
+		{"mail.example.com", true, 1, ""},
+		{"spoofed.example.com", false, 1, "no PTR name of 192.0.2.26 resolves back to it"},
+		{"noptr.example.com", false, 0, "server returned NXDOMAIN for 27.2.0.192.in-addr.arpa."},
+	}
+	for _, tt := range tests {
+		t.Run("reverse "+tt.hostname, func(t *testing.T) {
+			model := newModel(tt.hostname)
+			model.VerifyReverse = types.BoolValue(true)
+
+			if err := r.runTest(context.Background(), model); err != nil {
+				t.Fatalf("runTest failed: %v", err)
+			}
+			if model.TestPassed.ValueBool() != tt.passed {
+				t.Fatalf("expected passed=%t, got error %q", tt.passed, model.Error.ValueString())
+			}
+			if !strings.Contains(model.Error.ValueString(), tt.errorPart) {
+				t.Errorf("expected error to contain %q, got %q", tt.errorPart, model.Error.ValueString())
+			}
+
+			results := model.LastReverseResults.Elements()
+			if len(results) != 1 {
+				t.Fatalf("expected 1 reverse result, got %v", model.LastReverseResults)
+			}
+			check, ok := results[0].(types.Object)
+			if !ok {
+				t.Fatalf("unexpected result value %T", results[0])
+			}
+			names, ok := check.Attributes()["ptr_names"].(types.List)
+			if !ok || len(names.Elements()) != tt.ptrNames {
+				t.Errorf("expected %d PTR names, got %v", tt.ptrNames, check.Attributes()["ptr_names"])
+			}
+		})
+	}
+
+	t.Run("reverse requires an address record type", func(t *testing.T) {
+		model := newModel("example.com")
+		model.RecordType = types.StringValue("MX")
+		model.VerifyReverse = types.BoolValue(true)
+		if err := r.runTest(context.Background(), model); err == nil {
+			t.Errorf("expected configuration error for verify_reverse with MX")
+		}
+	})
+}
+
+// testDnssecKey is a DNSKEY with its private key.
+type testDnssecKey struct {
+	key    *dns.DNSKEY
+	signer crypto.Signer
+}
+
+// newTestDnssecKey generates a key signing key for a zone.
+func newTestDnssecKey(t *testing.T, zone string) testDnssecKey {
+	t.Helper()
+
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: zone, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     dns.ZONE | dns.SEP,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	private, err := key.Generate(256)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		t.Fatalf("unexpected private key %T", private)
+	}
+	return testDnssecKey{key: key, signer: signer}
+}
+
+// sign returns the records of rrset in zone file format followed by an RRSIG
+// over them that expires after validFor.
+func (k testDnssecKey) sign(t *testing.T, validFor time.Duration, rrset ...dns.RR) []string {
+	t.Helper()
+
+	now := time.Now()
+	sig := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Name: rrset[0].Header().Name, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: rrset[0].Header().Ttl},
+		KeyTag:     k.key.KeyTag(),
+		SignerName: k.key.Hdr.Name,
+		Algorithm:  k.key.Algorithm,
+		Inception:  uint32(now.Add(-time.Hour).Unix()),
+		Expiration: uint32(now.Add(validFor).Unix()),
+	}
+	if err := sig.Sign(k.signer, rrset); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	records := make([]string, 0, len(rrset)+1)
+	for _, rr := range rrset {
+		records = append(records, rr.String())
+	}
+	return append(records, sig.String())
+}
+
+// TestDnsTestResource_dnssec tests validating the chain of trust from a
+// trust anchor for example. down to a record in the delegated sub.example.
+// zone.
+func TestDnsTestResource_dnssec(t *testing.T) {
+	parent := newTestDnssecKey(t, "example.")
+	child := newTestDnssecKey(t, "sub.example.")
+	other := newTestDnssecKey(t, "example.")
+	validFor := 30*24*time.Hour + 12*time.Hour
+
+	mustRR := func(record string) dns.RR {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			t.Fatalf("invalid record %q: %v", record, err)
+		}
+		return rr
+	}
+
+	// zone returns the signed records, with the A record signed by the
+	// child key unless the test replaces it
+	zone := func(answer []string) []string {
+		var records []string
+		records = append(records, parent.sign(t, validFor, parent.key)...)
+		records = append(records, parent.sign(t, validFor, child.key.ToDS(dns.SHA256))...)
+		records = append(records, child.sign(t, validFor, child.key)...)
+		return append(records, answer...)
+	}
+	aRecord := mustRR("www.sub.example. 300 IN A 192.0.2.80")
+
+	tests := []struct {
+		name      string
+		records   []string
+		anchor    string
+		passed    bool
+		errorPart string
+		days      int64
+	}{
+		{"valid chain", zone(child.sign(t, validFor, aRecord)), parent.key.ToDS(dns.SHA256).String(), true, "", 30},
+		{"DNSKEY anchor", zone(child.sign(t, validFor, aRecord)), parent.key.String(), true, "", 30},
+		{"expired signature", zone(child.sign(t, -time.Hour, aRecord)), parent.key.ToDS(dns.SHA256).String(), false, "expired", -1},
+		{"tampered record", zone(append(child.sign(t, validFor, mustRR("www.sub.example. 300 IN A 192.0.2.81"))[1:], aRecord.String())), parent.key.ToDS(dns.SHA256).String(), false, "invalid signature over www.sub.example. A", 30},
+		{"unsigned record", zone([]string{aRecord.String()}), parent.key.ToDS(dns.SHA256).String(), false, "www.sub.example. A is not signed", 0},
+		{"key rolled without updating the anchor", zone(child.sign(t, validFor, aRecord)), other.key.ToDS(dns.SHA256).String(), false, "no DNSKEY of example. matches", 30},
+	}
+
+	r := &DnsTestResource{clientConfig: &TerraProbeClientConfig{}}
+
+	// validate runs a validating test of hostname against the records
+	validate := func(t *testing.T, hostname string, records []string, anchor string) *DnsTestResourceModel {
+		t.Helper()
+
+		server := startTestDnsServer(t, records...)
+		model := &DnsTestResourceModel{
+			Hostname:       types.StringValue(hostname),
+			RecordType:     types.StringValue("A"),
+			Resolver:       types.StringValue(server),
+			ValidateDnssec: types.BoolValue(true),
+			TrustAnchors:   types.ListValueMust(types.StringType, []attr.Value{types.StringValue(anchor)}),
+			ExpectResults:  types.ListNull(types.StringType),
+			ExpectExact:    types.ListNull(types.StringType),
+		}
+
+		if err := r.runTest(context.Background(), model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		return model
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := validate(t, "www.sub.example", tt.records, tt.anchor)
+			if model.TestPassed.ValueBool() != tt.passed || model.LastDnssecValid.ValueBool() != tt.passed {
+				t.Fatalf("expected passed=%t, got passed=%t valid=%t error %q", tt.passed, model.TestPassed.ValueBool(), model.LastDnssecValid.ValueBool(), model.Error.ValueString())
+			}
+			if !strings.Contains(model.Error.ValueString(), tt.errorPart) {
+				t.Errorf("expected error to contain %q, got %q", tt.errorPart, model.Error.ValueString())
+			}
+			if model.LastRrsigDaysUntilExpiry.ValueInt64() != tt.days {
+				t.Errorf("expected %d days until RRSIG expiry, got %d", tt.days, model.LastRrsigDaysUntilExpiry.ValueInt64())
+			}
+		})
+	}
+
+	t.Run("DS signed by the zone itself", func(t *testing.T) {
+		// The DS record of sub.example. is signed by the child key only, so
+		// authenticating it must not lead back to the keys of sub.example.
+		var records []string
+		records = append(records, parent.sign(t, validFor, parent.key)...)
+		records = append(records, child.sign(t, validFor, child.key.ToDS(dns.SHA256))...)
+		records = append(records, child.sign(t, validFor, child.key)...)
+		records = append(records, child.sign(t, validFor, aRecord)...)
+
+		model := validate(t, "www.sub.example", records, parent.key.ToDS(dns.SHA256).String())
+		if model.TestPassed.ValueBool() || model.LastDnssecValid.ValueBool() {
+			t.Fatalf("expected validation to fail")
+		}
+		if !strings.Contains(model.Error.ValueString(), "sub.example. DS is signed by the zone itself") {
+			t.Errorf("unexpected error %q", model.Error.ValueString())
+		}
+	})
+
+	t.Run("CNAME hops", func(t *testing.T) {
+		alias := mustRR("alias.sub.example. 300 IN CNAME www.sub.example.")
+
+		model := validate(t, "alias.sub.example", zone(append(child.sign(t, validFor, alias), child.sign(t, validFor, aRecord)...)), parent.key.ToDS(dns.SHA256).String())
+		if !model.TestPassed.ValueBool() || !model.LastDnssecValid.ValueBool() {
+			t.Fatalf("expected signed CNAME chain to validate, got error %q", model.Error.ValueString())
+		}
+
+		model = validate(t, "alias.sub.example", zone(append([]string{alias.String()}, child.sign(t, validFor, aRecord)...)), parent.key.ToDS(dns.SHA256).String())
+		if model.TestPassed.ValueBool() || model.LastDnssecValid.ValueBool() {
+			t.Fatalf("expected unsigned CNAME hop to fail validation")
+		}
+		if !strings.Contains(model.Error.ValueString(), "alias.sub.example. CNAME is not signed") {
+			t.Errorf("unexpected error %q", model.Error.ValueString())
+		}
+	})
+
+	t.Run("invalid trust anchor", func(t *testing.T) {
+		model := &DnsTestResourceModel{
+			Hostname:       types.StringValue("www.sub.example"),
+			RecordType:     types.StringValue("A"),
+			Resolver:       types.StringValue("127.0.0.1:53"),
+			ValidateDnssec: types.BoolValue(true),
+			TrustAnchors:   types.ListValueMust(types.StringType, []attr.Value{types.StringValue("example. IN A 192.0.2.1")}),
+		}
+		if err := r.runTest(context.Background(), model); err == nil {
+			t.Errorf("expected configuration error for a trust anchor that is not DS or DNSKEY")
+		}
+	})
+}
+
 // TestAccDnsTestResource is an acceptance test for the DNS test resource.
 func TestAccDnsTestResource(t *testing.T) {
 	// Skip in short mode as acceptance tests make real network connections
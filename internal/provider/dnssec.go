@@ -0,0 +1,297 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// defaultTrustAnchors are the DS records of the root zone key signing keys
+// published by IANA (KSK-2017 and KSK-2024).
+var defaultTrustAnchors = []string{
+	". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+	". IN DS 38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+// parseTrustAnchors parses DS or DNSKEY records in zone file format.
+func parseTrustAnchors(records []string) ([]dns.RR, error) {
+	anchors := make([]dns.RR, 0, len(records))
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trust anchor %q: %w", record, err)
+		}
+		switch rr.(type) {
+		case *dns.DS, *dns.DNSKEY:
+			anchors = append(anchors, rr)
+		default:
+			return nil, fmt.Errorf("invalid trust anchor %q: must be a DS or DNSKEY record", record)
+		}
+	}
+	return anchors, nil
+}
+
+// dnssecValidator validates the chain of trust from a set of trust anchors
+// down to an answer, following DS records from each zone to its parent.
+type dnssecValidator struct {
+	client  dnsClient
+	anchors []dns.RR
+	now     time.Time
+
+	// keys caches the authenticated DNSKEY records of each zone.
+	keys map[string][]*dns.DNSKEY
+	// pending holds the zones whose keys are being authenticated, so that
+	// a chain of signatures leading back to one of them is rejected.
+	pending map[string]bool
+}
+
+// newDnssecValidator returns a validator querying through client. Queries
+// are sent with the DO and CD flags so that records failing validation are
+// returned for inspection instead of SERVFAIL.
+func newDnssecValidator(client dnsClient, anchors []dns.RR) *dnssecValidator {
+	client.dnssecOK = true
+	client.checkingDisabled = true
+
+	return &dnssecValidator{
+		client:  client,
+		anchors: anchors,
+		now:     time.Now(),
+		keys:    make(map[string][]*dns.DNSKEY),
+		pending: make(map[string]bool),
+	}
+}
+
+// validateAnswer validates each CNAME followed from the question name and
+// the records of type qtype in a response, and returns the signatures
+// covering them.
+func (v *dnssecValidator) validateAnswer(ctx context.Context, response *dns.Msg, qtype uint16) ([]*dns.RRSIG, error) {
+	rrset := dnsAnswers(response, qtype)
+	if len(rrset) == 0 {
+		return nil, errors.New("no records to validate")
+	}
+
+	var allSigs []*dns.RRSIG
+	if len(response.Question) > 0 && qtype != dns.TypeCNAME {
+		for _, cname := range dnsCnameChain(response, response.Question[0].Name) {
+			sigs := dnssecSignatures(response.Answer, cname.Hdr.Name, dns.TypeCNAME)
+			allSigs = append(allSigs, sigs...)
+			if err := v.verifyRRset(ctx, []dns.RR{cname}, sigs); err != nil {
+				return allSigs, err
+			}
+		}
+	}
+
+	sigs := dnssecSignatures(response.Answer, rrset[0].Header().Name, qtype)
+	allSigs = append(allSigs, sigs...)
+	if err := v.verifyRRset(ctx, rrset, sigs); err != nil {
+		return allSigs, err
+	}
+	return allSigs, nil
+}
+
+// verifyRRset checks that at least one of the signatures over rrset is
+// currently valid and made by an authenticated key of the signer's zone.
+func (v *dnssecValidator) verifyRRset(ctx context.Context, rrset []dns.RR, sigs []*dns.RRSIG) error {
+	header := rrset[0].Header()
+	if len(sigs) == 0 {
+		return fmt.Errorf("%s %s is not signed", header.Name, dns.TypeToString[header.Rrtype])
+	}
+
+	var lastErr error
+	for _, sig := range sigs {
+		if !dns.IsSubDomain(sig.SignerName, header.Name) {
+			lastErr = fmt.Errorf("%s %s is signed by unrelated zone %s", header.Name, dns.TypeToString[header.Rrtype], sig.SignerName)
+			continue
+		}
+		// DS records belong to the parent zone and must be signed by it
+		if header.Rrtype == dns.TypeDS && dns.CanonicalName(sig.SignerName) == dns.CanonicalName(header.Name) {
+			lastErr = fmt.Errorf("%s DS is signed by the zone itself instead of its parent", header.Name)
+			continue
+		}
+		if !sig.ValidityPeriod(v.now) {
+			lastErr = fmt.Errorf("signature over %s %s by key %d is expired or not yet valid", header.Name, dns.TypeToString[header.Rrtype], sig.KeyTag)
+			continue
+		}
+
+		keys, err := v.zoneKeys(ctx, sig.SignerName)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		lastErr = fmt.Errorf("no DNSKEY of %s matches the signature over %s %s by key %d", sig.SignerName, header.Name, dns.TypeToString[header.Rrtype], sig.KeyTag)
+		for _, key := range keys {
+			if key.KeyTag() != sig.KeyTag || key.Algorithm != sig.Algorithm {
+				continue
+			}
+			if err := sig.Verify(key, rrset); err != nil {
+				lastErr = fmt.Errorf("invalid signature over %s %s by key %d: %w", header.Name, dns.TypeToString[header.Rrtype], sig.KeyTag, err)
+				continue
+			}
+			return nil
+		}
+	}
+
+	return lastErr
+}
+
+// zoneKeys returns the DNSKEY records of a zone after authenticating them
+// against a trust anchor for the zone or the DS records in its parent.
+func (v *dnssecValidator) zoneKeys(ctx context.Context, zone string) ([]*dns.DNSKEY, error) {
+	zone = dns.CanonicalName(zone)
+	if keys, ok := v.keys[zone]; ok {
+		return keys, nil
+	}
+	if v.pending[zone] {
+		return nil, fmt.Errorf("loop in the chain of trust at %s", zone)
+	}
+	v.pending[zone] = true
+	defer delete(v.pending, zone)
+
+	response, err := v.query(ctx, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return nil, err
+	}
+
+	keyset := dnsAnswers(response, dns.TypeDNSKEY)
+	if len(keyset) == 0 {
+		return nil, fmt.Errorf("no DNSKEY records found for %s", zone)
+	}
+	keys := make([]*dns.DNSKEY, 0, len(keyset))
+	for _, rr := range keyset {
+		if key, ok := rr.(*dns.DNSKEY); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	// Trust anchors for the zone take the place of its DS records
+	var delegation []dns.RR
+	for _, anchor := range v.anchors {
+		if strings.EqualFold(anchor.Header().Name, zone) {
+			delegation = append(delegation, anchor)
+		}
+	}
+
+	if len(delegation) == 0 {
+		if zone == "." {
+			return nil, errors.New("no trust anchor configured for the root zone")
+		}
+
+		dsResponse, err := v.query(ctx, zone, dns.TypeDS)
+		if err != nil {
+			return nil, err
+		}
+		delegation = dnsAnswers(dsResponse, dns.TypeDS)
+		if len(delegation) == 0 {
+			return nil, fmt.Errorf("no DS records found for %s, the delegation is insecure", zone)
+		}
+		if err := v.verifyRRset(ctx, delegation, dnssecSignatures(dsResponse.Answer, zone, dns.TypeDS)); err != nil {
+			return nil, err
+		}
+	}
+
+	// The key set must be signed by a key the delegation points to
+	var trusted []*dns.DNSKEY
+	for _, key := range keys {
+		if dnssecKeyMatches(key, delegation) {
+			trusted = append(trusted, key)
+		}
+	}
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("no DNSKEY of %s matches its DS records or trust anchors", zone)
+	}
+
+	lastErr := fmt.Errorf("DNSKEY records of %s are not signed by a trusted key", zone)
+	for _, sig := range dnssecSignatures(response.Answer, zone, dns.TypeDNSKEY) {
+		for _, key := range trusted {
+			if key.KeyTag() != sig.KeyTag || key.Algorithm != sig.Algorithm {
+				continue
+			}
+			if !sig.ValidityPeriod(v.now) {
+				lastErr = fmt.Errorf("signature over DNSKEY records of %s by key %d is expired or not yet valid", zone, sig.KeyTag)
+				continue
+			}
+			if err := sig.Verify(key, keyset); err != nil {
+				lastErr = fmt.Errorf("invalid signature over DNSKEY records of %s by key %d: %w", zone, sig.KeyTag, err)
+				continue
+			}
+
+			v.keys[zone] = keys
+			return keys, nil
+		}
+	}
+
+	return nil, lastErr
+}
+
+// query sends a single validation query and checks the response code.
+func (v *dnssecValidator) query(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	response, _, err := v.client.exchange(ctx, name, qtype)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s %s: %w", name, dns.TypeToString[qtype], err)
+	}
+	if response.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("server returned %s for %s %s", dns.RcodeToString[response.Rcode], name, dns.TypeToString[qtype])
+	}
+	return response, nil
+}
+
+// dnssecKeyMatches reports whether a key is referenced by one of the DS
+// records or is one of the DNSKEY records of a delegation.
+func dnssecKeyMatches(key *dns.DNSKEY, delegation []dns.RR) bool {
+	for _, rr := range delegation {
+		switch record := rr.(type) {
+		case *dns.DS:
+			if record.KeyTag != key.KeyTag() || record.Algorithm != key.Algorithm {
+				continue
+			}
+			if ds := key.ToDS(record.DigestType); ds != nil && strings.EqualFold(ds.Digest, record.Digest) {
+				return true
+			}
+		case *dns.DNSKEY:
+			if record.Algorithm == key.Algorithm && record.PublicKey == key.PublicKey {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dnssecSignatures returns the RRSIG records covering the records of a type
+// owned by name.
+func dnssecSignatures(records []dns.RR, name string, qtype uint16) []*dns.RRSIG {
+	var sigs []*dns.RRSIG
+	for _, rr := range records {
+		if sig, ok := rr.(*dns.RRSIG); ok && sig.TypeCovered == qtype && strings.EqualFold(sig.Hdr.Name, name) {
+			sigs = append(sigs, sig)
+		}
+	}
+	return sigs
+}
+
+// rrsigDaysUntilExpiry returns the whole days until the first of the
+// signatures expires, negative once it has expired.
+func rrsigDaysUntilExpiry(sigs []*dns.RRSIG, now time.Time) int64 {
+	if len(sigs) == 0 {
+		return 0
+	}
+
+	var remaining time.Duration
+	for i, sig := range sigs {
+		// Expiration is a serial number relative to now (RFC 4034 section 3.1.5)
+		delta := time.Duration(int32(sig.Expiration-uint32(now.Unix()))) * time.Second
+		if i == 0 || delta < remaining {
+			remaining = delta
+		}
+	}
+
+	days := int64(remaining / (24 * time.Hour))
+	if remaining < 0 && remaining%(24*time.Hour) != 0 {
+		days-- // Round towards the past for expired signatures
+	}
+	return days
+}
@@ -0,0 +1,511 @@
+package provider
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// defaultSpfMaxLookups is the limit on DNS lookups needed to evaluate an SPF
+// record (RFC 7208 section 4.6.4).
+const defaultSpfMaxLookups = 10
+
+// spfMechanisms are the mechanisms defined by RFC 7208 and whether each one
+// costs a DNS lookup.
+var spfMechanisms = map[string]bool{
+	"all":     false,
+	"include": true,
+	"a":       true,
+	"mx":      true,
+	"ptr":     true,
+	"ip4":     false,
+	"ip6":     false,
+	"exists":  true,
+}
+
+var (
+	spfModifierName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+	emailTagName    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	mtaStsPolicyId  = regexp.MustCompile(`^[A-Za-z0-9]{1,32}$`)
+)
+
+// spfTerm is a mechanism or modifier of an SPF record.
+type spfTerm struct {
+	// qualifier is empty when the mechanism has none, meaning "+".
+	qualifier string
+	name      string
+	// value is the domain-spec, address or modifier value.
+	value    string
+	modifier bool
+}
+
+// emailRecordHasVersion reports whether a TXT record starts with a version
+// tag such as v=spf1 or v=DMARC1.
+func emailRecordHasVersion(record, version string) bool {
+	if len(record) < len(version) || !strings.EqualFold(record[:len(version)], version) {
+		return false
+	}
+	rest := record[len(version):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == ';'
+}
+
+// lookupEmailRecord returns the TXT record of a name starting with version,
+// or an empty string when there is none. An empty version accepts any
+// record. More than one matching record is an error.
+func (c dnsClient) lookupEmailRecord(ctx context.Context, name, version string, retries int64, retryDelay time.Duration) (string, error) {
+	records, err := c.txtRecords(ctx, dns.Fqdn(name), retries, retryDelay)
+	if err != nil {
+		return "", err
+	}
+
+	var matching []string
+	for _, record := range records {
+		if version == "" || emailRecordHasVersion(record, version) {
+			matching = append(matching, record)
+		}
+	}
+	switch len(matching) {
+	case 0:
+		return "", nil
+	case 1:
+		return matching[0], nil
+	default:
+		return "", fmt.Errorf("found %d records at %s, expected one", len(matching), name)
+	}
+}
+
+// parseSpfRecord checks the syntax of an SPF record and returns its terms.
+func parseSpfRecord(record string) ([]spfTerm, error) {
+	fields := strings.Fields(record)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "v=spf1") {
+		return nil, errors.New("record must start with v=spf1")
+	}
+
+	var terms []spfTerm
+	seen := make(map[string]bool)
+	for _, field := range fields[1:] {
+		// Modifiers are name=value with no ':' or '/' in the name
+		if i := strings.Index(field, "="); i > 0 && !strings.ContainsAny(field[:i], ":/") {
+			name := strings.ToLower(field[:i])
+			if !spfModifierName.MatchString(name) {
+				return nil, fmt.Errorf("invalid modifier %q", field)
+			}
+			if name == "redirect" || name == "exp" {
+				if seen[name] {
+					return nil, fmt.Errorf("%s modifier appears more than once", name)
+				}
+				if field[i+1:] == "" {
+					return nil, fmt.Errorf("%s modifier requires a domain", name)
+				}
+			}
+			seen[name] = true
+			terms = append(terms, spfTerm{name: name, value: field[i+1:], modifier: true})
+			continue
+		}
+
+		var term spfTerm
+		mechanism := field
+		if strings.ContainsRune("+-~?", rune(mechanism[0])) {
+			term.qualifier, mechanism = mechanism[:1], mechanism[1:]
+		}
+		name, value := mechanism, ""
+		if i := strings.IndexAny(mechanism, ":/"); i >= 0 {
+			name, value = mechanism[:i], mechanism[i:]
+		}
+		term.name = strings.ToLower(name)
+		if _, ok := spfMechanisms[term.name]; !ok {
+			return nil, fmt.Errorf("unknown mechanism %q", field)
+		}
+
+		switch term.name {
+		case "all":
+			if value != "" {
+				return nil, fmt.Errorf("invalid mechanism %q", field)
+			}
+		case "include", "exists", "ptr":
+			if value == "" && term.name == "ptr" {
+				break
+			}
+			if !strings.HasPrefix(value, ":") || len(value) == 1 || strings.Contains(value, "/") {
+				return nil, fmt.Errorf("%s mechanism requires a domain, got %q", term.name, field)
+			}
+			term.value = value[1:]
+		case "ip4", "ip6":
+			if !strings.HasPrefix(value, ":") {
+				return nil, fmt.Errorf("%s mechanism requires an address, got %q", term.name, field)
+			}
+			if err := validateSpfAddress(term.name, value[1:]); err != nil {
+				return nil, err
+			}
+		case "a", "mx":
+			domain, err := parseSpfDomainCidr(term.name, value)
+			if err != nil {
+				return nil, err
+			}
+			term.value = domain
+		}
+		terms = append(terms, term)
+	}
+
+	return terms, nil
+}
+
+// validateSpfAddress checks the address or network of an ip4 or ip6
+// mechanism.
+func validateSpfAddress(mechanism, value string) error {
+	var addr netip.Addr
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		addr = prefix.Addr()
+	} else if addr, err = netip.ParseAddr(value); err != nil {
+		return fmt.Errorf("invalid %s address %q", mechanism, value)
+	}
+
+	if (mechanism == "ip4") != addr.Is4() {
+		return fmt.Errorf("invalid %s address %q", mechanism, value)
+	}
+	return nil
+}
+
+// parseSpfDomainCidr parses the optional ":domain", "/ip4-cidr" and
+// "//ip6-cidr" parts of an a or mx mechanism and returns the domain.
+func parseSpfDomainCidr(mechanism, value string) (string, error) {
+	domain := ""
+	if strings.HasPrefix(value, ":") {
+		domain, value = value[1:], ""
+		if i := strings.Index(domain, "/"); i >= 0 {
+			domain, value = domain[:i], domain[i:]
+		}
+		if domain == "" {
+			return "", fmt.Errorf("%s mechanism requires a domain after ':'", mechanism)
+		}
+	}
+	if value == "" {
+		return domain, nil
+	}
+
+	ip4, ip6, dual := strings.Cut(value, "//")
+	if ip4 != "" {
+		if length, err := strconv.Atoi(strings.TrimPrefix(ip4, "/")); err != nil || !strings.HasPrefix(ip4, "/") || length < 0 || length > 32 {
+			return "", fmt.Errorf("invalid %s mechanism CIDR length %q", mechanism, value)
+		}
+	}
+	if dual {
+		if length, err := strconv.Atoi(ip6); err != nil || length < 0 || length > 128 {
+			return "", fmt.Errorf("invalid %s mechanism CIDR length %q", mechanism, value)
+		}
+	}
+	return domain, nil
+}
+
+// spfEvaluator follows the include and redirect terms of an SPF record,
+// counting the DNS lookups a receiver would need to evaluate it.
+type spfEvaluator struct {
+	client     dnsClient
+	retries    int64
+	retryDelay time.Duration
+	maxLookups int64
+
+	lookups  int64
+	includes []string
+}
+
+// evaluate fetches and checks the SPF record of a domain and the records it
+// includes, returning the record and its all mechanism, which is taken from
+// the redirect target when the record has none.
+func (e *spfEvaluator) evaluate(ctx context.Context, domain string, path []string) (string, string, error) {
+	if slices.Contains(path, domain) {
+		return "", "", fmt.Errorf("include loop through %s", domain)
+	}
+
+	record, err := e.client.lookupEmailRecord(ctx, domain, "v=spf1", e.retries, e.retryDelay)
+	if err != nil {
+		return "", "", err
+	}
+	if record == "" {
+		return "", "", fmt.Errorf("no SPF record found for %s", domain)
+	}
+
+	terms, err := parseSpfRecord(record)
+	if err != nil {
+		return record, "", fmt.Errorf("invalid SPF record for %s: %w", domain, err)
+	}
+	path = append(slices.Clip(path), domain)
+
+	all := ""
+	redirect := ""
+	for _, term := range terms {
+		if term.modifier {
+			if term.name == "redirect" {
+				redirect = term.value
+			}
+			continue
+		}
+
+		if spfMechanisms[term.name] {
+			if err := e.countLookup(path[0]); err != nil {
+				return record, all, err
+			}
+		}
+
+		switch term.name {
+		case "all":
+			all = term.qualifier + "all"
+		case "include":
+			// Macros depend on the message being checked and can't be followed
+			if strings.Contains(term.value, "%") {
+				continue
+			}
+			e.includes = append(e.includes, term.value)
+			if _, _, err := e.evaluate(ctx, term.value, path); err != nil {
+				return record, all, fmt.Errorf("include:%s: %w", term.value, err)
+			}
+		}
+
+		// Receivers never get past the all mechanism
+		if all != "" {
+			break
+		}
+	}
+
+	// The redirect modifier is ignored when the record has an all mechanism
+	if redirect != "" && all == "" {
+		if err := e.countLookup(path[0]); err != nil {
+			return record, all, err
+		}
+		if strings.Contains(redirect, "%") {
+			return record, all, nil
+		}
+		e.includes = append(e.includes, redirect)
+		if _, all, err = e.evaluate(ctx, redirect, path); err != nil {
+			return record, all, fmt.Errorf("redirect=%s: %w", redirect, err)
+		}
+	}
+
+	return record, all, nil
+}
+
+// countLookup counts a term that costs a DNS lookup and fails once the limit
+// is exceeded.
+func (e *spfEvaluator) countLookup(domain string) error {
+	e.lookups++
+	if e.lookups > e.maxLookups {
+		return fmt.Errorf("evaluating the SPF record of %s takes more than %d DNS lookups", domain, e.maxLookups)
+	}
+	return nil
+}
+
+// normalizeSpfAll returns an all mechanism with its qualifier made explicit.
+func normalizeSpfAll(all string) string {
+	if all == "all" {
+		return "+all"
+	}
+	return all
+}
+
+// parseEmailTags parses the tag=value lists used by DKIM, DMARC, MTA-STS and
+// TLS-RPT records (RFC 6376 section 3.2) and returns the tag names in order.
+func parseEmailTags(record string) ([]string, map[string]string, error) {
+	var names []string
+	tags := make(map[string]string)
+	for _, part := range strings.Split(record, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !emailTagName.MatchString(name) {
+			return nil, nil, fmt.Errorf("invalid tag %q", part)
+		}
+		if _, duplicate := tags[name]; duplicate {
+			return nil, nil, fmt.Errorf("duplicate tag %q", name)
+		}
+		tags[name] = strings.TrimSpace(value)
+		names = append(names, name)
+	}
+	return names, tags, nil
+}
+
+// parseDmarcRecord checks the syntax of a DMARC record (RFC 7489 section
+// 6.3) and returns its policy.
+func parseDmarcRecord(record string) (string, error) {
+	names, tags, err := parseEmailTags(record)
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 || names[0] != "v" || tags["v"] != "DMARC1" {
+		return "", errors.New("record must start with v=DMARC1")
+	}
+
+	policy, ok := tags["p"]
+	if !ok {
+		return "", errors.New("missing the required p tag")
+	}
+	for _, name := range []string{"p", "sp"} {
+		if value, ok := tags[name]; ok && !slices.Contains([]string{"none", "quarantine", "reject"}, strings.ToLower(value)) {
+			return "", fmt.Errorf("invalid %s tag %q, must be none, quarantine or reject", name, value)
+		}
+	}
+	for _, name := range []string{"adkim", "aspf"} {
+		if value, ok := tags[name]; ok && value != "r" && value != "s" {
+			return "", fmt.Errorf("invalid %s tag %q, must be r or s", name, value)
+		}
+	}
+	if value, ok := tags["pct"]; ok {
+		if pct, err := strconv.Atoi(value); err != nil || pct < 0 || pct > 100 {
+			return "", fmt.Errorf("invalid pct tag %q, must be between 0 and 100", value)
+		}
+	}
+	if value, ok := tags["ri"]; ok {
+		if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+			return "", fmt.Errorf("invalid ri tag %q", value)
+		}
+	}
+	if value, ok := tags["fo"]; ok {
+		for _, option := range strings.Split(value, ":") {
+			if !slices.Contains([]string{"0", "1", "d", "s"}, strings.TrimSpace(option)) {
+				return "", fmt.Errorf("invalid fo tag %q", value)
+			}
+		}
+	}
+	for _, name := range []string{"rua", "ruf"} {
+		if value, ok := tags[name]; ok {
+			if err := validateEmailReportUris(name, value, nil); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return strings.ToLower(policy), nil
+}
+
+// validateEmailReportUris checks a comma separated list of reporting URIs,
+// optionally restricted to some schemes.
+func validateEmailReportUris(tag, value string, schemes []string) error {
+	for _, uri := range strings.Split(value, ",") {
+		uri = strings.TrimSpace(uri)
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Scheme == "" || parsed.Opaque == "" && parsed.Host == "" {
+			return fmt.Errorf("invalid %s URI %q", tag, uri)
+		}
+		if schemes != nil && !slices.Contains(schemes, strings.ToLower(parsed.Scheme)) {
+			return fmt.Errorf("invalid %s URI %q, must use %s", tag, uri, strings.Join(schemes, " or "))
+		}
+	}
+	return nil
+}
+
+// dkimKey describes the public key published in a DKIM record.
+type dkimKey struct {
+	keyType string
+	bits    int
+}
+
+// parseDkimRecord checks the syntax of a DKIM key record (RFC 6376 section
+// 3.6.1) and decodes its public key.
+func parseDkimRecord(record string) (dkimKey, error) {
+	names, tags, err := parseEmailTags(record)
+	if err != nil {
+		return dkimKey{}, err
+	}
+	if version, ok := tags["v"]; ok && (names[0] != "v" || version != "DKIM1") {
+		return dkimKey{}, errors.New("version must be v=DKIM1 and come first")
+	}
+
+	key := dkimKey{keyType: "rsa"}
+	if keyType, ok := tags["k"]; ok {
+		key.keyType = strings.ToLower(keyType)
+	}
+
+	encoded, ok := tags["p"]
+	if !ok {
+		return key, errors.New("missing the required p tag")
+	}
+	encoded = strings.Join(strings.Fields(encoded), "")
+	if encoded == "" {
+		return key, errors.New("the key has been revoked")
+	}
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return key, fmt.Errorf("invalid base64 in p tag: %w", err)
+	}
+
+	switch key.keyType {
+	case "rsa":
+		var publicKey *rsa.PublicKey
+		if parsed, err := x509.ParsePKIXPublicKey(der); err == nil {
+			publicKey, _ = parsed.(*rsa.PublicKey)
+		} else if publicKey, err = x509.ParsePKCS1PublicKey(der); err != nil {
+			return key, fmt.Errorf("invalid RSA key: %w", err)
+		}
+		if publicKey == nil {
+			return key, errors.New("p tag does not hold an RSA key")
+		}
+		key.bits = publicKey.N.BitLen()
+		if key.bits < 1024 {
+			return key, fmt.Errorf("RSA key of %d bits is shorter than the 1024 bit minimum", key.bits)
+		}
+	case "ed25519":
+		if len(der) != ed25519.PublicKeySize {
+			return key, fmt.Errorf("invalid Ed25519 key of %d bytes", len(der))
+		}
+		key.bits = ed25519.PublicKeySize * 8
+	default:
+		return key, fmt.Errorf("unsupported key type %q", key.keyType)
+	}
+
+	return key, nil
+}
+
+// parseMtaStsRecord checks the syntax of an MTA-STS record (RFC 8461
+// section 3.1) and returns its policy id.
+func parseMtaStsRecord(record string) (string, error) {
+	names, tags, err := parseEmailTags(record)
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 || names[0] != "v" || tags["v"] != "STSv1" {
+		return "", errors.New("record must start with v=STSv1")
+	}
+
+	id, ok := tags["id"]
+	if !ok {
+		return "", errors.New("missing the required id tag")
+	}
+	if !mtaStsPolicyId.MatchString(id) {
+		return "", fmt.Errorf("invalid id tag %q, must be 1 to 32 letters and digits", id)
+	}
+	return id, nil
+}
+
+// parseTlsRptRecord checks the syntax of a TLS-RPT record (RFC 8460 section
+// 3).
+func parseTlsRptRecord(record string) error {
+	names, tags, err := parseEmailTags(record)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 || names[0] != "v" || tags["v"] != "TLSRPTv1" {
+		return errors.New("record must start with v=TLSRPTv1")
+	}
+
+	rua, ok := tags["rua"]
+	if !ok {
+		return errors.New("missing the required rua tag")
+	}
+	return validateEmailReportUris("rua", rua, []string{"mailto", "https"})
+}
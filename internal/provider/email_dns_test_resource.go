@@ -0,0 +1,546 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &EmailDnsTestResource{}
+var _ resource.ResourceWithImportState = &EmailDnsTestResource{}
+
+func NewEmailDnsTestResource() resource.Resource {
+	return &EmailDnsTestResource{}
+}
+
+// EmailDnsTestResource defines the resource implementation.
+type EmailDnsTestResource struct {
+	clientConfig *TerraProbeClientConfig
+}
+
+// EmailDnsTestResourceModel describes the resource data model.
+type EmailDnsTestResourceModel struct {
+	Name          types.String `tfsdk:"name"`
+	Domain        types.String `tfsdk:"domain"`
+	SpfMaxLookups types.Int64  `tfsdk:"spf_max_lookups"`
+	ExpectSpfAll  types.String `tfsdk:"expect_spf_all"`
+	DmarcPolicy   types.String `tfsdk:"dmarc_policy"`
+	DkimSelectors types.List   `tfsdk:"dkim_selectors"`
+	RequireMtaSts types.Bool   `tfsdk:"require_mta_sts"`
+	RequireTlsRpt types.Bool   `tfsdk:"require_tls_rpt"`
+	Resolver      types.String `tfsdk:"resolver"`
+	Timeout       types.Int64  `tfsdk:"timeout"`
+	Retries       types.Int64  `tfsdk:"retries"`
+	RetryDelay    types.Int64  `tfsdk:"retry_delay"`
+	Id            types.String `tfsdk:"id"`
+
+	// Results
+	LastRun            types.String `tfsdk:"last_run"`
+	LastSpfRecord      types.String `tfsdk:"last_spf_record"`
+	LastSpfLookupCount types.Int64  `tfsdk:"last_spf_lookup_count"`
+	LastSpfAll         types.String `tfsdk:"last_spf_all"`
+	LastSpfIncludes    types.List   `tfsdk:"last_spf_includes"`
+	LastDmarcRecord    types.String `tfsdk:"last_dmarc_record"`
+	LastDmarcPolicy    types.String `tfsdk:"last_dmarc_policy"`
+	LastDkimResults    types.List   `tfsdk:"last_dkim_results"`
+	LastMtaStsRecord   types.String `tfsdk:"last_mta_sts_record"`
+	LastMtaStsId       types.String `tfsdk:"last_mta_sts_id"`
+	LastTlsRptRecord   types.String `tfsdk:"last_tls_rpt_record"`
+	TestPassed         types.Bool   `tfsdk:"test_passed"`
+	Error              types.String `tfsdk:"error"`
+}
+
+// emailDkimResultAttrTypes describes the check of a single DKIM selector.
+var emailDkimResultAttrTypes = map[string]attr.Type{
+	"selector": types.StringType,
+	"record":   types.StringType,
+	"key_type": types.StringType,
+	"key_bits": types.Int64Type,
+	"valid":    types.BoolType,
+	"error":    types.StringType,
+}
+
+func (r *EmailDnsTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_email_dns_test"
+}
+
+func (r *EmailDnsTestResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Email DNS test resource that fetches and validates the SPF, DKIM, DMARC, MTA-STS and TLS-RPT records of a domain",
+
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Descriptive name for the test",
+				Required:            true,
+			},
+			"domain": schema.StringAttribute{
+				MarkdownDescription: "Mail domain to check",
+				Required:            true,
+			},
+			"spf_max_lookups": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of DNS lookups the SPF record may need, counting nested includes. Receivers fail SPF above 10.",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(defaultSpfMaxLookups),
+			},
+			"expect_spf_all": schema.StringAttribute{
+				MarkdownDescription: "Expected `all` mechanism ending the SPF record, e.g. `-all` or `~all`",
+				Optional:            true,
+			},
+			"dmarc_policy": schema.StringAttribute{
+				MarkdownDescription: "Expected DMARC policy: `none`, `quarantine` or `reject`",
+				Optional:            true,
+			},
+			"dkim_selectors": schema.ListAttribute{
+				MarkdownDescription: "DKIM selectors that must publish a valid key at `<selector>._domainkey.<domain>`",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"require_mta_sts": schema.BoolAttribute{
+				MarkdownDescription: "Fail when the domain has no MTA-STS record. The record is validated whenever it exists.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"require_tls_rpt": schema.BoolAttribute{
+				MarkdownDescription: "Fail when the domain has no TLS-RPT record. The record is validated whenever it exists.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"resolver": schema.StringAttribute{
+				MarkdownDescription: "DNS resolver to use instead of the system resolvers, in any form accepted by the `resolver` attribute of `terraprobe_dns_test`",
+				Optional:            true,
+			},
+			"timeout": schema.Int64Attribute{
+				MarkdownDescription: "Timeout in seconds for each DNS query",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of retries for each failed DNS query",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retry_delay": schema.Int64Attribute{
+				MarkdownDescription: "Delay between retries in seconds",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			// Results - these are computed values based on the last test run
+			"last_run": schema.StringAttribute{
+				MarkdownDescription: "Timestamp of the last test run",
+				Computed:            true,
+			},
+			"last_spf_record": schema.StringAttribute{
+				MarkdownDescription: "SPF record of the domain",
+				Computed:            true,
+			},
+			"last_spf_lookup_count": schema.Int64Attribute{
+				MarkdownDescription: "DNS lookups needed to evaluate the SPF record, counted up to the first one over `spf_max_lookups`",
+				Computed:            true,
+			},
+			"last_spf_all": schema.StringAttribute{
+				MarkdownDescription: "The `all` mechanism of the SPF record or its `redirect` target, empty when there is none",
+				Computed:            true,
+			},
+			"last_spf_includes": schema.ListAttribute{
+				MarkdownDescription: "Domains followed through `include` and `redirect`, in evaluation order",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"last_dmarc_record": schema.StringAttribute{
+				MarkdownDescription: "DMARC record found at `_dmarc.<domain>`",
+				Computed:            true,
+			},
+			"last_dmarc_policy": schema.StringAttribute{
+				MarkdownDescription: "Policy of the DMARC record",
+				Computed:            true,
+			},
+			"last_dkim_results": schema.ListNestedAttribute{
+				MarkdownDescription: "Check of each DKIM selector, in the order of `dkim_selectors`",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"selector": schema.StringAttribute{
+							MarkdownDescription: "DKIM selector",
+							Computed:            true,
+						},
+						"record": schema.StringAttribute{
+							MarkdownDescription: "Key record of the selector",
+							Computed:            true,
+						},
+						"key_type": schema.StringAttribute{
+							MarkdownDescription: "Key type, `rsa` or `ed25519`",
+							Computed:            true,
+						},
+						"key_bits": schema.Int64Attribute{
+							MarkdownDescription: "Size of the public key in bits",
+							Computed:            true,
+						},
+						"valid": schema.BoolAttribute{
+							MarkdownDescription: "Whether the record holds a usable key",
+							Computed:            true,
+						},
+						"error": schema.StringAttribute{
+							MarkdownDescription: "Error message if the check failed",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"last_mta_sts_record": schema.StringAttribute{
+				MarkdownDescription: "MTA-STS record found at `_mta-sts.<domain>`",
+				Computed:            true,
+			},
+			"last_mta_sts_id": schema.StringAttribute{
+				MarkdownDescription: "Policy id of the MTA-STS record",
+				Computed:            true,
+			},
+			"last_tls_rpt_record": schema.StringAttribute{
+				MarkdownDescription: "TLS-RPT record found at `_smtp._tls.<domain>`",
+				Computed:            true,
+			},
+			"test_passed": schema.BoolAttribute{
+				MarkdownDescription: "Whether the test passed",
+				Computed:            true,
+			},
+			"error": schema.StringAttribute{
+				MarkdownDescription: "Error message if the test failed",
+				Computed:            true,
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Test identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *EmailDnsTestResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	clientConfig, ok := req.ProviderData.(*TerraProbeClientConfig)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *TerraProbeClientConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.clientConfig = clientConfig
+}
+
+func (r *EmailDnsTestResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data EmailDnsTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate a unique identifier for this test
+	data.Id = types.StringValue(fmt.Sprintf("email-dns-test-%s", time.Now().Format("20060102150405")))
+
+	// Run the email DNS test
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("Email DNS Test Error", err.Error())
+		return
+	}
+
+	// Set the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Write logs
+	tflog.Trace(ctx, "created email DNS test resource")
+	tflog.Debug(ctx, fmt.Sprintf("Email DNS Test Result: %t - SPF lookups: %d, DMARC policy: %s", data.TestPassed.ValueBool(), data.LastSpfLookupCount.ValueInt64(), data.LastDmarcPolicy.ValueString()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *EmailDnsTestResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data EmailDnsTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the email DNS test again during Read
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("Email DNS Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *EmailDnsTestResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data EmailDnsTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the email DNS test with updated parameters
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("Email DNS Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *EmailDnsTestResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data EmailDnsTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Nothing special to do for delete, as this is a stateless resource
+	// The resource will be removed from Terraform state
+}
+
+func (r *EmailDnsTestResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// runTest runs the email DNS test and updates the resource model with the results.
+func (r *EmailDnsTestResource) runTest(ctx context.Context, data *EmailDnsTestResourceModel) error {
+	// Get timeout from resource or default from provider
+	timeout := time.Second * 5
+	if !data.Timeout.IsNull() && data.Timeout.ValueInt64() > 0 {
+		timeout = time.Duration(data.Timeout.ValueInt64()) * time.Second
+	}
+
+	// Get retries from resource or default from provider
+	retries := r.clientConfig.Retries
+	if !data.Retries.IsNull() && data.Retries.ValueInt64() > 0 {
+		retries = data.Retries.ValueInt64()
+	}
+
+	// Get retry delay from resource or default from provider
+	retryDelay := r.clientConfig.RetryDelay
+	if !data.RetryDelay.IsNull() && data.RetryDelay.ValueInt64() > 0 {
+		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
+	}
+
+	domain := strings.TrimSuffix(data.Domain.ValueString(), ".")
+	if domain == "" {
+		return fmt.Errorf("domain must not be empty")
+	}
+
+	maxLookups := int64(defaultSpfMaxLookups)
+	if !data.SpfMaxLookups.IsNull() {
+		maxLookups = data.SpfMaxLookups.ValueInt64()
+	}
+	if maxLookups <= 0 {
+		return fmt.Errorf("spf_max_lookups must be positive")
+	}
+
+	expectAll := normalizeSpfAll(strings.ToLower(data.ExpectSpfAll.ValueString()))
+	switch expectAll {
+	case "", "+all", "-all", "~all", "?all":
+	default:
+		return fmt.Errorf("unsupported expect_spf_all %q, must be one of: -all, ~all, ?all, +all", data.ExpectSpfAll.ValueString())
+	}
+
+	expectPolicy := strings.ToLower(data.DmarcPolicy.ValueString())
+	switch expectPolicy {
+	case "", "none", "quarantine", "reject":
+	default:
+		return fmt.Errorf("unsupported dmarc_policy %q, must be one of: none, quarantine, reject", data.DmarcPolicy.ValueString())
+	}
+
+	var selectors []string
+	if !data.DkimSelectors.IsNull() {
+		if diags := data.DkimSelectors.ElementsAs(ctx, &selectors, false); diags.HasError() {
+			return fmt.Errorf("failed to read dkim_selectors")
+		}
+	}
+
+	// Set up the DNS client
+	server, transport, err := parseDnsResolver(data.Resolver.ValueString(), "")
+	if err != nil {
+		return err
+	}
+	client := dnsClient{transport: transport, timeout: timeout}
+	if server != "" {
+		client.servers = []string{server}
+	} else {
+		client.servers = systemDnsServers()
+	}
+	if transport == dnsTransportDoH {
+		httpClient, closeClient, err := r.clientConfig.newHttpClient(httpClientOptions{Timeout: timeout})
+		if err != nil {
+			return err
+		}
+		defer closeClient()
+		client.httpClient = httpClient
+	}
+
+	var errorMsg strings.Builder
+
+	// Check the SPF record and everything it includes
+	evaluator := &spfEvaluator{client: client, retries: retries, retryDelay: retryDelay, maxLookups: maxLookups}
+	spfRecord, spfAll, err := evaluator.evaluate(ctx, domain, nil)
+	if err != nil {
+		errorMsg.WriteString(fmt.Sprintf("SPF check failed: %s. ", err))
+	} else if expectAll != "" && normalizeSpfAll(spfAll) != expectAll {
+		errorMsg.WriteString(fmt.Sprintf("Expected SPF record to end with %s, got %q. ", data.ExpectSpfAll.ValueString(), spfAll))
+	}
+
+	includes := make([]attr.Value, 0, len(evaluator.includes))
+	for _, include := range evaluator.includes {
+		includes = append(includes, types.StringValue(include))
+	}
+	data.LastSpfRecord = types.StringValue(spfRecord)
+	data.LastSpfLookupCount = types.Int64Value(evaluator.lookups)
+	data.LastSpfAll = types.StringValue(spfAll)
+	data.LastSpfIncludes = types.ListValueMust(types.StringType, includes)
+
+	// Check the DMARC policy
+	dmarcName := "_dmarc." + domain
+	dmarcRecord, err := client.lookupEmailRecord(ctx, dmarcName, "v=DMARC1", retries, retryDelay)
+	policy := ""
+	switch {
+	case err != nil:
+		errorMsg.WriteString(fmt.Sprintf("DMARC lookup failed: %s. ", err))
+	case dmarcRecord == "":
+		errorMsg.WriteString(fmt.Sprintf("No DMARC record found at %s. ", dmarcName))
+	default:
+		if policy, err = parseDmarcRecord(dmarcRecord); err != nil {
+			errorMsg.WriteString(fmt.Sprintf("Invalid DMARC record: %s. ", err))
+		} else if expectPolicy != "" && policy != expectPolicy {
+			errorMsg.WriteString(fmt.Sprintf("Expected DMARC policy %s, got %s. ", expectPolicy, policy))
+		}
+	}
+	data.LastDmarcRecord = types.StringValue(dmarcRecord)
+	data.LastDmarcPolicy = types.StringValue(policy)
+
+	// Check the key of every DKIM selector
+	dkimResults := make([]attr.Value, 0, len(selectors))
+	for _, selector := range selectors {
+		name := selector + "._domainkey." + domain
+		record, err := client.lookupEmailRecord(ctx, name, "", retries, retryDelay)
+		var key dkimKey
+		if err == nil && record == "" {
+			err = fmt.Errorf("no DKIM record found at %s", name)
+		} else if err == nil {
+			key, err = parseDkimRecord(record)
+		}
+
+		selectorError := ""
+		if err != nil {
+			selectorError = err.Error()
+			errorMsg.WriteString(fmt.Sprintf("DKIM selector %s: %s. ", selector, selectorError))
+		}
+
+		dkimResults = append(dkimResults, types.ObjectValueMust(emailDkimResultAttrTypes, map[string]attr.Value{
+			"selector": types.StringValue(selector),
+			"record":   types.StringValue(record),
+			"key_type": types.StringValue(key.keyType),
+			"key_bits": types.Int64Value(int64(key.bits)),
+			"valid":    types.BoolValue(err == nil),
+			"error":    types.StringValue(selectorError),
+		}))
+	}
+	data.LastDkimResults = types.ListValueMust(types.ObjectType{AttrTypes: emailDkimResultAttrTypes}, dkimResults)
+
+	// Check the MTA-STS record
+	mtaStsName := "_mta-sts." + domain
+	mtaStsRecord, err := client.lookupEmailRecord(ctx, mtaStsName, "v=STSv1", retries, retryDelay)
+	mtaStsId := ""
+	switch {
+	case err != nil:
+		errorMsg.WriteString(fmt.Sprintf("MTA-STS lookup failed: %s. ", err))
+	case mtaStsRecord == "":
+		if data.RequireMtaSts.ValueBool() {
+			errorMsg.WriteString(fmt.Sprintf("No MTA-STS record found at %s. ", mtaStsName))
+		}
+	default:
+		if mtaStsId, err = parseMtaStsRecord(mtaStsRecord); err != nil {
+			errorMsg.WriteString(fmt.Sprintf("Invalid MTA-STS record: %s. ", err))
+		}
+	}
+	data.LastMtaStsRecord = types.StringValue(mtaStsRecord)
+	data.LastMtaStsId = types.StringValue(mtaStsId)
+
+	// Check the TLS-RPT record
+	tlsRptName := "_smtp._tls." + domain
+	tlsRptRecord, err := client.lookupEmailRecord(ctx, tlsRptName, "v=TLSRPTv1", retries, retryDelay)
+	switch {
+	case err != nil:
+		errorMsg.WriteString(fmt.Sprintf("TLS-RPT lookup failed: %s. ", err))
+	case tlsRptRecord == "":
+		if data.RequireTlsRpt.ValueBool() {
+			errorMsg.WriteString(fmt.Sprintf("No TLS-RPT record found at %s. ", tlsRptName))
+		}
+	default:
+		if err := parseTlsRptRecord(tlsRptRecord); err != nil {
+			errorMsg.WriteString(fmt.Sprintf("Invalid TLS-RPT record: %s. ", err))
+		}
+	}
+	data.LastTlsRptRecord = types.StringValue(tlsRptRecord)
+
+	// Set the test result
+	passed := errorMsg.Len() == 0
+	data.TestPassed = types.BoolValue(passed)
+
+	// Set error message if test failed
+	if !passed {
+		data.Error = types.StringValue(errorMsg.String())
+	} else {
+		data.Error = types.StringValue("")
+	}
+
+	return nil
+}
@@ -0,0 +1,282 @@
+package provider
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testDkimRecord returns a DKIM TXT record in zone file format, splitting
+// the key into strings of at most 255 characters.
+func testDkimRecord(name, keyType string, der []byte) string {
+	record := fmt.Sprintf("v=DKIM1; k=%s; p=%s", keyType, base64.StdEncoding.EncodeToString(der))
+
+	var chunks []string
+	for len(record) > 255 {
+		chunks = append(chunks, record[:255])
+		record = record[255:]
+	}
+	chunks = append(chunks, record)
+	return fmt.Sprintf(`%s 300 IN TXT "%s"`, name, strings.Join(chunks, `" "`))
+}
+
+// TestParseSpfRecord tests SPF syntax validation.
+func TestParseSpfRecord(t *testing.T) {
+	valid := []string{
+		"v=spf1 -all",
+		"v=spf1 ip4:192.0.2.0/24 ip6:2001:db8::/32 include:_spf.example.com ~all",
+		"v=spf1 a mx:mail.example.com/24 a//64 a:example.com/24//64 ptr exists:%{i}.example.com ?all",
+		"V=SPF1 +MX redirect=_spf.example.com exp=explain.example.com custom=value",
+	}
+	for _, record := range valid {
+		if _, err := parseSpfRecord(record); err != nil {
+			t.Errorf("expected %q to be valid, got %v", record, err)
+		}
+	}
+
+	invalid := []string{
+		"v=spf2 -all",
+		"v=spf1 include -all",
+		"v=spf1 ip4:2001:db8::1 -all",
+		"v=spf1 ip6:192.0.2.1 -all",
+		"v=spf1 ip4:192.0.2.0/33 -all",
+		"v=spf1 a/33 -all",
+		"v=spf1 mx//129 -all",
+		"v=spf1 all:example.com",
+		"v=spf1 redirect=a.example.com redirect=b.example.com",
+		"v=spf1 include:example.com spf -all",
+	}
+	for _, record := range invalid {
+		if _, err := parseSpfRecord(record); err == nil {
+			t.Errorf("expected %q to be invalid", record)
+		}
+	}
+}
+
+// TestParseEmailRecords tests DMARC, DKIM, MTA-STS and TLS-RPT syntax validation.
+func TestParseEmailRecords(t *testing.T) {
+	policy, err := parseDmarcRecord("v=DMARC1; p=Reject; sp=quarantine; pct=50; adkim=s; fo=0:d; rua=mailto:dmarc@example.com,mailto:dmarc@example.net;")
+	if err != nil || policy != "reject" {
+		t.Errorf("expected reject policy, got %q: %v", policy, err)
+	}
+	for _, record := range []string{
+		"p=reject; v=DMARC1",
+		"v=DMARC1; rua=mailto:dmarc@example.com",
+		"v=DMARC1; p=block",
+		"v=DMARC1; p=none; pct=101",
+		"v=DMARC1; p=none; rua=dmarc@example.com",
+		"v=DMARC1; p=none; p=reject",
+	} {
+		if _, err := parseDmarcRecord(record); err == nil {
+			t.Errorf("expected DMARC record %q to be invalid", record)
+		}
+	}
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	key, err := parseDkimRecord("v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(publicKey))
+	if err != nil || key.keyType != "ed25519" || key.bits != 256 {
+		t.Errorf("expected a 256 bit ed25519 key, got %+v: %v", key, err)
+	}
+	for _, record := range []string{
+		"v=DKIM1; k=rsa; p=",
+		"k=rsa; v=DKIM1; p=" + base64.StdEncoding.EncodeToString(publicKey),
+		"v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(publicKey),
+		"v=DKIM1; k=dsa; p=" + base64.StdEncoding.EncodeToString(publicKey),
+		"v=DKIM1; p=not base64!",
+		"v=DKIM1; k=rsa",
+	} {
+		if _, err := parseDkimRecord(record); err == nil {
+			t.Errorf("expected DKIM record %q to be invalid", record)
+		}
+	}
+
+	if id, err := parseMtaStsRecord("v=STSv1; id=20261018T1200;"); err != nil || id != "20261018T1200" {
+		t.Errorf("expected MTA-STS id 20261018T1200, got %q: %v", id, err)
+	}
+	for _, record := range []string{"v=STSv1;", "v=STSv1; id=2026-10-18", "id=1; v=STSv1"} {
+		if _, err := parseMtaStsRecord(record); err == nil {
+			t.Errorf("expected MTA-STS record %q to be invalid", record)
+		}
+	}
+
+	if err := parseTlsRptRecord("v=TLSRPTv1; rua=mailto:tls@example.com,https://reports.example.com/tls"); err != nil {
+		t.Errorf("expected TLS-RPT record to be valid, got %v", err)
+	}
+	for _, record := range []string{"v=TLSRPTv1;", "v=TLSRPTv1; rua=ftp://reports.example.com", "v=TLSRPTv2; rua=mailto:tls@example.com"} {
+		if err := parseTlsRptRecord(record); err == nil {
+			t.Errorf("expected TLS-RPT record %q to be invalid", record)
+		}
+	}
+}
+
+// TestEmailDnsTestResource_runTest tests checking the email records of a domain.
+func TestEmailDnsTestResource_runTest(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	rsaDer, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	server := startTestDnsServer(t,
+		`example.com. 300 IN TXT "google-site-verification=abc"`,
+		`example.com. 300 IN TXT "v=spf1 include:_spf.example.net ip4:192.0.2.0/24 -all"`,
+		`_spf.example.net. 300 IN TXT "v=spf1 include:_spf2.example.net ~all"`,
+		`_spf2.example.net. 300 IN TXT "v=spf1 a mx -all"`,
+		`_dmarc.example.com. 300 IN TXT "v=DMARC1; p=reject; rua=mailto:dmarc@example.com"`,
+		testDkimRecord("rsa._domainkey.example.com.", "rsa", rsaDer),
+		testDkimRecord("ed._domainkey.example.com.", "ed25519", edKey),
+		`revoked._domainkey.example.com. 300 IN TXT "v=DKIM1; p="`,
+		`_mta-sts.example.com. 300 IN TXT "v=STSv1; id=20261018"`,
+		`_smtp._tls.example.com. 300 IN TXT "v=TLSRPTv1; rua=mailto:tls@example.com"`,
+		`loop.example.org. 300 IN TXT "v=spf1 include:loop2.example.org -all"`,
+		`loop2.example.org. 300 IN TXT "v=spf1 include:loop.example.org -all"`,
+		`_dmarc.loop.example.org. 300 IN TXT "v=DMARC1; p=none"`,
+	)
+
+	r := &EmailDnsTestResource{clientConfig: &TerraProbeClientConfig{}}
+	ctx := context.Background()
+
+	stringList := func(values ...string) types.List {
+		elements := make([]attr.Value, 0, len(values))
+		for _, v := range values {
+			elements = append(elements, types.StringValue(v))
+		}
+		return types.ListValueMust(types.StringType, elements)
+	}
+
+	newModel := func(domain string) *EmailDnsTestResourceModel {
+		return &EmailDnsTestResourceModel{
+			Name:          types.StringValue("Email DNS"),
+			Domain:        types.StringValue(domain),
+			SpfMaxLookups: types.Int64Value(defaultSpfMaxLookups),
+			DkimSelectors: types.ListNull(types.StringType),
+			RequireMtaSts: types.BoolValue(false),
+			RequireTlsRpt: types.BoolValue(false),
+			Resolver:      types.StringValue(server),
+			Timeout:       types.Int64Value(2),
+		}
+	}
+
+	t.Run("all records valid", func(t *testing.T) {
+		model := newModel("example.com")
+		model.ExpectSpfAll = types.StringValue("-all")
+		model.DmarcPolicy = types.StringValue("reject")
+		model.DkimSelectors = stringList("rsa", "ed")
+		model.RequireMtaSts = types.BoolValue(true)
+		model.RequireTlsRpt = types.BoolValue(true)
+
+		if err := r.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !model.TestPassed.ValueBool() {
+			t.Fatalf("expected test to pass, got error: %s", model.Error.ValueString())
+		}
+		if model.LastSpfLookupCount.ValueInt64() != 4 {
+			t.Errorf("expected 4 SPF lookups, got %d", model.LastSpfLookupCount.ValueInt64())
+		}
+		if !model.LastSpfIncludes.Equal(stringList("_spf.example.net", "_spf2.example.net")) {
+			t.Errorf("unexpected SPF includes %v", model.LastSpfIncludes)
+		}
+		if model.LastDmarcPolicy.ValueString() != "reject" || model.LastMtaStsId.ValueString() != "20261018" {
+			t.Errorf("unexpected DMARC policy %q or MTA-STS id %q", model.LastDmarcPolicy.ValueString(), model.LastMtaStsId.ValueString())
+		}
+		if len(model.LastDkimResults.Elements()) != 2 {
+			t.Fatalf("expected 2 DKIM results, got %v", model.LastDkimResults)
+		}
+		result, ok := model.LastDkimResults.Elements()[0].(types.Object)
+		if !ok {
+			t.Fatalf("unexpected DKIM result type %T", model.LastDkimResults.Elements()[0])
+		}
+		if bits, ok := result.Attributes()["key_bits"].(types.Int64); !ok || bits.ValueInt64() != 2048 {
+			t.Errorf("expected a 2048 bit RSA key, got %v", result.Attributes()["key_bits"])
+		}
+	})
+
+	t.Run("policy mismatches", func(t *testing.T) {
+		model := newModel("example.com")
+		model.ExpectSpfAll = types.StringValue("~all")
+		model.DmarcPolicy = types.StringValue("quarantine")
+		model.DkimSelectors = stringList("revoked", "missing")
+
+		if err := r.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if model.TestPassed.ValueBool() {
+			t.Fatalf("expected test to fail")
+		}
+		for _, expected := range []string{
+			`Expected SPF record to end with ~all, got "-all"`,
+			"Expected DMARC policy quarantine, got reject",
+			"DKIM selector revoked: the key has been revoked",
+			"DKIM selector missing: no DKIM record found at missing._domainkey.example.com",
+		} {
+			if !strings.Contains(model.Error.ValueString(), expected) {
+				t.Errorf("expected error to contain %q, got %q", expected, model.Error.ValueString())
+			}
+		}
+	})
+
+	t.Run("SPF lookup limit", func(t *testing.T) {
+		model := newModel("example.com")
+		model.SpfMaxLookups = types.Int64Value(3)
+
+		if err := r.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if model.TestPassed.ValueBool() || !strings.Contains(model.Error.ValueString(), "takes more than 3 DNS lookups") {
+			t.Errorf("expected lookup limit failure, got %q", model.Error.ValueString())
+		}
+	})
+
+	t.Run("include loop and missing records", func(t *testing.T) {
+		model := newModel("loop.example.org")
+		model.RequireMtaSts = types.BoolValue(true)
+
+		if err := r.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		for _, expected := range []string{"include loop through loop.example.org", "No MTA-STS record found at _mta-sts.loop.example.org"} {
+			if !strings.Contains(model.Error.ValueString(), expected) {
+				t.Errorf("expected error to contain %q, got %q", expected, model.Error.ValueString())
+			}
+		}
+		if strings.Contains(model.Error.ValueString(), "TLS-RPT") {
+			t.Errorf("expected a missing TLS-RPT record to be allowed, got %q", model.Error.ValueString())
+		}
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		for _, update := range []func(*EmailDnsTestResourceModel){
+			func(m *EmailDnsTestResourceModel) { m.Domain = types.StringValue("") },
+			func(m *EmailDnsTestResourceModel) { m.SpfMaxLookups = types.Int64Value(0) },
+			func(m *EmailDnsTestResourceModel) { m.ExpectSpfAll = types.StringValue("reject") },
+			func(m *EmailDnsTestResourceModel) { m.DmarcPolicy = types.StringValue("block") },
+			func(m *EmailDnsTestResourceModel) { m.Resolver = types.StringValue("quic://dns.example.com") },
+		} {
+			model := newModel("example.com")
+			update(model)
+			if err := r.runTest(ctx, model); err == nil {
+				t.Errorf("expected configuration error for %+v", model)
+			}
+		}
+	})
+}
@@ -0,0 +1,578 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &GraphqlTestResource{}
+var _ resource.ResourceWithImportState = &GraphqlTestResource{}
+
+func NewGraphqlTestResource() resource.Resource {
+	return &GraphqlTestResource{}
+}
+
+// GraphqlTestResource defines the resource implementation.
+type GraphqlTestResource struct {
+	clientConfig *TerraProbeClientConfig
+}
+
+// GraphqlTestResourceModel describes the resource data model.
+type GraphqlTestResourceModel struct {
+	Name             types.String  `tfsdk:"name"`
+	URL              types.String  `tfsdk:"url"`
+	Query            types.String  `tfsdk:"query"`
+	Variables        types.Dynamic `tfsdk:"variables"`
+	OperationName    types.String  `tfsdk:"operation_name"`
+	Headers          types.Map     `tfsdk:"headers"`
+	Timeout          types.Int64   `tfsdk:"timeout"`
+	Retries          types.Int64   `tfsdk:"retries"`
+	RetryDelay       types.Int64   `tfsdk:"retry_delay"`
+	ExpectStatusCode types.Int64   `tfsdk:"expect_status_code"`
+	AllowErrors      types.Bool    `tfsdk:"allow_errors"`
+	ExpectData       types.Map     `tfsdk:"expect_data"`
+	ExpectTypes      types.List    `tfsdk:"expect_types"`
+	ExpectFields     types.List    `tfsdk:"expect_fields"`
+	Id               types.String  `tfsdk:"id"`
+
+	// TLS and authentication
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+	CACert             types.String `tfsdk:"ca_cert"`
+	ClientCert         types.String `tfsdk:"client_cert"`
+	ClientKey          types.String `tfsdk:"client_key"`
+	BearerToken        types.String `tfsdk:"bearer_token"`
+	BasicAuth          types.Object `tfsdk:"basic_auth"`
+
+	// Results
+	LastRun          types.String `tfsdk:"last_run"`
+	LastStatusCode   types.Int64  `tfsdk:"last_status_code"`
+	LastResponseTime types.Int64  `tfsdk:"last_response_time"`
+	LastData         types.String `tfsdk:"last_data"`
+	LastErrors       types.List   `tfsdk:"last_errors"`
+	TestPassed       types.Bool   `tfsdk:"test_passed"`
+	Error            types.String `tfsdk:"error"`
+}
+
+// graphqlIntrospectionQuery fetches the type and field names of a schema.
+const graphqlIntrospectionQuery = `query TerraProbeIntrospection { __schema { types { name fields(includeDeprecated: true) { name } } } }`
+
+// graphqlResponse is the standard GraphQL response envelope.
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (r *GraphqlTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_graphql_test"
+}
+
+func (r *GraphqlTestResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "GraphQL test resource that validates a GraphQL endpoint",
+
+		Attributes: mergeSchemaAttributes(map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Descriptive name for the test",
+				Required:            true,
+			},
+			"url": schema.StringAttribute{
+				MarkdownDescription: "GraphQL endpoint URL",
+				Required:            true,
+			},
+			"query": schema.StringAttribute{
+				MarkdownDescription: "GraphQL query or mutation document to send",
+				Required:            true,
+			},
+			"variables": schema.DynamicAttribute{
+				MarkdownDescription: "Variables object sent with the query",
+				Optional:            true,
+			},
+			"operation_name": schema.StringAttribute{
+				MarkdownDescription: "Name of the operation to execute when the document contains several",
+				Optional:            true,
+			},
+			"headers": schema.MapAttribute{
+				MarkdownDescription: "HTTP headers to include in the request",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"timeout": schema.Int64Attribute{
+				MarkdownDescription: "Timeout in seconds for the GraphQL request",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of retries for the GraphQL request",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retry_delay": schema.Int64Attribute{
+				MarkdownDescription: "Delay between retries in seconds",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"expect_status_code": schema.Int64Attribute{
+				MarkdownDescription: "Expected HTTP status code",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(200),
+			},
+			"allow_errors": schema.BoolAttribute{
+				MarkdownDescription: "Pass the test even if the response contains a non-empty `errors` array",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"expect_data": schema.MapAttribute{
+				MarkdownDescription: "Expected values keyed by JSONPath evaluated against `data`, e.g. `{ \"$.user.name\" = \"alice\" }`. Non-string values are compared in their JSON encoding.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"expect_types": schema.ListAttribute{
+				MarkdownDescription: "Type names that must exist in the schema. Setting this runs an introspection query.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"expect_fields": schema.ListAttribute{
+				MarkdownDescription: "Fields that must exist in the schema, written as `Type.field`. Setting this runs an introspection query.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+
+			// Results - these are computed values based on the last test run
+			"last_run": schema.StringAttribute{
+				MarkdownDescription: "Timestamp of the last test run",
+				Computed:            true,
+			},
+			"last_status_code": schema.Int64Attribute{
+				MarkdownDescription: "HTTP status code from the last test run",
+				Computed:            true,
+			},
+			"last_response_time": schema.Int64Attribute{
+				MarkdownDescription: "Response time in milliseconds from the last test run",
+				Computed:            true,
+			},
+			"last_data": schema.StringAttribute{
+				MarkdownDescription: "JSON encoded `data` from the last response",
+				Computed:            true,
+			},
+			"last_errors": schema.ListAttribute{
+				MarkdownDescription: "Error messages from the last response",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"test_passed": schema.BoolAttribute{
+				MarkdownDescription: "Whether the test passed",
+				Computed:            true,
+			},
+			"error": schema.StringAttribute{
+				MarkdownDescription: "Error message if the test failed",
+				Computed:            true,
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Test identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		}, httpTLSSchemaAttributes(), httpAuthSchemaAttributes()),
+	}
+}
+
+func (r *GraphqlTestResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	clientConfig, ok := req.ProviderData.(*TerraProbeClientConfig)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *TerraProbeClientConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.clientConfig = clientConfig
+}
+
+func (r *GraphqlTestResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data GraphqlTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate a unique identifier for this test
+	data.Id = types.StringValue(fmt.Sprintf("graphql-test-%s", time.Now().Format("20060102150405")))
+
+	// Run the GraphQL test
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("GraphQL Test Error", err.Error())
+		return
+	}
+
+	// Set the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Write logs
+	tflog.Trace(ctx, "created GraphQL test resource")
+	tflog.Debug(ctx, fmt.Sprintf("GraphQL Test Result: %t - %s", data.TestPassed.ValueBool(), data.URL.ValueString()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GraphqlTestResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data GraphqlTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the GraphQL test again during Read
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("GraphQL Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GraphqlTestResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data GraphqlTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the GraphQL test with updated parameters
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("GraphQL Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GraphqlTestResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data GraphqlTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Nothing special to do for delete, as this is a stateless resource
+	// The resource will be removed from Terraform state
+}
+
+func (r *GraphqlTestResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// runTest runs the GraphQL test and updates the resource model with the results.
+func (r *GraphqlTestResource) runTest(ctx context.Context, data *GraphqlTestResourceModel) error {
+	// Get timeout from resource or default from provider
+	timeout := r.clientConfig.HttpClient.Timeout
+	if !data.Timeout.IsNull() && data.Timeout.ValueInt64() > 0 {
+		timeout = time.Duration(data.Timeout.ValueInt64()) * time.Second
+	}
+
+	// Get retries from resource or default from provider
+	retries := r.clientConfig.Retries
+	if !data.Retries.IsNull() && data.Retries.ValueInt64() > 0 {
+		retries = data.Retries.ValueInt64()
+	}
+
+	// Get retry delay from resource or default from provider
+	retryDelay := r.clientConfig.RetryDelay
+	if !data.RetryDelay.IsNull() && data.RetryDelay.ValueInt64() > 0 {
+		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
+	}
+
+	// Read the expectations
+	expectData := make(map[string]string)
+	if !data.ExpectData.IsNull() {
+		if diags := data.ExpectData.ElementsAs(ctx, &expectData, false); diags.HasError() {
+			return fmt.Errorf("failed to read expect_data")
+		}
+	}
+
+	var expectTypes, expectFields []string
+	if !data.ExpectTypes.IsNull() {
+		if diags := data.ExpectTypes.ElementsAs(ctx, &expectTypes, false); diags.HasError() {
+			return fmt.Errorf("failed to read expect_types")
+		}
+	}
+	if !data.ExpectFields.IsNull() {
+		if diags := data.ExpectFields.ElementsAs(ctx, &expectFields, false); diags.HasError() {
+			return fmt.Errorf("failed to read expect_fields")
+		}
+	}
+
+	// Encode the variables
+	var variables interface{}
+	if !data.Variables.IsNull() && !data.Variables.IsUnderlyingValueNull() {
+		converted, err := attrValueToInterface(data.Variables.UnderlyingValue())
+		if err != nil {
+			return fmt.Errorf("failed to convert variables: %w", err)
+		}
+		variables = converted
+	}
+
+	client, closeClient, err := r.clientConfig.newHttpClient(httpClientOptions{
+		Timeout: timeout,
+		TLS:     httpTLSOptionsFromModel(data.InsecureSkipVerify, data.CACert, data.ClientCert, data.ClientKey),
+	})
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	// Reset the results from any previous run
+	data.LastStatusCode = types.Int64Value(0)
+	data.LastResponseTime = types.Int64Value(0)
+	data.LastData = types.StringValue("")
+	data.LastErrors = types.ListValueMust(types.StringType, []attr.Value{})
+
+	// Run the query
+	result, statusCode, responseTime, err := r.execute(ctx, client, data, data.Query.ValueString(), data.OperationName.ValueString(), variables, retries, retryDelay)
+	data.LastStatusCode = types.Int64Value(int64(statusCode))
+	data.LastResponseTime = types.Int64Value(int64(responseTime / time.Millisecond))
+	if err != nil {
+		data.Error = types.StringValue(err.Error())
+		data.TestPassed = types.BoolValue(false)
+		return nil // Don't return error as we want to keep the error in the state
+	}
+
+	// Record the results
+	data.LastData = types.StringValue(string(result.Data))
+	errorMessages := make([]attr.Value, len(result.Errors))
+	for i, e := range result.Errors {
+		errorMessages[i] = types.StringValue(e.Message)
+	}
+	data.LastErrors = types.ListValueMust(types.StringType, errorMessages)
+
+	// Check if the test passed
+	passed := true
+	var errorMsg strings.Builder
+
+	expectedStatusCode := int64(200)
+	if !data.ExpectStatusCode.IsNull() {
+		expectedStatusCode = data.ExpectStatusCode.ValueInt64()
+	}
+
+	if int64(statusCode) != expectedStatusCode {
+		passed = false
+		errorMsg.WriteString(fmt.Sprintf("Expected status code %d but got %d. ", expectedStatusCode, statusCode))
+	}
+
+	if len(result.Errors) > 0 && !data.AllowErrors.ValueBool() {
+		passed = false
+		errorMsg.WriteString(fmt.Sprintf("Response contains errors: %s. ", result.Errors[0].Message))
+	}
+
+	// Check the JSONPath assertions against data
+	if len(expectData) > 0 {
+		var document interface{}
+		var decodeErr error
+		if len(result.Data) > 0 {
+			decodeErr = json.Unmarshal(result.Data, &document)
+		}
+
+		if decodeErr != nil {
+			passed = false
+			errorMsg.WriteString(fmt.Sprintf("Failed to decode GraphQL data: %s. ", decodeErr))
+		} else {
+			for _, failure := range checkJSONPathExpectations(document, expectData) {
+				passed = false
+				errorMsg.WriteString(failure + ". ")
+			}
+		}
+	}
+
+	// Check the schema through introspection if requested
+	if len(expectTypes) > 0 || len(expectFields) > 0 {
+		for _, failure := range r.checkSchema(ctx, client, data, expectTypes, expectFields, retries, retryDelay) {
+			passed = false
+			errorMsg.WriteString(failure + ". ")
+		}
+	}
+
+	// Set the test result
+	data.TestPassed = types.BoolValue(passed)
+
+	// Set error message if test failed
+	if !passed {
+		data.Error = types.StringValue(errorMsg.String())
+	} else {
+		data.Error = types.StringValue("")
+	}
+
+	return nil
+}
+
+// execute posts a GraphQL operation and decodes the response envelope.
+func (r *GraphqlTestResource) execute(ctx context.Context, client *http.Client, data *GraphqlTestResourceModel, query, operationName string, variables interface{}, retries int64, retryDelay time.Duration) (*graphqlResponse, int, time.Duration, error) {
+	request := map[string]interface{}{
+		"query": query,
+	}
+	if operationName != "" {
+		request["operationName"] = operationName
+	}
+	if variables != nil {
+		request["variables"] = variables
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to encode GraphQL request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, data.URL.ValueString(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/graphql-response+json, application/json")
+
+	// Add headers
+	if !data.Headers.IsNull() {
+		headers := make(map[string]string)
+		data.Headers.ElementsAs(ctx, &headers, false)
+
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+	}
+
+	// Add credentials
+	if err := applyHttpAuth(ctx, req, data.BearerToken, data.BasicAuth); err != nil {
+		return nil, 0, 0, err
+	}
+
+	// Add user agent
+	req.Header.Set("User-Agent", r.clientConfig.UserAgent)
+
+	resp, responseTime, err := doHttpRequestWithRetries(client, req, retries, retryDelay)
+	if err != nil {
+		return nil, 0, responseTime, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, responseTime, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var result graphqlResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, resp.StatusCode, responseTime, fmt.Errorf("response is not a GraphQL response (status %d): %w", resp.StatusCode, err)
+	}
+
+	return &result, resp.StatusCode, responseTime, nil
+}
+
+// checkSchema runs an introspection query and reports missing types and fields.
+func (r *GraphqlTestResource) checkSchema(ctx context.Context, client *http.Client, data *GraphqlTestResourceModel, expectTypes, expectFields []string, retries int64, retryDelay time.Duration) []string {
+	result, _, _, err := r.execute(ctx, client, data, graphqlIntrospectionQuery, "", nil, retries, retryDelay)
+	if err != nil {
+		return []string{fmt.Sprintf("Introspection failed: %s", err.Error())}
+	}
+	if len(result.Errors) > 0 {
+		return []string{fmt.Sprintf("Introspection failed: %s", result.Errors[0].Message)}
+	}
+
+	var introspection struct {
+		Schema struct {
+			Types []struct {
+				Name   string `json:"name"`
+				Fields []struct {
+					Name string `json:"name"`
+				} `json:"fields"`
+			} `json:"types"`
+		} `json:"__schema"`
+	}
+	if err := json.Unmarshal(result.Data, &introspection); err != nil {
+		return []string{fmt.Sprintf("Failed to decode introspection result: %s", err.Error())}
+	}
+
+	// Index the schema by type and field name
+	fields := make(map[string]map[string]bool)
+	for _, t := range introspection.Schema.Types {
+		fields[t.Name] = make(map[string]bool)
+		for _, f := range t.Fields {
+			fields[t.Name][f.Name] = true
+		}
+	}
+
+	var failures []string
+	for _, typeName := range expectTypes {
+		if _, ok := fields[typeName]; !ok {
+			failures = append(failures, fmt.Sprintf("Type '%s' not found in schema", typeName))
+		}
+	}
+	for _, field := range expectFields {
+		typeName, fieldName, ok := strings.Cut(field, ".")
+		if !ok {
+			failures = append(failures, fmt.Sprintf("Invalid expected field '%s', expected Type.field", field))
+			continue
+		}
+		if !fields[typeName][fieldName] {
+			failures = append(failures, fmt.Sprintf("Field '%s' not found in schema", field))
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,246 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TestGraphqlTestResource_runTest tests the GraphQL test resource's runTest function.
+func TestGraphqlTestResource_runTest(t *testing.T) {
+	// Minimal GraphQL server that requires a bearer token
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"unauthorized"}]}`))
+			return
+		}
+
+		var request struct {
+			Query         string                 `json:"query"`
+			OperationName string                 `json:"operationName"`
+			Variables     map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case strings.Contains(request.Query, "__schema"):
+			_, _ = w.Write([]byte(`{"data":{"__schema":{"types":[
+				{"name":"Query","fields":[{"name":"user"}]},
+				{"name":"User","fields":[{"name":"id"},{"name":"name"}]},
+				{"name":"String","fields":null}
+			]}}}`))
+		case request.OperationName == "Broken":
+			_, _ = w.Write([]byte(`{"data":null,"errors":[{"message":"field 'nope' not found"}]}`))
+		default:
+			response := map[string]interface{}{
+				"data": map[string]interface{}{
+					"user": map[string]interface{}{
+						"id":   request.Variables["id"],
+						"name": "alice",
+					},
+				},
+			}
+			_ = json.NewEncoder(w).Encode(response)
+		}
+	}))
+	defer server.Close()
+
+	resource := &GraphqlTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	ctx := context.Background()
+
+	newModel := func(name string) *GraphqlTestResourceModel {
+		return &GraphqlTestResourceModel{
+			Name:             types.StringValue(name),
+			URL:              types.StringValue(server.URL),
+			Query:            types.StringValue(`query GetUser($id: ID!) { user(id: $id) { id name } }`),
+			ExpectStatusCode: types.Int64Value(200),
+			BearerToken:      types.StringValue("secret"),
+			Variables: types.DynamicValue(types.ObjectValueMust(
+				map[string]attr.Type{"id": types.StringType},
+				map[string]attr.Value{"id": types.StringValue("42")},
+			)),
+		}
+	}
+
+	t.Run("query with data assertions", func(t *testing.T) {
+		model := newModel("Query")
+		model.ExpectData = types.MapValueMust(types.StringType, map[string]attr.Value{
+			"$.user.id":   types.StringValue("42"),
+			"$.user.name": types.StringValue("alice"),
+		})
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if !model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+		}
+
+		if !strings.Contains(model.LastData.ValueString(), `"alice"`) {
+			t.Errorf("Expected last_data to contain the user, got %s", model.LastData.ValueString())
+		}
+	})
+
+	t.Run("failed data assertion", func(t *testing.T) {
+		model := newModel("Wrong data")
+		model.ExpectData = types.MapValueMust(types.StringType, map[string]attr.Value{
+			"$.user.name": types.StringValue("bob"),
+		})
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to fail with wrong data, but it passed")
+		}
+	})
+
+	t.Run("errors fail by default", func(t *testing.T) {
+		model := newModel("Errors")
+		model.OperationName = types.StringValue("Broken")
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to fail with GraphQL errors, but it passed")
+		}
+
+		if len(model.LastErrors.Elements()) != 1 {
+			t.Errorf("Expected 1 error message, got %d", len(model.LastErrors.Elements()))
+		}
+
+		// The same response passes when errors are allowed
+		model.AllowErrors = types.BoolValue(true)
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if !model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass with allow_errors, but it failed with error: %s", model.Error.ValueString())
+		}
+	})
+
+	t.Run("introspection", func(t *testing.T) {
+		model := newModel("Introspection")
+		model.ExpectTypes = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("User")})
+		model.ExpectFields = types.ListValueMust(types.StringType, []attr.Value{
+			types.StringValue("User.name"),
+			types.StringValue("User.email"),
+		})
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to fail with a missing field, but it passed")
+		}
+
+		if !strings.Contains(model.Error.ValueString(), "User.email") {
+			t.Errorf("Expected error to mention the missing field, got %s", model.Error.ValueString())
+		}
+	})
+
+	t.Run("missing credentials", func(t *testing.T) {
+		model := newModel("Unauthorized")
+		model.BearerToken = types.StringNull()
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to fail without credentials, but it passed")
+		}
+
+		if model.LastStatusCode.ValueInt64() != http.StatusUnauthorized {
+			t.Errorf("Expected status code 401, got %d", model.LastStatusCode.ValueInt64())
+		}
+	})
+}
+
+// TestGraphqlTestResource_tlsAndAuth tests custom CA verification and basic authentication.
+func TestGraphqlTestResource_tlsAndAuth(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "admin" || password != "hunter2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer server.Close()
+
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	resource := &GraphqlTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	ctx := context.Background()
+
+	// Without the CA the certificate cannot be verified
+	model := &GraphqlTestResourceModel{
+		Name:             types.StringValue("Untrusted"),
+		URL:              types.StringValue(server.URL),
+		Query:            types.StringValue(`{ ok }`),
+		ExpectStatusCode: types.Int64Value(200),
+		BasicAuth: types.ObjectValueMust(
+			map[string]attr.Type{"username": types.StringType, "password": types.StringType},
+			map[string]attr.Value{"username": types.StringValue("admin"), "password": types.StringValue("hunter2")},
+		),
+	}
+	if err := resource.runTest(ctx, model); err != nil {
+		t.Fatalf("runTest failed: %v", err)
+	}
+	if model.TestPassed.ValueBool() {
+		t.Errorf("Expected test to fail for an untrusted certificate, but it passed")
+	}
+
+	// Trusting the test server CA with valid credentials passes
+	model.CACert = types.StringValue(caCert)
+	if err := resource.runTest(ctx, model); err != nil {
+		t.Fatalf("runTest failed: %v", err)
+	}
+	if !model.TestPassed.ValueBool() {
+		t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+	}
+
+	// An invalid CA is a configuration error
+	model.CACert = types.StringValue("not a certificate")
+	if err := resource.runTest(ctx, model); err == nil {
+		t.Errorf("Expected error for an invalid ca_cert, but got none")
+	}
+}
@@ -0,0 +1,796 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionalphapb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &GrpcTestResource{}
+var _ resource.ResourceWithImportState = &GrpcTestResource{}
+
+func NewGrpcTestResource() resource.Resource {
+	return &GrpcTestResource{}
+}
+
+// GrpcTestResource defines the resource implementation.
+type GrpcTestResource struct {
+	clientConfig *TerraProbeClientConfig
+}
+
+// GrpcTestResourceModel describes the resource data model.
+type GrpcTestResourceModel struct {
+	Name              types.String `tfsdk:"name"`
+	Address           types.String `tfsdk:"address"`
+	Service           types.String `tfsdk:"service"`
+	Method            types.String `tfsdk:"method"`
+	RequestJson       types.String `tfsdk:"request_json"`
+	DescriptorSetFile types.String `tfsdk:"descriptor_set_file"`
+	Metadata          types.Map    `tfsdk:"metadata"`
+	UseTLS            types.Bool   `tfsdk:"use_tls"`
+	ServerName        types.String `tfsdk:"server_name"`
+	ExpectCode        types.String `tfsdk:"expect_code"`
+	ExpectStatus      types.String `tfsdk:"expect_status"`
+	ExpectResponse    types.Map    `tfsdk:"expect_response"`
+	Timeout           types.Int64  `tfsdk:"timeout"`
+	Retries           types.Int64  `tfsdk:"retries"`
+	RetryDelay        types.Int64  `tfsdk:"retry_delay"`
+	Id                types.String `tfsdk:"id"`
+
+	// TLS
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+	CACert             types.String `tfsdk:"ca_cert"`
+	ClientCert         types.String `tfsdk:"client_cert"`
+	ClientKey          types.String `tfsdk:"client_key"`
+
+	// Results
+	LastRun          types.String `tfsdk:"last_run"`
+	LastCode         types.String `tfsdk:"last_code"`
+	LastStatus       types.String `tfsdk:"last_status"`
+	LastResponse     types.String `tfsdk:"last_response"`
+	LastResponseTime types.Int64  `tfsdk:"last_response_time"`
+	TestPassed       types.Bool   `tfsdk:"test_passed"`
+	Error            types.String `tfsdk:"error"`
+}
+
+func (r *GrpcTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_grpc_test"
+}
+
+func (r *GrpcTestResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "gRPC test resource that checks service health via `grpc.health.v1.Health/Check` or invokes a unary method",
+
+		Attributes: mergeSchemaAttributes(map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Descriptive name for the test",
+				Required:            true,
+			},
+			"address": schema.StringAttribute{
+				MarkdownDescription: "Server address as `host:port`",
+				Required:            true,
+			},
+			"service": schema.StringAttribute{
+				MarkdownDescription: "Service name passed to the health check. Empty checks the overall server health.",
+				Optional:            true,
+			},
+			"method": schema.StringAttribute{
+				MarkdownDescription: "Unary method to invoke instead of the health check, as `package.Service/Method`",
+				Optional:            true,
+			},
+			"request_json": schema.StringAttribute{
+				MarkdownDescription: "JSON encoded request message for `method`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("{}"),
+			},
+			"descriptor_set_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a binary `FileDescriptorSet` describing `method`. Server reflection is used when not set.",
+				Optional:            true,
+			},
+			"metadata": schema.MapAttribute{
+				MarkdownDescription: "Metadata headers to send with the call",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"use_tls": schema.BoolAttribute{
+				MarkdownDescription: "Connect using TLS",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"server_name": schema.StringAttribute{
+				MarkdownDescription: "Server name used for TLS verification (default: host from `address`)",
+				Optional:            true,
+			},
+			"expect_code": schema.StringAttribute{
+				MarkdownDescription: "Expected gRPC status code name, e.g. `OK` or `NOT_FOUND`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("OK"),
+			},
+			"expect_status": schema.StringAttribute{
+				MarkdownDescription: "Expected health status when no `method` is set",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("SERVING"),
+			},
+			"expect_response": schema.MapAttribute{
+				MarkdownDescription: "Expected values keyed by JSONPath evaluated against the JSON encoded response. Non-string values are compared in their JSON encoding.",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"timeout": schema.Int64Attribute{
+				MarkdownDescription: "Timeout in seconds for the call",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of retries when the server is unavailable",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retry_delay": schema.Int64Attribute{
+				MarkdownDescription: "Delay between retries in seconds",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+
+			// Results - these are computed values based on the last test run
+			"last_run": schema.StringAttribute{
+				MarkdownDescription: "Timestamp of the last test run",
+				Computed:            true,
+			},
+			"last_code": schema.StringAttribute{
+				MarkdownDescription: "gRPC status code from the last test run",
+				Computed:            true,
+			},
+			"last_status": schema.StringAttribute{
+				MarkdownDescription: "Health status from the last health check",
+				Computed:            true,
+			},
+			"last_response": schema.StringAttribute{
+				MarkdownDescription: "JSON encoded response message from the last test run",
+				Computed:            true,
+			},
+			"last_response_time": schema.Int64Attribute{
+				MarkdownDescription: "Response time in milliseconds from the last test run",
+				Computed:            true,
+			},
+			"test_passed": schema.BoolAttribute{
+				MarkdownDescription: "Whether the test passed",
+				Computed:            true,
+			},
+			"error": schema.StringAttribute{
+				MarkdownDescription: "Error message if the test failed",
+				Computed:            true,
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Test identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		}, httpTLSSchemaAttributes()),
+	}
+}
+
+func (r *GrpcTestResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	clientConfig, ok := req.ProviderData.(*TerraProbeClientConfig)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *TerraProbeClientConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.clientConfig = clientConfig
+}
+
+func (r *GrpcTestResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data GrpcTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate a unique identifier for this test
+	data.Id = types.StringValue(fmt.Sprintf("grpc-test-%s", time.Now().Format("20060102150405")))
+
+	// Run the gRPC test
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("gRPC Test Error", err.Error())
+		return
+	}
+
+	// Set the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Write logs
+	tflog.Trace(ctx, "created gRPC test resource")
+	tflog.Debug(ctx, fmt.Sprintf("gRPC Test Result: %t - %s", data.TestPassed.ValueBool(), data.Address.ValueString()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GrpcTestResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data GrpcTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the gRPC test again during Read
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("gRPC Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GrpcTestResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data GrpcTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the gRPC test with updated parameters
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("gRPC Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GrpcTestResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data GrpcTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Nothing special to do for delete, as this is a stateless resource
+	// The resource will be removed from Terraform state
+}
+
+func (r *GrpcTestResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// runTest runs the gRPC test and updates the resource model with the results.
+func (r *GrpcTestResource) runTest(ctx context.Context, data *GrpcTestResourceModel) error {
+	// Get timeout from resource or default from provider
+	timeout := r.clientConfig.HttpClient.Timeout
+	if !data.Timeout.IsNull() && data.Timeout.ValueInt64() > 0 {
+		timeout = time.Duration(data.Timeout.ValueInt64()) * time.Second
+	}
+
+	// Get retries from resource or default from provider
+	retries := r.clientConfig.Retries
+	if !data.Retries.IsNull() && data.Retries.ValueInt64() > 0 {
+		retries = data.Retries.ValueInt64()
+	}
+
+	// Get retry delay from resource or default from provider
+	retryDelay := r.clientConfig.RetryDelay
+	if !data.RetryDelay.IsNull() && data.RetryDelay.ValueInt64() > 0 {
+		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
+	}
+
+	// Resolve the expected status code
+	expectCode := codes.OK
+	if !data.ExpectCode.IsNull() && data.ExpectCode.ValueString() != "" {
+		code, err := parseGrpcCode(data.ExpectCode.ValueString())
+		if err != nil {
+			return fmt.Errorf("invalid expect_code: %w", err)
+		}
+		expectCode = code
+	}
+
+	expectResponse := make(map[string]string)
+	if !data.ExpectResponse.IsNull() {
+		if diags := data.ExpectResponse.ElementsAs(ctx, &expectResponse, false); diags.HasError() {
+			return fmt.Errorf("failed to read expect_response")
+		}
+	}
+
+	// Set up transport credentials
+	creds := insecure.NewCredentials()
+	tlsOptions := httpTLSOptionsFromModel(data.InsecureSkipVerify, data.CACert, data.ClientCert, data.ClientKey)
+	if data.UseTLS.ValueBool() || !tlsOptions.isZero() {
+		tlsConfig := &tls.Config{ServerName: data.ServerName.ValueString()}
+		if err := tlsOptions.applyTo(tlsConfig); err != nil {
+			return err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(data.Address.ValueString(), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("invalid gRPC address: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	// Attach metadata to every call
+	if !data.Metadata.IsNull() {
+		md := make(map[string]string)
+		if diags := data.Metadata.ElementsAs(ctx, &md, false); diags.HasError() {
+			return fmt.Errorf("failed to read metadata")
+		}
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(md))
+	}
+
+	// Resolve the method descriptor before making any calls
+	var method protoreflect.MethodDescriptor
+	var request *dynamicpb.Message
+	if !data.Method.IsNull() && data.Method.ValueString() != "" {
+		method, err = r.resolveMethod(ctx, conn, data, timeout)
+		if err != nil {
+			data.Error = types.StringValue(fmt.Sprintf("Failed to resolve method: %s", err.Error()))
+			data.TestPassed = types.BoolValue(false)
+			data.LastCode = types.StringValue(grpcCodeName(status.Code(err)))
+			data.LastStatus = types.StringValue("")
+			data.LastResponse = types.StringValue("")
+			data.LastResponseTime = types.Int64Value(0)
+			return nil // Don't return error as we want to keep the error in the state
+		}
+
+		// A malformed request is a configuration error rather than a test failure
+		request = dynamicpb.NewMessage(method.Input())
+		if err := protojson.Unmarshal([]byte(data.RequestJson.ValueString()), request); err != nil {
+			return fmt.Errorf("invalid request_json for %s: %w", method.Input().FullName(), err)
+		}
+	}
+
+	// Perform the call with retries
+	var response proto.Message
+	var callErr error
+	var responseTime time.Duration
+
+	for i := int64(0); i <= retries; i++ {
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		if method != nil {
+			response, callErr = r.invoke(callCtx, conn, method, request)
+		} else {
+			response, callErr = healthpb.NewHealthClient(conn).Check(callCtx, &healthpb.HealthCheckRequest{
+				Service: data.Service.ValueString(),
+			})
+		}
+		responseTime = time.Since(start)
+		cancel()
+
+		// Only retry when the server could not be reached
+		code := status.Code(callErr)
+		if code != codes.Unavailable && code != codes.DeadlineExceeded {
+			break
+		}
+
+		if i < retries {
+			time.Sleep(retryDelay)
+		}
+	}
+
+	// Update the test results
+	code := status.Code(callErr)
+	data.LastCode = types.StringValue(grpcCodeName(code))
+	data.LastResponseTime = types.Int64Value(int64(responseTime / time.Millisecond))
+	data.LastStatus = types.StringValue("")
+	data.LastResponse = types.StringValue("")
+
+	var document interface{}
+	if callErr == nil {
+		encoded, err := protojson.Marshal(response)
+		if err != nil {
+			return fmt.Errorf("failed to encode response: %w", err)
+		}
+		// protojson output is deliberately unstable, so normalise it
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, encoded); err != nil {
+			return fmt.Errorf("failed to encode response: %w", err)
+		}
+		data.LastResponse = types.StringValue(compact.String())
+
+		if err := json.Unmarshal(encoded, &document); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		if health, ok := response.(*healthpb.HealthCheckResponse); ok {
+			data.LastStatus = types.StringValue(health.GetStatus().String())
+		}
+	}
+
+	// Check if the test passed
+	passed := true
+	var errorMsg strings.Builder
+
+	if code != expectCode {
+		passed = false
+		errorMsg.WriteString(fmt.Sprintf("Expected code %s but got %s", grpcCodeName(expectCode), grpcCodeName(code)))
+		if callErr != nil {
+			errorMsg.WriteString(fmt.Sprintf(": %s", status.Convert(callErr).Message()))
+		}
+		errorMsg.WriteString(". ")
+	}
+
+	if callErr == nil && method == nil {
+		expectStatus := data.ExpectStatus.ValueString()
+		if expectStatus == "" {
+			expectStatus = healthpb.HealthCheckResponse_SERVING.String()
+		}
+		if data.LastStatus.ValueString() != expectStatus {
+			passed = false
+			errorMsg.WriteString(fmt.Sprintf("Expected health status %s but got %s. ", expectStatus, data.LastStatus.ValueString()))
+		}
+	}
+
+	if callErr == nil {
+		for _, failure := range checkJSONPathExpectations(document, expectResponse) {
+			passed = false
+			errorMsg.WriteString(failure + ". ")
+		}
+	}
+
+	// Set the test result
+	data.TestPassed = types.BoolValue(passed)
+
+	// Set error message if test failed
+	if !passed {
+		data.Error = types.StringValue(errorMsg.String())
+	} else {
+		data.Error = types.StringValue("")
+	}
+
+	return nil
+}
+
+// invoke calls a unary method using dynamic messages.
+func (r *GrpcTestResource) invoke(ctx context.Context, conn *grpc.ClientConn, method protoreflect.MethodDescriptor, request proto.Message) (proto.Message, error) {
+	response := dynamicpb.NewMessage(method.Output())
+	fullMethod := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+	if err := conn.Invoke(ctx, fullMethod, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// resolveMethod finds the descriptor of the configured method either in the
+// descriptor set file or through server reflection.
+func (r *GrpcTestResource) resolveMethod(ctx context.Context, conn *grpc.ClientConn, data *GrpcTestResourceModel, timeout time.Duration) (protoreflect.MethodDescriptor, error) {
+	serviceName, methodName, ok := strings.Cut(strings.TrimPrefix(data.Method.ValueString(), "/"), "/")
+	if !ok || serviceName == "" || methodName == "" {
+		return nil, fmt.Errorf("method must be written as package.Service/Method, got %q", data.Method.ValueString())
+	}
+
+	var files *protoregistry.Files
+	var err error
+	if !data.DescriptorSetFile.IsNull() && data.DescriptorSetFile.ValueString() != "" {
+		files, err = loadDescriptorSet(data.DescriptorSetFile.ValueString())
+	} else {
+		reflectCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		files, err = reflectServiceFiles(reflectCtx, conn, serviceName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", serviceName, err)
+	}
+
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", serviceName)
+	}
+
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", methodName, serviceName)
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s is streaming, only unary methods are supported", data.Method.ValueString())
+	}
+
+	return method, nil
+}
+
+// loadDescriptorSet reads a binary FileDescriptorSet, e.g. produced by
+// `protoc --descriptor_set_out --include_imports` or `buf build -o`.
+func loadDescriptorSet(filename string) (*protoregistry.Files, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor_set_file: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(content, &set); err != nil {
+		return nil, fmt.Errorf("failed to decode descriptor_set_file: %w", err)
+	}
+
+	protos := make(map[string]*descriptorpb.FileDescriptorProto, len(set.GetFile()))
+	for _, file := range set.GetFile() {
+		protos[file.GetName()] = file
+	}
+
+	return buildFileRegistry(protos, nil)
+}
+
+// reflectServiceFiles fetches the file descriptors for a service, and all of
+// their dependencies, from the server reflection service. The v1 service is
+// preferred, falling back to v1alpha for older servers.
+func reflectServiceFiles(ctx context.Context, conn *grpc.ClientConn, serviceName string) (*protoregistry.Files, error) {
+	fetch, closeStream, err := openReflectionStream(ctx, conn, false)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized, err := fetch(serviceName, "")
+	if status.Code(err) == codes.Unimplemented {
+		closeStream()
+		fetch, closeStream, err = openReflectionStream(ctx, conn, true)
+		if err != nil {
+			return nil, err
+		}
+		serialized, err = fetch(serviceName, "")
+	}
+	defer closeStream()
+	if err != nil {
+		return nil, fmt.Errorf("server reflection failed: %w", err)
+	}
+
+	protos := make(map[string]*descriptorpb.FileDescriptorProto)
+	addFiles := func(serialized [][]byte) error {
+		for _, raw := range serialized {
+			var file descriptorpb.FileDescriptorProto
+			if err := proto.Unmarshal(raw, &file); err != nil {
+				return fmt.Errorf("failed to decode reflected descriptor: %w", err)
+			}
+			protos[file.GetName()] = &file
+		}
+		return nil
+	}
+
+	if err := addFiles(serialized); err != nil {
+		return nil, err
+	}
+
+	return buildFileRegistry(protos, func(name string) error {
+		serialized, err := fetch("", name)
+		if err != nil {
+			return err
+		}
+		return addFiles(serialized)
+	})
+}
+
+// reflectionFetcher requests the serialized files defining a symbol or with
+// a given file name.
+type reflectionFetcher func(symbol, filename string) ([][]byte, error)
+
+// openReflectionStream opens a server reflection stream using either the v1
+// or the v1alpha service, which share the same message layout.
+func openReflectionStream(ctx context.Context, conn *grpc.ClientConn, alpha bool) (reflectionFetcher, func(), error) {
+	if alpha {
+		stream, err := reflectionalphapb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("server reflection failed: %w", err)
+		}
+
+		fetch := func(symbol, filename string) ([][]byte, error) {
+			req := &reflectionalphapb.ServerReflectionRequest{}
+			if symbol != "" {
+				req.MessageRequest = &reflectionalphapb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol}
+			} else {
+				req.MessageRequest = &reflectionalphapb.ServerReflectionRequest_FileByFilename{FileByFilename: filename}
+			}
+			if err := stream.Send(req); err != nil {
+				return nil, err
+			}
+			resp, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			if errResp := resp.GetErrorResponse(); errResp != nil {
+				return nil, status.Error(codes.Code(errResp.GetErrorCode()), errResp.GetErrorMessage())
+			}
+			return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+		}
+		return fetch, func() { _ = stream.CloseSend() }, nil
+	}
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("server reflection failed: %w", err)
+	}
+
+	fetch := func(symbol, filename string) ([][]byte, error) {
+		req := &reflectionpb.ServerReflectionRequest{}
+		if symbol != "" {
+			req.MessageRequest = &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol}
+		} else {
+			req.MessageRequest = &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: filename}
+		}
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return nil, status.Error(codes.Code(errResp.GetErrorCode()), errResp.GetErrorMessage())
+		}
+		return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+	}
+	return fetch, func() { _ = stream.CloseSend() }, nil
+}
+
+// buildFileRegistry links file descriptor protos into a registry, resolving
+// dependencies from the set itself, the optional fetch function and finally
+// the well-known types compiled into the provider.
+func buildFileRegistry(protos map[string]*descriptorpb.FileDescriptorProto, fetch func(name string) error) (*protoregistry.Files, error) {
+	files := new(protoregistry.Files)
+
+	var register func(name string) error
+	register = func(name string) error {
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+
+		file, ok := protos[name]
+		if !ok && fetch != nil {
+			if err := fetch(name); err == nil {
+				file, ok = protos[name]
+			}
+		}
+		if !ok {
+			// Fall back to descriptors linked into the binary, e.g. well-known types
+			descriptor, err := protoregistry.GlobalFiles.FindFileByPath(name)
+			if err != nil {
+				return fmt.Errorf("descriptor for %s not found", name)
+			}
+			return files.RegisterFile(descriptor)
+		}
+
+		for _, dependency := range file.GetDependency() {
+			if err := register(dependency); err != nil {
+				return err
+			}
+		}
+
+		descriptor, err := protodesc.NewFile(file, files)
+		if err != nil {
+			return fmt.Errorf("invalid descriptor %s: %w", name, err)
+		}
+		return files.RegisterFile(descriptor)
+	}
+
+	// Snapshot the names as fetching dependencies grows the map
+	names := make([]string, 0, len(protos))
+	for name := range protos {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if err := register(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// grpcCodeNames maps status codes to their canonical names as used in
+// expect_code and last_code.
+var grpcCodeNames = map[codes.Code]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
+
+// grpcCodeName returns the canonical name of a status code.
+func grpcCodeName(code codes.Code) string {
+	if name, ok := grpcCodeNames[code]; ok {
+		return name
+	}
+	return code.String()
+}
+
+// parseGrpcCode parses a canonical status code name.
+func parseGrpcCode(name string) (codes.Code, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for code, codeName := range grpcCodeNames {
+		if codeName == name {
+			return code, nil
+		}
+	}
+	return codes.Unknown, fmt.Errorf("unsupported status code: %s", name)
+}
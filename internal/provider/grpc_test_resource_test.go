@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestGrpcTestResource_runTest tests the gRPC test resource's runTest function.
+func TestGrpcTestResource_runTest(t *testing.T) {
+	// In-process server with health checking and reflection
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("billing", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	// Descriptor set for the health service, as produced by protoc
+	descriptorSet, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode descriptor set: %v", err)
+	}
+	descriptorFile := filepath.Join(t.TempDir(), "health.binpb")
+	if err := os.WriteFile(descriptorFile, descriptorSet, 0o600); err != nil {
+		t.Fatalf("failed to write descriptor set: %v", err)
+	}
+
+	resource := &GrpcTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	ctx := context.Background()
+
+	newModel := func(name string) *GrpcTestResourceModel {
+		return &GrpcTestResourceModel{
+			Name:           types.StringValue(name),
+			Address:        types.StringValue(listener.Addr().String()),
+			Service:        types.StringNull(),
+			Method:         types.StringNull(),
+			RequestJson:    types.StringValue("{}"),
+			Metadata:       types.MapNull(types.StringType),
+			UseTLS:         types.BoolValue(false),
+			ExpectCode:     types.StringValue("OK"),
+			ExpectStatus:   types.StringValue("SERVING"),
+			ExpectResponse: types.MapNull(types.StringType),
+			Timeout:        types.Int64Value(5),
+			Retries:        types.Int64Value(0),
+			RetryDelay:     types.Int64Value(1),
+		}
+	}
+
+	t.Run("overall health", func(t *testing.T) {
+		data := newModel("overall")
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !data.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, got error: %s", data.Error.ValueString())
+		}
+		if data.LastStatus.ValueString() != "SERVING" {
+			t.Errorf("Expected status SERVING, got %s", data.LastStatus.ValueString())
+		}
+	})
+
+	t.Run("service not serving", func(t *testing.T) {
+		data := newModel("billing")
+		data.Service = types.StringValue("billing")
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if data.TestPassed.ValueBool() {
+			t.Error("Expected test to fail for a NOT_SERVING service")
+		}
+		if data.LastStatus.ValueString() != "NOT_SERVING" {
+			t.Errorf("Expected status NOT_SERVING, got %s", data.LastStatus.ValueString())
+		}
+	})
+
+	t.Run("unknown service with expected code", func(t *testing.T) {
+		data := newModel("unknown")
+		data.Service = types.StringValue("missing")
+		data.ExpectCode = types.StringValue("NOT_FOUND")
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !data.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, got error: %s", data.Error.ValueString())
+		}
+		if data.LastCode.ValueString() != "NOT_FOUND" {
+			t.Errorf("Expected code NOT_FOUND, got %s", data.LastCode.ValueString())
+		}
+	})
+
+	t.Run("method via reflection", func(t *testing.T) {
+		data := newModel("reflection")
+		data.Method = types.StringValue("grpc.health.v1.Health/Check")
+		data.RequestJson = types.StringValue(`{"service": "orders"}`)
+		data.Metadata = types.MapValueMust(types.StringType, map[string]attr.Value{
+			"x-request-id": types.StringValue("terraprobe"),
+		})
+		data.ExpectResponse = types.MapValueMust(types.StringType, map[string]attr.Value{
+			"$.status": types.StringValue("SERVING"),
+		})
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !data.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, got error: %s", data.Error.ValueString())
+		}
+		if data.LastResponse.ValueString() != `{"status":"SERVING"}` {
+			t.Errorf("Unexpected response: %s", data.LastResponse.ValueString())
+		}
+	})
+
+	t.Run("method via descriptor set", func(t *testing.T) {
+		data := newModel("descriptor")
+		data.Method = types.StringValue("grpc.health.v1.Health/Check")
+		data.DescriptorSetFile = types.StringValue(descriptorFile)
+		data.RequestJson = types.StringValue(`{"service": "billing"}`)
+		data.ExpectResponse = types.MapValueMust(types.StringType, map[string]attr.Value{
+			"$.status": types.StringValue("SERVING"),
+		})
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if data.TestPassed.ValueBool() {
+			t.Error("Expected response assertion to fail")
+		}
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		data := newModel("missing method")
+		data.Method = types.StringValue("grpc.health.v1.Health/Nope")
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if data.TestPassed.ValueBool() || !strings.Contains(data.Error.ValueString(), "Nope") {
+			t.Errorf("Expected method resolution failure, got: %s", data.Error.ValueString())
+		}
+	})
+
+	t.Run("invalid request json", func(t *testing.T) {
+		data := newModel("invalid request")
+		data.Method = types.StringValue("grpc.health.v1.Health/Check")
+		data.RequestJson = types.StringValue(`{"unknown": true}`)
+		if err := resource.runTest(ctx, data); err == nil {
+			t.Error("Expected an error for invalid request_json")
+		}
+	})
+}
@@ -0,0 +1,449 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// httpTransportSettings holds the provider-level tuning for the shared HTTP transport.
+type httpTransportSettings struct {
+	MaxConnsPerHost     int
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	EnableHTTP2         bool
+}
+
+// defaultHttpTransportSettings returns the settings used when the provider does not override them.
+func defaultHttpTransportSettings() httpTransportSettings {
+	return httpTransportSettings{
+		MaxConnsPerHost:     0,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		EnableHTTP2:         true,
+	}
+}
+
+// newHttpTransport creates the transport shared by all HTTP tests so that
+// connections and TLS sessions are reused between probes.
+func newHttpTransport(settings httpTransportSettings) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(settings.EnableHTTP2)
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		Protocols:             protocols,
+		MaxConnsPerHost:       settings.MaxConnsPerHost,
+		MaxIdleConns:          settings.MaxIdleConns,
+		MaxIdleConnsPerHost:   settings.MaxIdleConnsPerHost,
+		IdleConnTimeout:       settings.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// httpClientOptions describes how a single test wants its HTTP client set up.
+type httpClientOptions struct {
+	Timeout         time.Duration
+	FreshConnection bool
+
+	// HTTPVersion forces a protocol: "1.1", "2" (HTTP/2 over TLS) or
+	// "h2c" (HTTP/2 over cleartext with prior knowledge). Empty means
+	// whatever the shared transport negotiates.
+	HTTPVersion string
+
+	// UnixSocket sends every request over the unix domain socket at this path.
+	UnixSocket string
+
+	// ConnectTo dials this host:port instead of the host in the URL while
+	// keeping the URL host for the Host header and TLS server name.
+	ConnectTo string
+
+	// SourceAddress binds outgoing TCP connections to this local IP.
+	SourceAddress net.IP
+
+	// TLS customizes certificate verification and client certificates.
+	TLS httpTLSOptions
+}
+
+// httpTLSOptions holds the TLS settings shared by HTTP based tests.
+type httpTLSOptions struct {
+	InsecureSkipVerify bool
+	CACert             string
+	ClientCert         string
+	ClientKey          string
+}
+
+// isZero reports whether the options leave the transport TLS settings untouched.
+func (o httpTLSOptions) isZero() bool {
+	return o == httpTLSOptions{}
+}
+
+// key returns a compact identifier for the options without embedding key material.
+func (o httpTLSOptions) key() string {
+	sum := sha256.Sum256([]byte(o.CACert + "\x00" + o.ClientCert + "\x00" + o.ClientKey))
+	return fmt.Sprintf("insecure=%t,%x", o.InsecureSkipVerify, sum[:8])
+}
+
+// applyTo updates a TLS configuration with the options.
+func (o httpTLSOptions) applyTo(config *tls.Config) error {
+	config.InsecureSkipVerify = o.InsecureSkipVerify
+
+	if o.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(o.CACert)) {
+			return fmt.Errorf("ca_cert does not contain a valid PEM certificate")
+		}
+		config.RootCAs = pool
+	}
+
+	if o.ClientCert != "" || o.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(o.ClientCert), []byte(o.ClientKey))
+		if err != nil {
+			return fmt.Errorf("invalid client_cert/client_key: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return nil
+}
+
+// httpTLSOptionsFromModel builds TLS options from the common resource attributes.
+func httpTLSOptionsFromModel(insecureSkipVerify types.Bool, caCert, clientCert, clientKey types.String) httpTLSOptions {
+	return httpTLSOptions{
+		InsecureSkipVerify: insecureSkipVerify.ValueBool(),
+		CACert:             caCert.ValueString(),
+		ClientCert:         clientCert.ValueString(),
+		ClientKey:          clientKey.ValueString(),
+	}
+}
+
+// httpTLSSchemaAttributes returns the TLS attributes shared by HTTP based tests.
+func httpTLSSchemaAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"insecure_skip_verify": schema.BoolAttribute{
+			MarkdownDescription: "Skip verification of the server certificate",
+			Optional:            true,
+			Computed:            true,
+			Default:             booldefault.StaticBool(false),
+		},
+		"ca_cert": schema.StringAttribute{
+			MarkdownDescription: "PEM encoded CA certificate(s) used to verify the server instead of the system roots",
+			Optional:            true,
+		},
+		"client_cert": schema.StringAttribute{
+			MarkdownDescription: "PEM encoded client certificate for mutual TLS",
+			Optional:            true,
+		},
+		"client_key": schema.StringAttribute{
+			MarkdownDescription: "PEM encoded private key for `client_cert`",
+			Optional:            true,
+			Sensitive:           true,
+		},
+	}
+}
+
+// HttpBasicAuthModel describes HTTP basic authentication credentials.
+type HttpBasicAuthModel struct {
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+}
+
+// httpAuthSchemaAttributes returns the authentication attributes shared by HTTP based tests.
+func httpAuthSchemaAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"bearer_token": schema.StringAttribute{
+			MarkdownDescription: "Token sent in an `Authorization: Bearer` header",
+			Optional:            true,
+			Sensitive:           true,
+		},
+		"basic_auth": schema.SingleNestedAttribute{
+			MarkdownDescription: "HTTP basic authentication credentials",
+			Optional:            true,
+			Attributes: map[string]schema.Attribute{
+				"username": schema.StringAttribute{
+					MarkdownDescription: "Username for basic authentication",
+					Required:            true,
+				},
+				"password": schema.StringAttribute{
+					MarkdownDescription: "Password for basic authentication",
+					Required:            true,
+					Sensitive:           true,
+				},
+			},
+		},
+	}
+}
+
+// applyHttpAuth adds the configured credentials to the request.
+func applyHttpAuth(ctx context.Context, req *http.Request, bearerToken types.String, basicAuth types.Object) error {
+	if !bearerToken.IsNull() && !basicAuth.IsNull() {
+		return fmt.Errorf("only one of bearer_token and basic_auth can be set")
+	}
+
+	if !bearerToken.IsNull() {
+		req.Header.Set("Authorization", "Bearer "+bearerToken.ValueString())
+	}
+
+	if !basicAuth.IsNull() {
+		var auth HttpBasicAuthModel
+		if diags := basicAuth.As(ctx, &auth, basetypes.ObjectAsOptions{}); diags.HasError() {
+			return fmt.Errorf("failed to read basic_auth")
+		}
+		req.SetBasicAuth(auth.Username.ValueString(), auth.Password.ValueString())
+	}
+
+	return nil
+}
+
+// mergeSchemaAttributes copies shared attributes into a resource schema.
+func mergeSchemaAttributes(attributes map[string]schema.Attribute, shared ...map[string]schema.Attribute) map[string]schema.Attribute {
+	for _, set := range shared {
+		maps.Copy(attributes, set)
+	}
+	return attributes
+}
+
+// doHttpRequestWithRetries performs the request, retrying on transport
+// errors. It returns the last response or error and the time taken by the
+// final attempt.
+func doHttpRequestWithRetries(client *http.Client, req *http.Request, retries int64, retryDelay time.Duration) (*http.Response, time.Duration, error) {
+	var resp *http.Response
+	var respErr error
+	var responseTime time.Duration
+
+	for i := int64(0); i <= retries; i++ {
+		// Rewind the body consumed by a previous attempt
+		if i > 0 && req.GetBody != nil {
+			req.Body, _ = req.GetBody()
+		}
+
+		start := time.Now()
+		resp, respErr = client.Do(req)
+		responseTime = time.Since(start)
+
+		if respErr == nil {
+			break
+		}
+
+		if i < retries {
+			time.Sleep(retryDelay)
+		}
+	}
+
+	return resp, responseTime, respErr
+}
+
+// transportKey identifies the derived transport for these options. An empty
+// key means the shared provider transport can be used as is.
+func (o httpClientOptions) transportKey() string {
+	var parts []string
+	if o.HTTPVersion != "" {
+		parts = append(parts, "version="+o.HTTPVersion)
+	}
+	if o.UnixSocket != "" {
+		parts = append(parts, "unix="+o.UnixSocket)
+	}
+	if o.ConnectTo != "" {
+		parts = append(parts, "connect_to="+o.ConnectTo)
+	}
+	if o.SourceAddress != nil {
+		parts = append(parts, "source="+o.SourceAddress.String())
+	}
+	if !o.TLS.isZero() {
+		parts = append(parts, "tls="+o.TLS.key())
+	}
+	return strings.Join(parts, ";")
+}
+
+// applyTo customizes a cloned transport according to the options.
+func (o httpClientOptions) applyTo(transport *http.Transport) error {
+	if !o.TLS.isZero() {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		if err := o.TLS.applyTo(transport.TLSClientConfig); err != nil {
+			return err
+		}
+	}
+
+	if o.UnixSocket != "" || o.ConnectTo != "" || o.SourceAddress != nil {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+		if o.SourceAddress != nil {
+			dialer.LocalAddr = &net.TCPAddr{IP: o.SourceAddress}
+		}
+
+		transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+			switch {
+			case o.UnixSocket != "":
+				return dialer.DialContext(ctx, "unix", o.UnixSocket)
+			case o.ConnectTo != "":
+				return dialer.DialContext(ctx, network, o.ConnectTo)
+			default:
+				return dialer.DialContext(ctx, network, address)
+			}
+		}
+
+		// A proxy would bypass the custom dial target
+		if o.UnixSocket != "" || o.ConnectTo != "" {
+			transport.Proxy = nil
+		}
+	}
+
+	if o.HTTPVersion == "" {
+		return nil
+	}
+
+	protocols := new(http.Protocols)
+	switch o.HTTPVersion {
+	case "1.1":
+		protocols.SetHTTP1(true)
+	case "2":
+		protocols.SetHTTP2(true)
+	case "h2c":
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	transport.Protocols = protocols
+
+	// Let the transport advertise ALPN protocols matching the forced version
+	if transport.TLSClientConfig != nil {
+		transport.TLSClientConfig.NextProtos = nil
+	}
+
+	return nil
+}
+
+// validateHttpVersion checks an http_version value.
+func validateHttpVersion(version string) error {
+	switch version {
+	case "", "1.1", "2", "h2c":
+		return nil
+	default:
+		return fmt.Errorf("unsupported http_version: %s (expected 1.1, 2 or h2c)", version)
+	}
+}
+
+// validateConnectTo checks a connect_to value.
+func validateConnectTo(connectTo string) error {
+	if connectTo == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(connectTo); err != nil {
+		return fmt.Errorf("invalid connect_to %q, expected host:port: %w", connectTo, err)
+	}
+	return nil
+}
+
+// normalizeHttpProto converts shorthand protocol names such as "2" or "h2"
+// into the form reported by http.Response.Proto.
+func normalizeHttpProto(proto string) string {
+	switch strings.ToLower(strings.TrimSpace(proto)) {
+	case "1.0", "http/1.0":
+		return "HTTP/1.0"
+	case "1.1", "http/1.1":
+		return "HTTP/1.1"
+	case "2", "2.0", "h2", "h2c", "http/2", "http/2.0":
+		return "HTTP/2.0"
+	default:
+		return proto
+	}
+}
+
+// sharedTransport returns the provider transport, falling back to the
+// default transport when the provider was configured without one.
+func (c *TerraProbeClientConfig) sharedTransport() *http.Transport {
+	if c.HttpClient != nil {
+		if transport, ok := c.HttpClient.Transport.(*http.Transport); ok {
+			return transport
+		}
+	}
+
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		return transport
+	}
+
+	return newHttpTransport(defaultHttpTransportSettings())
+}
+
+// newHttpClient returns a client for a single test run together with a
+// cleanup function that must be called once the response has been consumed.
+// Clients share the provider transport unless a fresh connection is requested.
+func (c *TerraProbeClientConfig) newHttpClient(opts httpClientOptions) (*http.Client, func(), error) {
+	client := &http.Client{}
+	if c.HttpClient != nil {
+		*client = *c.HttpClient
+	}
+	client.Timeout = opts.Timeout
+
+	if !opts.FreshConnection {
+		transport, err := c.pooledTransport(opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		client.Transport = transport
+		return client, func() {}, nil
+	}
+
+	// A private transport without keep-alives guarantees a cold connection
+	fresh := c.sharedTransport().Clone()
+	if err := opts.applyTo(fresh); err != nil {
+		return nil, nil, err
+	}
+	fresh.DisableKeepAlives = true
+	client.Transport = fresh
+
+	return client, fresh.CloseIdleConnections, nil
+}
+
+// pooledTransport returns a long-lived transport for the options. Options
+// that need a customized transport get their own pool, created on first use
+// and shared by every test with the same settings.
+func (c *TerraProbeClientConfig) pooledTransport(opts httpClientOptions) (*http.Transport, error) {
+	key := opts.transportKey()
+	if key == "" {
+		return c.sharedTransport(), nil
+	}
+
+	c.transportsMu.Lock()
+	defer c.transportsMu.Unlock()
+
+	if transport, ok := c.transports[key]; ok {
+		return transport, nil
+	}
+
+	transport := c.sharedTransport().Clone()
+	if err := opts.applyTo(transport); err != nil {
+		return nil, err
+	}
+
+	if c.transports == nil {
+		c.transports = make(map[string]*http.Transport)
+	}
+	c.transports[key] = transport
+
+	return transport, nil
+}
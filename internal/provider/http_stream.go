@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// httpStreamSSE reads a text/event-stream body as Server-Sent Events.
+	httpStreamSSE = "sse"
+	// httpStreamLines treats every non-empty line as an event, e.g. NDJSON.
+	httpStreamLines = "lines"
+
+	// maxHttpStreamLine bounds the length of a single line in a stream.
+	maxHttpStreamLine = 1024 * 1024
+)
+
+// validateHttpStream checks a stream value.
+func validateHttpStream(stream string) error {
+	switch stream {
+	case "", httpStreamSSE, httpStreamLines:
+		return nil
+	default:
+		return fmt.Errorf("unsupported stream %q, must be one of: sse, lines", stream)
+	}
+}
+
+// httpStreamResult holds the events read from a streaming response body.
+type httpStreamResult struct {
+	Events []string
+	// Raw is everything read from the body, including partial events.
+	Raw []byte
+	// FirstEvent is the time between start and the first complete event.
+	FirstEvent time.Duration
+}
+
+// readHttpStream reads events from a streaming response body until it ends,
+// maxEvents events have been read (0 means no limit) or reading fails. The
+// events read so far are returned along with any read error, so callers can
+// treat an error caused by closing the body at the end of a time window as a
+// normal end of the stream.
+func readHttpStream(body io.Reader, mode string, maxEvents int64, start time.Time) (httpStreamResult, error) {
+	var result httpStreamResult
+	var raw bytes.Buffer
+
+	scanner := bufio.NewScanner(io.TeeReader(body, &raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxHttpStreamLine)
+
+	emit := func(event string) bool {
+		if len(result.Events) == 0 {
+			result.FirstEvent = time.Since(start)
+		}
+		result.Events = append(result.Events, event)
+		return maxEvents > 0 && int64(len(result.Events)) >= maxEvents
+	}
+
+	// Data lines of the Server-Sent Event being assembled
+	var data []string
+	dataSeen := false
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if mode == httpStreamLines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if emit(line) {
+				break
+			}
+			continue
+		}
+
+		// A blank line dispatches the event; events without data are ignored
+		if line == "" {
+			if dataSeen {
+				done := emit(strings.Join(data, "\n"))
+				data, dataSeen = nil, false
+				if done {
+					break
+				}
+			}
+			continue
+		}
+
+		// Lines starting with a colon are comments, e.g. keep-alives
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		if field == "data" {
+			data = append(data, value)
+			dataSeen = true
+		}
+	}
+
+	result.Raw = raw.Bytes()
+	return result, scanner.Err()
+}
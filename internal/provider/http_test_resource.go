@@ -1,16 +1,32 @@
 package provider
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
@@ -34,27 +50,57 @@ type HttpTestResource struct {
 
 // HttpTestResourceModel describes the resource data model.
 type HttpTestResourceModel struct {
-	Name             types.String `tfsdk:"name"`
-	URL              types.String `tfsdk:"url"`
-	Method           types.String `tfsdk:"method"`
-	Headers          types.Map    `tfsdk:"headers"`
-	Body             types.String `tfsdk:"body"`
-	Timeout          types.Int64  `tfsdk:"timeout"`
-	Retries          types.Int64  `tfsdk:"retries"`
-	RetryDelay       types.Int64  `tfsdk:"retry_delay"`
-	ExpectStatusCode types.Int64  `tfsdk:"expect_status_code"`
-	ExpectContains   types.String `tfsdk:"expect_contains"`
-	Id               types.String `tfsdk:"id"`
+	Name             types.String  `tfsdk:"name"`
+	URL              types.String  `tfsdk:"url"`
+	Method           types.String  `tfsdk:"method"`
+	Headers          types.Map     `tfsdk:"headers"`
+	Body             types.String  `tfsdk:"body"`
+	BodyFile         types.String  `tfsdk:"body_file"`
+	Form             types.Map     `tfsdk:"form"`
+	Multipart        types.List    `tfsdk:"multipart"`
+	JsonBody         types.Dynamic `tfsdk:"json_body"`
+	CompressBody     types.Bool    `tfsdk:"compress_body"`
+	Timeout          types.Int64   `tfsdk:"timeout"`
+	Retries          types.Int64   `tfsdk:"retries"`
+	RetryDelay       types.Int64   `tfsdk:"retry_delay"`
+	ExpectStatusCode types.Int64   `tfsdk:"expect_status_code"`
+	ExpectContains   types.String  `tfsdk:"expect_contains"`
+	FreshConnection  types.Bool    `tfsdk:"fresh_connection"`
+	HTTPVersion      types.String  `tfsdk:"http_version"`
+	ExpectProtocol   types.String  `tfsdk:"expect_protocol"`
+	UnixSocket       types.String  `tfsdk:"unix_socket"`
+	ConnectTo        types.String  `tfsdk:"connect_to"`
+	SourceAddress    types.String  `tfsdk:"source_address"`
+	Stream           types.String  `tfsdk:"stream"`
+	StreamDuration   types.Int64   `tfsdk:"stream_duration"`
+	StreamMaxEvents  types.Int64   `tfsdk:"stream_max_events"`
+	ExpectMinEvents  types.Int64   `tfsdk:"expect_min_events"`
+	ExpectEventMatch types.String  `tfsdk:"expect_event_matches"`
+	Id               types.String  `tfsdk:"id"`
 
 	// Results
 	LastRun          types.String `tfsdk:"last_run"`
 	LastStatusCode   types.Int64  `tfsdk:"last_status_code"`
 	LastResponseBody types.String `tfsdk:"last_response_body"`
 	LastResponseTime types.Int64  `tfsdk:"last_response_time"`
+	LastProtocol     types.String `tfsdk:"last_protocol"`
+	LastALPNProtocol types.String `tfsdk:"last_alpn_protocol"`
+	LastLocalAddress types.String `tfsdk:"last_local_address"`
+	LastEventCount   types.Int64  `tfsdk:"last_event_count"`
+	LastFirstEvent   types.Int64  `tfsdk:"last_time_to_first_event"`
 	TestPassed       types.Bool   `tfsdk:"test_passed"`
 	Error            types.String `tfsdk:"error"`
 }
 
+// HttpMultipartPartModel describes a single part of a multipart/form-data body.
+type HttpMultipartPartModel struct {
+	Name        types.String `tfsdk:"name"`
+	Value       types.String `tfsdk:"value"`
+	File        types.String `tfsdk:"file"`
+	Filename    types.String `tfsdk:"filename"`
+	ContentType types.String `tfsdk:"content_type"`
+}
+
 func (r *HttpTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_http_test"
 }
@@ -87,6 +133,53 @@ func (r *HttpTestResource) Schema(ctx context.Context, req resource.SchemaReques
 				MarkdownDescription: "Request body for POST, PUT, etc.",
 				Optional:            true,
 			},
+			"body_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a file whose contents are sent as the request body",
+				Optional:            true,
+			},
+			"form": schema.MapAttribute{
+				MarkdownDescription: "Form fields sent as an `application/x-www-form-urlencoded` body",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"multipart": schema.ListNestedAttribute{
+				MarkdownDescription: "Parts sent as a `multipart/form-data` body",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							MarkdownDescription: "Form field name of the part",
+							Required:            true,
+						},
+						"value": schema.StringAttribute{
+							MarkdownDescription: "Value of a plain field part",
+							Optional:            true,
+						},
+						"file": schema.StringAttribute{
+							MarkdownDescription: "Path to a file uploaded as the part contents",
+							Optional:            true,
+						},
+						"filename": schema.StringAttribute{
+							MarkdownDescription: "File name reported for a file part (default: base name of `file`)",
+							Optional:            true,
+						},
+						"content_type": schema.StringAttribute{
+							MarkdownDescription: "Content type of the part",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"json_body": schema.DynamicAttribute{
+				MarkdownDescription: "Object encoded to JSON and sent as the request body. `Content-Type: application/json` is set unless overridden in `headers`.",
+				Optional:            true,
+			},
+			"compress_body": schema.BoolAttribute{
+				MarkdownDescription: "Compress the request body with gzip and set `Content-Encoding: gzip`",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
 			"timeout": schema.Int64Attribute{
 				MarkdownDescription: "Timeout in seconds for the HTTP request",
 				Optional:            true,
@@ -115,6 +208,53 @@ func (r *HttpTestResource) Schema(ctx context.Context, req resource.SchemaReques
 				MarkdownDescription: "String to look for in the response body",
 				Optional:            true,
 			},
+			"fresh_connection": schema.BoolAttribute{
+				MarkdownDescription: "Open a new connection for every request instead of reusing the provider's shared connection pool. Useful when measuring cold connection timing.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"http_version": schema.StringAttribute{
+				MarkdownDescription: "Force the HTTP protocol version: `1.1`, `2` (HTTP/2 over TLS) or `h2c` (HTTP/2 over cleartext with prior knowledge). By default the version is negotiated.",
+				Optional:            true,
+			},
+			"expect_protocol": schema.StringAttribute{
+				MarkdownDescription: "Expected negotiated protocol, e.g. `HTTP/2.0` or `HTTP/1.1` (shorthands such as `2` and `1.1` are accepted)",
+				Optional:            true,
+			},
+			"unix_socket": schema.StringAttribute{
+				MarkdownDescription: "Path of a unix domain socket to send the request over. The URL host is only used for the Host header, e.g. `http://localhost/info`.",
+				Optional:            true,
+			},
+			"connect_to": schema.StringAttribute{
+				MarkdownDescription: "Connect to this `host:port` instead of the host in the URL, keeping the URL host for the Host header and TLS server name (like curl's `--connect-to`)",
+				Optional:            true,
+			},
+			"source_address": sourceAddressSchemaAttribute(),
+			"stream": schema.StringAttribute{
+				MarkdownDescription: "Read the response as a stream of events instead of waiting for the body to end: `sse` for Server-Sent Events or `lines` for line delimited bodies such as NDJSON",
+				Optional:            true,
+			},
+			"stream_duration": schema.Int64Attribute{
+				MarkdownDescription: "Seconds to read events for in streaming mode, bounded by `timeout`",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means read until the timeout
+			},
+			"stream_max_events": schema.Int64Attribute{
+				MarkdownDescription: "Stop reading after this many events in streaming mode",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means no limit
+			},
+			"expect_min_events": schema.Int64Attribute{
+				MarkdownDescription: "Minimum number of events that must be received in streaming mode",
+				Optional:            true,
+			},
+			"expect_event_matches": schema.StringAttribute{
+				MarkdownDescription: "Regular expression that at least one event must match in streaming mode. Server-Sent Events are matched on their data.",
+				Optional:            true,
+			},
 
 			// Results - these are computed values based on the last test run
 			"last_run": schema.StringAttribute{
@@ -133,6 +273,26 @@ func (r *HttpTestResource) Schema(ctx context.Context, req resource.SchemaReques
 				MarkdownDescription: "Response time in milliseconds from the last test run",
 				Computed:            true,
 			},
+			"last_protocol": schema.StringAttribute{
+				MarkdownDescription: "Protocol of the response from the last test run (e.g. `HTTP/2.0`)",
+				Computed:            true,
+			},
+			"last_alpn_protocol": schema.StringAttribute{
+				MarkdownDescription: "Protocol negotiated via TLS ALPN in the last test run, empty for cleartext connections",
+				Computed:            true,
+			},
+			"last_local_address": schema.StringAttribute{
+				MarkdownDescription: "Local address (`ip:port`) of the connection used in the last test run, empty for unix domain sockets or if no connection was established",
+				Computed:            true,
+			},
+			"last_event_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of events received in streaming mode in the last test run",
+				Computed:            true,
+			},
+			"last_time_to_first_event": schema.Int64Attribute{
+				MarkdownDescription: "Time in milliseconds until the first event was received in streaming mode in the last test run",
+				Computed:            true,
+			},
 			"test_passed": schema.BoolAttribute{
 				MarkdownDescription: "Whether the test passed",
 				Computed:            true,
@@ -289,20 +449,79 @@ func (r *HttpTestResource) runTest(ctx context.Context, data *HttpTestResourceMo
 		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
 	}
 
-	// Create a custom client with the specified timeout
-	client := &http.Client{
-		Timeout: timeout,
+	// Validate the forced protocol version
+	httpVersion := data.HTTPVersion.ValueString()
+	if err := validateHttpVersion(httpVersion); err != nil {
+		return err
+	}
+
+	// Validate the dial target overrides
+	if !data.UnixSocket.IsNull() && !data.ConnectTo.IsNull() {
+		return fmt.Errorf("only one of unix_socket and connect_to can be set")
+	}
+	if err := validateConnectTo(data.ConnectTo.ValueString()); err != nil {
+		return err
+	}
+	sourceAddress, err := parseSourceAddress(data.SourceAddress.ValueString())
+	if err != nil {
+		return err
+	}
+	if sourceAddress != nil && !data.UnixSocket.IsNull() {
+		return fmt.Errorf("source_address cannot be used with unix_socket")
+	}
+
+	// Validate the streaming options
+	stream := data.Stream.ValueString()
+	if err := validateHttpStream(stream); err != nil {
+		return err
+	}
+	if stream == "" && (!data.ExpectMinEvents.IsNull() || !data.ExpectEventMatch.IsNull()) {
+		return fmt.Errorf("expect_min_events and expect_event_matches require stream to be set")
+	}
+	var eventPattern *regexp.Regexp
+	if !data.ExpectEventMatch.IsNull() {
+		re, err := regexp.Compile(data.ExpectEventMatch.ValueString())
+		if err != nil {
+			return fmt.Errorf("invalid expect_event_matches: %w", err)
+		}
+		eventPattern = re
 	}
 
+	// Use the shared provider transport unless a fresh connection is requested
+	client, closeClient, err := r.clientConfig.newHttpClient(httpClientOptions{
+		Timeout:         timeout,
+		FreshConnection: data.FreshConnection.ValueBool(),
+		HTTPVersion:     httpVersion,
+		UnixSocket:      data.UnixSocket.ValueString(),
+		ConnectTo:       data.ConnectTo.ValueString(),
+		SourceAddress:   sourceAddress,
+	})
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	// Reset protocol results from any previous run
+	data.LastProtocol = types.StringValue("")
+	data.LastALPNProtocol = types.StringValue("")
+	data.LastLocalAddress = types.StringValue("")
+	data.LastEventCount = types.Int64Value(0)
+	data.LastFirstEvent = types.Int64Value(0)
+
 	// Create the request
 	method := "GET"
 	if !data.Method.IsNull() {
 		method = data.Method.ValueString()
 	}
 
+	payload, contentType, err := buildRequestBody(ctx, data)
+	if err != nil {
+		return err
+	}
+
 	var body io.Reader
-	if !data.Body.IsNull() {
-		body = strings.NewReader(data.Body.ValueString())
+	if payload != nil {
+		body = bytes.NewReader(payload)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, data.URL.ValueString(), body)
@@ -322,27 +541,28 @@ func (r *HttpTestResource) runTest(ctx context.Context, data *HttpTestResourceMo
 		}
 	}
 
+	// Set the body encoding unless the headers already specify it
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if data.CompressBody.ValueBool() && payload != nil {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
 	// Add user agent
 	req.Header.Set("User-Agent", r.clientConfig.UserAgent)
 
-	// Perform the request with retries
-	var resp *http.Response
-	var respErr error
-	var responseTime time.Duration
-
-	for i := int64(0); i <= retries; i++ {
-		start := time.Now()
-		resp, respErr = client.Do(req)
-		responseTime = time.Since(start)
-
-		if respErr == nil {
-			break
-		}
+	// Record the local address of the connection the request is sent on
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if addr, ok := info.Conn.LocalAddr().(*net.TCPAddr); ok {
+				data.LastLocalAddress = types.StringValue(addr.String())
+			}
+		},
+	}))
 
-		if i < retries {
-			time.Sleep(retryDelay)
-		}
-	}
+	// Perform the request with retries
+	resp, responseTime, respErr := doHttpRequestWithRetries(client, req, retries, retryDelay)
 
 	// Handle request errors
 	if respErr != nil {
@@ -355,8 +575,20 @@ func (r *HttpTestResource) runTest(ctx context.Context, data *HttpTestResourceMo
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	// Read the response body
-	respBody, err := io.ReadAll(resp.Body)
+	// Read the response body, or a bounded window of events when streaming
+	var respBody []byte
+	var events []string
+	if stream != "" {
+		var result httpStreamResult
+		result, err = r.readStream(resp, stream, data, timeout, responseTime)
+		respBody, events = result.Raw, result.Events
+		data.LastEventCount = types.Int64Value(int64(len(events)))
+		if len(events) > 0 {
+			data.LastFirstEvent = types.Int64Value(int64(result.FirstEvent / time.Millisecond))
+		}
+	} else {
+		respBody, err = io.ReadAll(resp.Body)
+	}
 	if err != nil {
 		data.Error = types.StringValue(fmt.Sprintf("Failed to read response body: %s", err.Error()))
 		data.TestPassed = types.BoolValue(false)
@@ -370,6 +602,10 @@ func (r *HttpTestResource) runTest(ctx context.Context, data *HttpTestResourceMo
 	data.LastResponseTime = types.Int64Value(int64(responseTime / time.Millisecond))
 	data.LastStatusCode = types.Int64Value(int64(resp.StatusCode))
 	data.LastResponseBody = types.StringValue(string(respBody))
+	data.LastProtocol = types.StringValue(resp.Proto)
+	if resp.TLS != nil {
+		data.LastALPNProtocol = types.StringValue(resp.TLS.NegotiatedProtocol)
+	}
 
 	// Check if the test passed
 	passed := true
@@ -394,6 +630,26 @@ func (r *HttpTestResource) runTest(ctx context.Context, data *HttpTestResourceMo
 		}
 	}
 
+	// Check the received events when streaming
+	if !data.ExpectMinEvents.IsNull() && int64(len(events)) < data.ExpectMinEvents.ValueInt64() {
+		passed = false
+		errorMsg.WriteString(fmt.Sprintf("Expected at least %d events but got %d. ", data.ExpectMinEvents.ValueInt64(), len(events)))
+	}
+
+	if eventPattern != nil && !slices.ContainsFunc(events, eventPattern.MatchString) {
+		passed = false
+		errorMsg.WriteString(fmt.Sprintf("No event matches '%s'. ", eventPattern.String()))
+	}
+
+	// Check the negotiated protocol if specified
+	if !data.ExpectProtocol.IsNull() && data.ExpectProtocol.ValueString() != "" {
+		expectedProto := normalizeHttpProto(data.ExpectProtocol.ValueString())
+		if resp.Proto != expectedProto {
+			passed = false
+			errorMsg.WriteString(fmt.Sprintf("Expected protocol %s but got %s. ", expectedProto, resp.Proto))
+		}
+	}
+
 	// Set the test result
 	data.TestPassed = types.BoolValue(passed)
 
@@ -406,3 +662,255 @@ func (r *HttpTestResource) runTest(ctx context.Context, data *HttpTestResourceMo
 
 	return nil
 }
+
+// readStream reads events from a streaming response until the stream ends,
+// the event limit is reached or the stream window closes. Hitting the window
+// or the request timeout is the expected way for a stream to end, so it is
+// not reported as an error.
+func (r *HttpTestResource) readStream(resp *http.Response, stream string, data *HttpTestResourceModel, timeout, responseTime time.Duration) (httpStreamResult, error) {
+	window := timeout
+	if duration := time.Duration(data.StreamDuration.ValueInt64()) * time.Second; duration > 0 && (window <= 0 || duration < window) {
+		window = duration
+	}
+
+	// Closing the body unblocks a pending read once the window has passed
+	var expired atomic.Bool
+	if window > 0 {
+		timer := time.AfterFunc(window, func() {
+			expired.Store(true)
+			_ = resp.Body.Close()
+		})
+		defer timer.Stop()
+	}
+
+	// Measure time to first event from when the request was sent
+	start := time.Now().Add(-responseTime)
+	result, err := readHttpStream(resp.Body, stream, data.StreamMaxEvents.ValueInt64(), start)
+
+	var netErr net.Error
+	if err != nil && (expired.Load() || (errors.As(err, &netErr) && netErr.Timeout())) {
+		err = nil
+	}
+
+	return result, err
+}
+
+// buildRequestBody encodes the configured request body and returns it along
+// with the content type it implies. A nil payload means no body is sent.
+func buildRequestBody(ctx context.Context, data *HttpTestResourceModel) ([]byte, string, error) {
+	// Only one body source may be configured
+	sources := 0
+	for _, set := range []bool{
+		!data.Body.IsNull(),
+		!data.BodyFile.IsNull(),
+		!data.Form.IsNull(),
+		!data.Multipart.IsNull(),
+		!data.JsonBody.IsNull() && !data.JsonBody.IsUnderlyingValueNull(),
+	} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, "", fmt.Errorf("only one of body, body_file, form, multipart and json_body can be set")
+	}
+
+	var payload []byte
+	var contentType string
+
+	switch {
+	case !data.Body.IsNull():
+		payload = []byte(data.Body.ValueString())
+	case !data.BodyFile.IsNull():
+		content, err := os.ReadFile(data.BodyFile.ValueString())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read body_file: %w", err)
+		}
+		payload = content
+	case !data.Form.IsNull():
+		fields := make(map[string]string)
+		if diags := data.Form.ElementsAs(ctx, &fields, false); diags.HasError() {
+			return nil, "", fmt.Errorf("failed to read form fields")
+		}
+
+		values := url.Values{}
+		for k, v := range fields {
+			values.Set(k, v)
+		}
+		payload = []byte(values.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case !data.Multipart.IsNull():
+		var parts []HttpMultipartPartModel
+		if diags := data.Multipart.ElementsAs(ctx, &parts, false); diags.HasError() {
+			return nil, "", fmt.Errorf("failed to read multipart parts")
+		}
+
+		encoded, boundaryType, err := encodeMultipart(parts)
+		if err != nil {
+			return nil, "", err
+		}
+		payload = encoded
+		contentType = boundaryType
+	case !data.JsonBody.IsNull() && !data.JsonBody.IsUnderlyingValueNull():
+		value, err := attrValueToInterface(data.JsonBody.UnderlyingValue())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to convert json_body: %w", err)
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode json_body: %w", err)
+		}
+		payload = encoded
+		contentType = "application/json"
+	default:
+		return nil, "", nil
+	}
+
+	// Compress the payload if requested
+	if data.CompressBody.ValueBool() {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(payload); err != nil {
+			return nil, "", fmt.Errorf("failed to compress request body: %w", err)
+		}
+		if err := gz.Close(); err != nil {
+			return nil, "", fmt.Errorf("failed to compress request body: %w", err)
+		}
+		payload = buf.Bytes()
+	}
+
+	return payload, contentType, nil
+}
+
+// encodeMultipart builds a multipart/form-data body from the configured parts.
+func encodeMultipart(parts []HttpMultipartPartModel) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for _, part := range parts {
+		name := part.Name.ValueString()
+
+		var content []byte
+		filename := ""
+		switch {
+		case !part.File.IsNull() && !part.Value.IsNull():
+			return nil, "", fmt.Errorf("multipart part %q must set only one of value and file", name)
+		case !part.File.IsNull():
+			fileContent, err := os.ReadFile(part.File.ValueString())
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read multipart file for part %q: %w", name, err)
+			}
+			content = fileContent
+			filename = filepath.Base(part.File.ValueString())
+		default:
+			content = []byte(part.Value.ValueString())
+		}
+
+		if !part.Filename.IsNull() {
+			filename = part.Filename.ValueString()
+		}
+
+		// Build the part headers the same way the multipart helpers do
+		header := make(textproto.MIMEHeader)
+		disposition := fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(name))
+		if filename != "" {
+			disposition += fmt.Sprintf(`; filename="%s"`, escapeQuotes(filename))
+		}
+		header.Set("Content-Disposition", disposition)
+
+		switch {
+		case !part.ContentType.IsNull():
+			header.Set("Content-Type", part.ContentType.ValueString())
+		case filename != "":
+			header.Set("Content-Type", "application/octet-stream")
+		}
+
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create multipart part %q: %w", name, err)
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, "", fmt.Errorf("failed to write multipart part %q: %w", name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finish multipart body: %w", err)
+	}
+
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes a value for use in a Content-Disposition parameter.
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// attrValueToInterface converts a Terraform value into plain Go values that
+// encoding/json can marshal.
+func attrValueToInterface(value attr.Value) (interface{}, error) {
+	if value == nil || value.IsNull() {
+		return nil, nil
+	}
+	if value.IsUnknown() {
+		return nil, fmt.Errorf("value is not yet known")
+	}
+
+	switch v := value.(type) {
+	case types.String:
+		return v.ValueString(), nil
+	case types.Bool:
+		return v.ValueBool(), nil
+	case types.Int64:
+		return v.ValueInt64(), nil
+	case types.Int32:
+		return v.ValueInt32(), nil
+	case types.Float64:
+		return v.ValueFloat64(), nil
+	case types.Float32:
+		return v.ValueFloat32(), nil
+	case types.Number:
+		return json.Number(v.ValueBigFloat().Text('g', -1)), nil
+	case types.Dynamic:
+		return attrValueToInterface(v.UnderlyingValue())
+	case types.List:
+		return attrValuesToSlice(v.Elements())
+	case types.Set:
+		return attrValuesToSlice(v.Elements())
+	case types.Tuple:
+		return attrValuesToSlice(v.Elements())
+	case types.Map:
+		return attrValuesToMap(v.Elements())
+	case types.Object:
+		return attrValuesToMap(v.Attributes())
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+func attrValuesToSlice(elements []attr.Value) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		converted, err := attrValueToInterface(element)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, converted)
+	}
+	return result, nil
+}
+
+func attrValuesToMap(elements map[string]attr.Value) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(elements))
+	for key, element := range elements {
+		converted, err := attrValueToInterface(element)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = converted
+	}
+	return result, nil
+}
@@ -1,12 +1,24 @@
 package provider
 
 import (
+	"compress/gzip"
 	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
@@ -107,3 +119,574 @@ func TestAccHttpTestResource(t *testing.T) {
 		},
 	})
 }
+
+// TestHttpTestResource_connectionReuse verifies that HTTP tests share the
+// provider connection pool unless a fresh connection is requested.
+func TestHttpTestResource_connectionReuse(t *testing.T) {
+	// Count the connections accepted by the test server
+	var mu sync.Mutex
+	newConns := 0
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			newConns++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	clientConfig := &TerraProbeClientConfig{
+		HttpClient: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: newHttpTransport(defaultHttpTransportSettings()),
+		},
+		UserAgent:  "TerraProbe-Test",
+		Retries:    0,
+		RetryDelay: time.Second,
+	}
+
+	resource := &HttpTestResource{
+		clientConfig: clientConfig,
+	}
+
+	ctx := context.Background()
+
+	countConns := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return newConns
+	}
+
+	// Shared pool - the second run should reuse the first connection
+	for i := 0; i < 2; i++ {
+		model := &HttpTestResourceModel{
+			Name:             types.StringValue("Pooled HTTP"),
+			URL:              types.StringValue(server.URL),
+			Method:           types.StringValue("GET"),
+			ExpectStatusCode: types.Int64Value(200),
+			FreshConnection:  types.BoolValue(false),
+		}
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if !model.TestPassed.ValueBool() {
+			t.Fatalf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+		}
+	}
+
+	if got := countConns(); got != 1 {
+		t.Errorf("Expected 1 connection with the shared pool, got %d", got)
+	}
+
+	// Fresh connections - every run should dial again
+	for i := 0; i < 2; i++ {
+		model := &HttpTestResourceModel{
+			Name:             types.StringValue("Fresh HTTP"),
+			URL:              types.StringValue(server.URL),
+			Method:           types.StringValue("GET"),
+			ExpectStatusCode: types.Int64Value(200),
+			FreshConnection:  types.BoolValue(true),
+		}
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+	}
+
+	if got := countConns(); got != 3 {
+		t.Errorf("Expected 3 connections after two fresh runs, got %d", got)
+	}
+}
+
+// TestHttpTestResource_protocolVersion tests forcing and asserting the HTTP protocol version.
+func TestHttpTestResource_protocolVersion(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(r.Proto))
+	})
+
+	// TLS server that supports HTTP/2 via ALPN
+	tlsServer := httptest.NewUnstartedServer(handler)
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	// Cleartext server that accepts HTTP/2 with prior knowledge
+	h2cServer := httptest.NewUnstartedServer(handler)
+	h2cServer.Config.Protocols = new(http.Protocols)
+	h2cServer.Config.Protocols.SetHTTP1(true)
+	h2cServer.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2cServer.Start()
+	defer h2cServer.Close()
+
+	// The test server client trusts the TLS server certificate
+	httpClient := tlsServer.Client()
+	httpClient.Timeout = 5 * time.Second
+
+	resource := &HttpTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: httpClient,
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		url           string
+		httpVersion   string
+		expectProto   string
+		expectPassed  bool
+		expectedProto string
+		expectedALPN  string
+	}{
+		{"negotiated HTTP/2", tlsServer.URL, "", "HTTP/2.0", true, "HTTP/2.0", "h2"},
+		{"forced HTTP/1.1", tlsServer.URL, "1.1", "1.1", true, "HTTP/1.1", ""},
+		{"forced HTTP/2", tlsServer.URL, "2", "2", true, "HTTP/2.0", "h2"},
+		{"h2c prior knowledge", h2cServer.URL, "h2c", "HTTP/2.0", true, "HTTP/2.0", ""},
+		{"protocol mismatch", h2cServer.URL, "", "2", false, "HTTP/1.1", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			model := &HttpTestResourceModel{
+				Name:             types.StringValue(tc.name),
+				URL:              types.StringValue(tc.url),
+				Method:           types.StringValue("GET"),
+				ExpectStatusCode: types.Int64Value(200),
+				HTTPVersion:      types.StringValue(tc.httpVersion),
+				ExpectProtocol:   types.StringValue(tc.expectProto),
+			}
+
+			if err := resource.runTest(ctx, model); err != nil {
+				t.Fatalf("runTest failed: %v", err)
+			}
+
+			if model.TestPassed.ValueBool() != tc.expectPassed {
+				t.Errorf("Expected test_passed=%t, got %t (error: %s)", tc.expectPassed, model.TestPassed.ValueBool(), model.Error.ValueString())
+			}
+
+			if model.LastProtocol.ValueString() != tc.expectedProto {
+				t.Errorf("Expected protocol %s, got %s", tc.expectedProto, model.LastProtocol.ValueString())
+			}
+
+			if model.LastALPNProtocol.ValueString() != tc.expectedALPN {
+				t.Errorf("Expected ALPN protocol %q, got %q", tc.expectedALPN, model.LastALPNProtocol.ValueString())
+			}
+		})
+	}
+
+	// Unsupported versions are configuration errors
+	model := &HttpTestResourceModel{
+		Name:        types.StringValue("Invalid version"),
+		URL:         types.StringValue(tlsServer.URL),
+		HTTPVersion: types.StringValue("3"),
+	}
+	if err := resource.runTest(ctx, model); err == nil {
+		t.Errorf("Expected error for unsupported http_version, but got none")
+	}
+}
+
+// TestHttpTestResource_requestBodies tests the different request body encodings.
+func TestHttpTestResource_requestBodies(t *testing.T) {
+	// Echo a summary of the decoded request back to the client
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			reader = gz
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "application/x-www-form-urlencoded":
+			content, _ := io.ReadAll(reader)
+			values, _ := url.ParseQuery(string(content))
+			_, _ = fmt.Fprintf(w, "form:%s", values.Get("user"))
+		case "multipart/form-data":
+			r.Body = io.NopCloser(reader)
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			file, header, err := r.FormFile("upload")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := io.ReadAll(file)
+			_, _ = fmt.Fprintf(w, "multipart:%s:%s:%s", r.FormValue("field"), header.Filename, content)
+		default:
+			content, _ := io.ReadAll(reader)
+			_, _ = fmt.Fprintf(w, "%s:%s", mediaType, content)
+		}
+	}))
+	defer server.Close()
+
+	resource := &HttpTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	ctx := context.Background()
+
+	// Files used by body_file and multipart uploads
+	dir := t.TempDir()
+	bodyFile := filepath.Join(dir, "body.txt")
+	if err := os.WriteFile(bodyFile, []byte("from file"), 0o600); err != nil {
+		t.Fatalf("failed to write body file: %v", err)
+	}
+	uploadFile := filepath.Join(dir, "report.csv")
+	if err := os.WriteFile(uploadFile, []byte("a,b"), 0o600); err != nil {
+		t.Fatalf("failed to write upload file: %v", err)
+	}
+
+	partType := types.ObjectType{AttrTypes: map[string]attr.Type{
+		"name":         types.StringType,
+		"value":        types.StringType,
+		"file":         types.StringType,
+		"filename":     types.StringType,
+		"content_type": types.StringType,
+	}}
+
+	tests := []struct {
+		name     string
+		setup    func(model *HttpTestResourceModel)
+		expected string
+	}{
+		{
+			name: "body_file",
+			setup: func(model *HttpTestResourceModel) {
+				model.BodyFile = types.StringValue(bodyFile)
+			},
+			expected: ":from file",
+		},
+		{
+			name: "form",
+			setup: func(model *HttpTestResourceModel) {
+				model.Form = types.MapValueMust(types.StringType, map[string]attr.Value{
+					"user": types.StringValue("alice"),
+				})
+			},
+			expected: "form:alice",
+		},
+		{
+			name: "multipart",
+			setup: func(model *HttpTestResourceModel) {
+				model.Multipart = types.ListValueMust(partType, []attr.Value{
+					types.ObjectValueMust(partType.AttrTypes, map[string]attr.Value{
+						"name":         types.StringValue("field"),
+						"value":        types.StringValue("hello"),
+						"file":         types.StringNull(),
+						"filename":     types.StringNull(),
+						"content_type": types.StringNull(),
+					}),
+					types.ObjectValueMust(partType.AttrTypes, map[string]attr.Value{
+						"name":         types.StringValue("upload"),
+						"value":        types.StringNull(),
+						"file":         types.StringValue(uploadFile),
+						"filename":     types.StringNull(),
+						"content_type": types.StringValue("text/csv"),
+					}),
+				})
+			},
+			expected: "multipart:hello:report.csv:a,b",
+		},
+		{
+			name: "json_body compressed",
+			setup: func(model *HttpTestResourceModel) {
+				model.JsonBody = types.DynamicValue(types.ObjectValueMust(
+					map[string]attr.Type{"count": types.NumberType, "tags": types.TupleType{ElemTypes: []attr.Type{types.StringType}}},
+					map[string]attr.Value{
+						"count": types.NumberValue(big.NewFloat(3)),
+						"tags":  types.TupleValueMust([]attr.Type{types.StringType}, []attr.Value{types.StringValue("x")}),
+					},
+				))
+				model.CompressBody = types.BoolValue(true)
+			},
+			expected: `application/json:{"count":3,"tags":["x"]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			model := &HttpTestResourceModel{
+				Name:             types.StringValue(tc.name),
+				URL:              types.StringValue(server.URL),
+				Method:           types.StringValue("POST"),
+				ExpectStatusCode: types.Int64Value(200),
+				ExpectContains:   types.StringValue(tc.expected),
+				JsonBody:         types.DynamicNull(),
+			}
+			tc.setup(model)
+
+			if err := resource.runTest(ctx, model); err != nil {
+				t.Fatalf("runTest failed: %v", err)
+			}
+
+			if !model.TestPassed.ValueBool() {
+				t.Errorf("Expected test to pass, but it failed with error: %s (body: %s)", model.Error.ValueString(), model.LastResponseBody.ValueString())
+			}
+		})
+	}
+
+	// Multiple body sources are configuration errors
+	model := &HttpTestResourceModel{
+		Name:     types.StringValue("Conflicting bodies"),
+		URL:      types.StringValue(server.URL),
+		Method:   types.StringValue("POST"),
+		Body:     types.StringValue("inline"),
+		BodyFile: types.StringValue(bodyFile),
+		JsonBody: types.DynamicNull(),
+	}
+	if err := resource.runTest(ctx, model); err == nil {
+		t.Errorf("Expected error for conflicting body sources, but got none")
+	}
+}
+
+// TestHttpTestResource_dialTargets tests sending requests over unix sockets and to overridden dial targets.
+func TestHttpTestResource_dialTargets(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, "host=%s path=%s", r.Host, r.URL.Path)
+	})
+
+	// Server listening on a unix domain socket
+	socketPath := filepath.Join(t.TempDir(), "admin.sock")
+	unixListener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen on unix socket: %v", err)
+	}
+	unixServer := &http.Server{Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = unixServer.Serve(unixListener) }()
+	defer func() { _ = unixServer.Close() }()
+
+	// Server listening on TCP, reached through connect_to
+	tcpServer := httptest.NewServer(handler)
+	defer tcpServer.Close()
+
+	resource := &HttpTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	ctx := context.Background()
+
+	t.Run("unix_socket", func(t *testing.T) {
+		model := &HttpTestResourceModel{
+			Name:             types.StringValue("Unix socket"),
+			URL:              types.StringValue("http://localhost/stats"),
+			Method:           types.StringValue("GET"),
+			UnixSocket:       types.StringValue(socketPath),
+			ExpectStatusCode: types.Int64Value(200),
+			ExpectContains:   types.StringValue("host=localhost path=/stats"),
+		}
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if !model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+		}
+	})
+
+	t.Run("connect_to", func(t *testing.T) {
+		model := &HttpTestResourceModel{
+			Name:             types.StringValue("Connect to"),
+			URL:              types.StringValue("http://app.example.invalid/health"),
+			Method:           types.StringValue("GET"),
+			ConnectTo:        types.StringValue(tcpServer.Listener.Addr().String()),
+			ExpectStatusCode: types.Int64Value(200),
+			ExpectContains:   types.StringValue("host=app.example.invalid path=/health"),
+		}
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if !model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+		}
+	})
+
+	t.Run("source_address", func(t *testing.T) {
+		model := &HttpTestResourceModel{
+			Name:             types.StringValue("Source address"),
+			URL:              types.StringValue(tcpServer.URL + "/health"),
+			Method:           types.StringValue("GET"),
+			SourceAddress:    types.StringValue("127.0.0.1"),
+			ExpectStatusCode: types.Int64Value(200),
+		}
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if !model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+		}
+		if !strings.HasPrefix(model.LastLocalAddress.ValueString(), "127.0.0.1:") {
+			t.Errorf("Expected local address on 127.0.0.1, got %q", model.LastLocalAddress.ValueString())
+		}
+
+		model.UnixSocket = types.StringValue(socketPath)
+		if err := resource.runTest(ctx, model); err == nil {
+			t.Errorf("Expected error for source_address with unix_socket, but got none")
+		}
+	})
+
+	t.Run("invalid connect_to", func(t *testing.T) {
+		model := &HttpTestResourceModel{
+			Name:      types.StringValue("Invalid connect to"),
+			URL:       types.StringValue(tcpServer.URL),
+			ConnectTo: types.StringValue("missing-port"),
+		}
+
+		if err := resource.runTest(ctx, model); err == nil {
+			t.Errorf("Expected error for invalid connect_to, but got none")
+		}
+	})
+}
+
+// TestHttpTestResource_streaming tests reading Server-Sent Events and line
+// delimited streams that never end on their own.
+func TestHttpTestResource_streaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+			_, _ = fmt.Fprint(w, "event: status\ndata: {\"state\":\"starting\"}\n\n")
+			_, _ = fmt.Fprint(w, "event: status\ndata: {\"state\":\ndata: \"ready\"}\n\n")
+		} else {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			for i := 0; i < 3; i++ {
+				_, _ = fmt.Fprintf(w, "{\"seq\":%d}\n", i)
+			}
+		}
+		flusher.Flush()
+
+		// Keep the stream open until the client goes away
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	resource := &HttpTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	ctx := context.Background()
+
+	t.Run("sse within duration", func(t *testing.T) {
+		model := &HttpTestResourceModel{
+			Name:             types.StringValue("SSE"),
+			URL:              types.StringValue(server.URL + "/events"),
+			Method:           types.StringValue("GET"),
+			ExpectStatusCode: types.Int64Value(200),
+			Stream:           types.StringValue("sse"),
+			StreamDuration:   types.Int64Value(1),
+			ExpectMinEvents:  types.Int64Value(2),
+			ExpectEventMatch: types.StringValue(`"state":\s*"ready"`),
+		}
+
+		start := time.Now()
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("Expected the stream to be cut after about 1s, took %s", elapsed)
+		}
+
+		if !model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+		}
+		if model.LastEventCount.ValueInt64() != 2 {
+			t.Errorf("Expected 2 events, got %d", model.LastEventCount.ValueInt64())
+		}
+	})
+
+	t.Run("lines with max events", func(t *testing.T) {
+		model := &HttpTestResourceModel{
+			Name:             types.StringValue("NDJSON"),
+			URL:              types.StringValue(server.URL + "/ndjson"),
+			Method:           types.StringValue("GET"),
+			ExpectStatusCode: types.Int64Value(200),
+			Stream:           types.StringValue("lines"),
+			StreamDuration:   types.Int64Value(30),
+			StreamMaxEvents:  types.Int64Value(2),
+			ExpectEventMatch: types.StringValue(`"seq":1`),
+		}
+
+		start := time.Now()
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected reading to stop after 2 events, took %s", elapsed)
+		}
+
+		if !model.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+		}
+		if model.LastEventCount.ValueInt64() != 2 {
+			t.Errorf("Expected 2 events, got %d", model.LastEventCount.ValueInt64())
+		}
+	})
+
+	t.Run("too few events", func(t *testing.T) {
+		model := &HttpTestResourceModel{
+			Name:             types.StringValue("Too few"),
+			URL:              types.StringValue(server.URL + "/ndjson"),
+			Method:           types.StringValue("GET"),
+			ExpectStatusCode: types.Int64Value(200),
+			Stream:           types.StringValue("lines"),
+			StreamDuration:   types.Int64Value(1),
+			ExpectMinEvents:  types.Int64Value(5),
+		}
+
+		if err := resource.runTest(ctx, model); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+
+		if model.TestPassed.ValueBool() {
+			t.Error("Expected test to fail with too few events")
+		}
+		if !strings.Contains(model.Error.ValueString(), "at least 5 events but got 3") {
+			t.Errorf("Unexpected error: %s", model.Error.ValueString())
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// evaluateJSONPath resolves a simple JSONPath expression against a decoded
+// JSON document. Supported syntax is the root `$`, dotted member access
+// (`$.a.b`), bracketed member access (`$['a']`) and array indexes
+// (`$.items[0]`, negative indexes count from the end).
+func evaluateJSONPath(document interface{}, path string) (interface{}, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("empty JSONPath")
+	}
+
+	// The root is optional so that "a.b" and "$.a.b" are equivalent
+	rest := strings.TrimPrefix(path, "$")
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		rest = "." + rest
+	}
+
+	current := document
+	for rest != "" {
+		var segment string
+		var index *int
+
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			segment = rest[:end]
+			rest = rest[end:]
+			if segment == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty member name", path)
+			}
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unterminated bracket", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segment = inner[1 : len(inner)-1]
+			} else {
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid JSONPath %q: unsupported selector [%s]", path, inner)
+				}
+				index = &i
+			}
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q", path)
+		}
+
+		if index != nil {
+			list, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("JSONPath %q: cannot index a non-array value", path)
+			}
+			i := *index
+			if i < 0 {
+				i += len(list)
+			}
+			if i < 0 || i >= len(list) {
+				return nil, fmt.Errorf("JSONPath %q: index %d out of range", path, *index)
+			}
+			current = list[i]
+			continue
+		}
+
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("JSONPath %q: cannot select %q from a non-object value", path, segment)
+		}
+		value, found := object[segment]
+		if !found {
+			return nil, fmt.Errorf("JSONPath %q: member %q not found", path, segment)
+		}
+		current = value
+	}
+
+	return current, nil
+}
+
+// jsonValueString renders a JSON value for comparison with an expected
+// string. Strings are returned as is, other values in their JSON encoding.
+func jsonValueString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
+
+// checkJSONPathExpectations evaluates each path against the document and
+// returns a description of every expectation that was not met, ordered by
+// path so that the reported error is stable between runs.
+func checkJSONPathExpectations(document interface{}, expectations map[string]string) []string {
+	var failures []string
+
+	for _, path := range slices.Sorted(maps.Keys(expectations)) {
+		expected := expectations[path]
+		value, err := evaluateJSONPath(document, path)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+
+		if actual := jsonValueString(value); actual != expected {
+			failures = append(failures, fmt.Sprintf("JSONPath %s: expected '%s' but got '%s'", path, expected, actual))
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestEvaluateJSONPath tests the JSONPath subset used by response assertions.
+func TestEvaluateJSONPath(t *testing.T) {
+	var document interface{}
+	err := json.Unmarshal([]byte(`{
+		"user": {"name": "alice", "active": true, "roles": ["admin", "reader"]},
+		"items": [{"id": 1}, {"id": 2}],
+		"dotted.key": "yes"
+	}`), &document)
+	if err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{path: "$.user.name", expected: "alice"},
+		{path: "user.name", expected: "alice"},
+		{path: "$.user.active", expected: "true"},
+		{path: "$.user.roles[1]", expected: "reader"},
+		{path: "$.user.roles[-1]", expected: "reader"},
+		{path: "$.items[0].id", expected: "1"},
+		{path: "$['dotted.key']", expected: "yes"},
+		{path: "$.user.roles", expected: `["admin","reader"]`},
+		{path: "$.user.missing", wantErr: true},
+		{path: "$.items[5]", wantErr: true},
+		{path: "$.user[0]", wantErr: true},
+		{path: "$.items[*]", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			value, err := evaluateJSONPath(document, tc.path)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %s, got value %v", tc.path, value)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("evaluateJSONPath(%s) failed: %v", tc.path, err)
+			}
+
+			if got := jsonValueString(value); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+
+	// Failed expectations are reported individually
+	failures := checkJSONPathExpectations(document, map[string]string{
+		"$.user.name": "alice",
+		"$.items[1]":  `{"id":2}`,
+		"$.user.role": "admin",
+	})
+	if len(failures) != 1 {
+		t.Errorf("Expected 1 failed expectation, got %d: %v", len(failures), failures)
+	}
+
+	// Failures are reported in path order
+	failures = checkJSONPathExpectations(document, map[string]string{
+		"$.user.role":  "admin",
+		"$.user.name":  "bob",
+		"$.items[0]":   "{}",
+		"$.user.email": "",
+	})
+	expected := []string{"$.items[0]", "$.user.email", "$.user.name", "$.user.role"}
+	if len(failures) != len(expected) {
+		t.Fatalf("Expected %d failed expectations, got %d: %v", len(expected), len(failures), failures)
+	}
+	for i, path := range expected {
+		if !strings.Contains(failures[i], path) {
+			t.Errorf("Expected failure %d to mention %s, got %s", i, path, failures[i])
+		}
+	}
+}
@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
@@ -33,6 +34,13 @@ type TerraProbeProviderModel struct {
 	DefaultRetries    types.Int64  `tfsdk:"default_retries"`
 	DefaultRetryDelay types.Int64  `tfsdk:"default_retry_delay"`
 	UserAgent         types.String `tfsdk:"user_agent"`
+
+	// HTTP transport tuning
+	MaxConnsPerHost     types.Int64 `tfsdk:"max_conns_per_host"`
+	MaxIdleConns        types.Int64 `tfsdk:"max_idle_conns"`
+	MaxIdleConnsPerHost types.Int64 `tfsdk:"max_idle_conns_per_host"`
+	IdleConnTimeout     types.Int64 `tfsdk:"idle_conn_timeout"`
+	EnableHTTP2         types.Bool  `tfsdk:"enable_http2"`
 }
 
 func (p *TerraProbeProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -61,6 +69,26 @@ func (p *TerraProbeProvider) Schema(ctx context.Context, req provider.SchemaRequ
 				MarkdownDescription: "User agent to use for HTTP requests.",
 				Optional:            true,
 			},
+			"max_conns_per_host": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of connections per host shared by all HTTP tests. 0 means no limit.",
+				Optional:            true,
+			},
+			"max_idle_conns": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of idle keep-alive connections kept across all hosts. Defaults to 100.",
+				Optional:            true,
+			},
+			"max_idle_conns_per_host": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of idle keep-alive connections kept per host. Defaults to 10.",
+				Optional:            true,
+			},
+			"idle_conn_timeout": schema.Int64Attribute{
+				MarkdownDescription: "Time in seconds an idle keep-alive connection is kept before being closed. Defaults to 90.",
+				Optional:            true,
+			},
+			"enable_http2": schema.BoolAttribute{
+				MarkdownDescription: "Whether HTTP tests may negotiate HTTP/2 over TLS. Defaults to true.",
+				Optional:            true,
+			},
 		},
 	}
 }
@@ -95,9 +123,28 @@ func (p *TerraProbeProvider) Configure(ctx context.Context, req provider.Configu
 		userAgent = config.UserAgent.ValueString()
 	}
 
+	// Build the transport shared by all HTTP tests
+	transportSettings := defaultHttpTransportSettings()
+	if !config.MaxConnsPerHost.IsNull() {
+		transportSettings.MaxConnsPerHost = int(config.MaxConnsPerHost.ValueInt64())
+	}
+	if !config.MaxIdleConns.IsNull() {
+		transportSettings.MaxIdleConns = int(config.MaxIdleConns.ValueInt64())
+	}
+	if !config.MaxIdleConnsPerHost.IsNull() {
+		transportSettings.MaxIdleConnsPerHost = int(config.MaxIdleConnsPerHost.ValueInt64())
+	}
+	if !config.IdleConnTimeout.IsNull() {
+		transportSettings.IdleConnTimeout = time.Duration(config.IdleConnTimeout.ValueInt64()) * time.Second
+	}
+	if !config.EnableHTTP2.IsNull() {
+		transportSettings.EnableHTTP2 = config.EnableHTTP2.ValueBool()
+	}
+
 	// Create a custom HTTP client with the specified timeout
 	client := &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: newHttpTransport(transportSettings),
 	}
 
 	// Create a client configuration
@@ -118,15 +165,27 @@ type TerraProbeClientConfig struct {
 	UserAgent  string
 	Retries    int64
 	RetryDelay time.Duration
+
+	// transports caches customized HTTP transports derived from HttpClient.
+	transportsMu sync.Mutex
+	transports   map[string]*http.Transport
 }
 
 func (p *TerraProbeProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewHttpTestResource,
 		NewTcpTestResource,
+		NewTcpMatrixTestResource,
+		NewUdpTestResource,
+		NewTlsTestResource,
 		NewDnsTestResource,
+		NewDnsPropagationTestResource,
+		NewEmailDnsTestResource,
 		NewTestSuiteResource,
 		NewDbTestResource,
+		NewGraphqlTestResource,
+		NewGrpcTestResource,
+		NewWebsocketTestResource,
 	}
 }
 
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+)
+
+// sourceAddressSchemaAttribute returns the source_address attribute shared by
+// network tests.
+func sourceAddressSchemaAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Local IP address to connect from, e.g. to check reachability from a particular interface on a multi-homed host. The address must be assigned to this host.",
+		Optional:            true,
+	}
+}
+
+// parseSourceAddress checks a source_address value and returns the IP that
+// outgoing connections should be bound to, or nil if it is not set.
+func parseSourceAddress(source string) (net.IP, error) {
+	if source == "" {
+		return nil, nil
+	}
+
+	ip := net.ParseIP(source)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid source_address %q, expected an IP address", source)
+	}
+	return ip, nil
+}
+
+// newSourceDialer returns a dialer for the network ("tcp" or "udp") that
+// binds connections to the source IP when one is given. When the target is a
+// hostname only addresses of the source IP's family are dialed.
+func newSourceDialer(network string, source net.IP, timeout time.Duration) *net.Dialer {
+	dialer := &net.Dialer{Timeout: timeout}
+	if source == nil {
+		return dialer
+	}
+
+	switch network {
+	case "udp":
+		dialer.LocalAddr = &net.UDPAddr{IP: source}
+	default:
+		dialer.LocalAddr = &net.TCPAddr{IP: source}
+	}
+	return dialer
+}
+
+// localAddressString returns the local address of a connection, or an empty
+// string if there is none.
+func localAddressString(conn net.Conn) string {
+	if conn == nil || conn.LocalAddr() == nil {
+		return ""
+	}
+	return conn.LocalAddr().String()
+}
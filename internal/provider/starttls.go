@@ -0,0 +1,251 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+)
+
+const (
+	startTLSSMTP     = "smtp"
+	startTLSIMAP     = "imap"
+	startTLSPOP3     = "pop3"
+	startTLSFTP      = "ftp"
+	startTLSPostgres = "postgres"
+	startTLSLDAP     = "ldap"
+)
+
+// validateStartTLS checks a starttls value.
+func validateStartTLS(protocol string) error {
+	switch protocol {
+	case "", startTLSSMTP, startTLSIMAP, startTLSPOP3, startTLSFTP, startTLSPostgres, startTLSLDAP:
+		return nil
+	default:
+		return fmt.Errorf("unsupported starttls %q, must be one of: smtp, imap, pop3, ftp, postgres, ldap", protocol)
+	}
+}
+
+// startTLS asks the server to upgrade a plaintext connection to TLS using the
+// protocol's own command. When it returns without error the next bytes on
+// the connection belong to the TLS handshake.
+func startTLS(conn net.Conn, protocol string) error {
+	// Servers do not send anything after agreeing to upgrade, so buffering
+	// the replies does not swallow any handshake bytes
+	reader := bufio.NewReader(conn)
+
+	switch protocol {
+	case startTLSSMTP:
+		text := textproto.NewReader(reader)
+		if _, _, err := text.ReadResponse(220); err != nil {
+			return fmt.Errorf("unexpected SMTP greeting: %w", err)
+		}
+		if err := writeLine(conn, "EHLO terraprobe"); err != nil {
+			return err
+		}
+		if _, _, err := text.ReadResponse(250); err != nil {
+			return fmt.Errorf("SMTP EHLO failed: %w", err)
+		}
+		if err := writeLine(conn, "STARTTLS"); err != nil {
+			return err
+		}
+		if _, _, err := text.ReadResponse(220); err != nil {
+			return fmt.Errorf("SMTP STARTTLS rejected: %w", err)
+		}
+
+	case startTLSFTP:
+		text := textproto.NewReader(reader)
+		if _, _, err := text.ReadResponse(220); err != nil {
+			return fmt.Errorf("unexpected FTP greeting: %w", err)
+		}
+		if err := writeLine(conn, "AUTH TLS"); err != nil {
+			return err
+		}
+		if _, _, err := text.ReadResponse(234); err != nil {
+			return fmt.Errorf("FTP AUTH TLS rejected: %w", err)
+		}
+
+	case startTLSIMAP:
+		if err := expectLinePrefix(reader, "* OK", "IMAP greeting"); err != nil {
+			return err
+		}
+		if err := writeLine(conn, "a1 STARTTLS"); err != nil {
+			return err
+		}
+		// Skip untagged responses until the tagged reply
+		for {
+			line, err := readLine(reader)
+			if err != nil {
+				return fmt.Errorf("failed to read IMAP STARTTLS reply: %w", err)
+			}
+			if strings.HasPrefix(line, "a1 ") {
+				if !strings.HasPrefix(line, "a1 OK") {
+					return fmt.Errorf("IMAP STARTTLS rejected: %s", line)
+				}
+				break
+			}
+		}
+
+	case startTLSPOP3:
+		if err := expectLinePrefix(reader, "+OK", "POP3 greeting"); err != nil {
+			return err
+		}
+		if err := writeLine(conn, "STLS"); err != nil {
+			return err
+		}
+		if err := expectLinePrefix(reader, "+OK", "POP3 STLS reply"); err != nil {
+			return err
+		}
+
+	case startTLSPostgres:
+		// SSLRequest: message length followed by the SSL request code
+		request := make([]byte, 8)
+		binary.BigEndian.PutUint32(request[0:4], 8)
+		binary.BigEndian.PutUint32(request[4:8], 80877103)
+		if _, err := conn.Write(request); err != nil {
+			return fmt.Errorf("failed to send SSLRequest: %w", err)
+		}
+		reply, err := reader.ReadByte()
+		if err != nil {
+			return fmt.Errorf("failed to read SSLRequest reply: %w", err)
+		}
+		if reply != 'S' {
+			return fmt.Errorf("PostgreSQL server does not support SSL (replied %q)", reply)
+		}
+
+	case startTLSLDAP:
+		if _, err := conn.Write(ldapStartTLSRequest); err != nil {
+			return fmt.Errorf("failed to send LDAP StartTLS request: %w", err)
+		}
+		code, err := readLdapExtendedResultCode(reader)
+		if err != nil {
+			return fmt.Errorf("failed to read LDAP StartTLS reply: %w", err)
+		}
+		if code != 0 {
+			return fmt.Errorf("LDAP StartTLS rejected with result code %d", code)
+		}
+	}
+
+	return nil
+}
+
+// writeLine writes a CRLF terminated command.
+func writeLine(conn net.Conn, line string) error {
+	if _, err := io.WriteString(conn, line+"\r\n"); err != nil {
+		return fmt.Errorf("failed to send %s: %w", strings.Fields(line)[0], err)
+	}
+	return nil
+}
+
+// readLine reads a line without its line ending.
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// expectLinePrefix reads a line and checks that it starts with prefix.
+func expectLinePrefix(reader *bufio.Reader, prefix, what string) error {
+	line, err := readLine(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	if !strings.HasPrefix(line, prefix) {
+		return fmt.Errorf("unexpected %s: %s", what, line)
+	}
+	return nil
+}
+
+// ldapStartTLSRequest is a BER encoded LDAP message with ID 1 carrying an
+// ExtendedRequest for the StartTLS OID 1.3.6.1.4.1.1466.20037.
+var ldapStartTLSRequest = append([]byte{0x30, 0x1d, 0x02, 0x01, 0x01, 0x77, 0x18, 0x80, 0x16}, "1.3.6.1.4.1.1466.20037"...)
+
+// readLdapExtendedResultCode reads an LDAP message and returns the result
+// code of the ExtendedResponse it carries.
+func readLdapExtendedResultCode(reader *bufio.Reader) (int, error) {
+	tag, message, err := readBER(reader)
+	if err != nil {
+		return 0, err
+	}
+	if tag != 0x30 {
+		return 0, fmt.Errorf("unexpected BER tag 0x%02x", tag)
+	}
+
+	// messageID INTEGER, then the [APPLICATION 24] ExtendedResponse
+	body := bufio.NewReader(bytes.NewReader(message))
+	if _, _, err := readBER(body); err != nil {
+		return 0, err
+	}
+	tag, response, err := readBER(body)
+	if err != nil {
+		return 0, err
+	}
+	if tag != 0x78 {
+		return 0, fmt.Errorf("unexpected LDAP protocol operation 0x%02x", tag)
+	}
+
+	// resultCode ENUMERATED is the first element of the response
+	tag, code, err := readBER(bufio.NewReader(bytes.NewReader(response)))
+	if err != nil {
+		return 0, err
+	}
+	if tag != 0x0a || len(code) == 0 {
+		return 0, fmt.Errorf("missing LDAP result code")
+	}
+
+	result := 0
+	for _, b := range code {
+		result = result<<8 | int(b)
+	}
+	return result, nil
+}
+
+// maxBERLength bounds the size of an LDAP reply read during StartTLS.
+const maxBERLength = 64 * 1024
+
+// readBER reads a single BER element with a single byte tag and returns its
+// tag and contents.
+func readBER(reader *bufio.Reader) (byte, []byte, error) {
+	tag, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	first, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length := int(first)
+	if first&0x80 != 0 {
+		// Long form: the low bits give the number of length bytes
+		count := int(first & 0x7f)
+		if count == 0 || count > 4 {
+			return 0, nil, fmt.Errorf("unsupported BER length encoding")
+		}
+		length = 0
+		for range count {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return 0, nil, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+
+	if length > maxBERLength {
+		return 0, nil, fmt.Errorf("BER element too large (%d bytes)", length)
+	}
+
+	contents := make([]byte, length)
+	if _, err := io.ReadFull(reader, contents); err != nil {
+		return 0, nil, err
+	}
+	return tag, contents, nil
+}
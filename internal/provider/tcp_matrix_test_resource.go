@@ -0,0 +1,481 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// maxTcpMatrixEndpoints bounds the number of host and port combinations a
+// single matrix test may dial.
+const maxTcpMatrixEndpoints = 4096
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &TcpMatrixTestResource{}
+var _ resource.ResourceWithImportState = &TcpMatrixTestResource{}
+
+func NewTcpMatrixTestResource() resource.Resource {
+	return &TcpMatrixTestResource{}
+}
+
+// TcpMatrixTestResource defines the resource implementation.
+type TcpMatrixTestResource struct {
+	clientConfig *TerraProbeClientConfig
+}
+
+// TcpMatrixTestResourceModel describes the resource data model.
+type TcpMatrixTestResourceModel struct {
+	Name        types.String `tfsdk:"name"`
+	Hosts       types.List   `tfsdk:"hosts"`
+	Ports       types.List   `tfsdk:"ports"`
+	Expect      types.String `tfsdk:"expect"`
+	Concurrency types.Int64  `tfsdk:"concurrency"`
+	Timeout     types.Int64  `tfsdk:"timeout"`
+	Retries     types.Int64  `tfsdk:"retries"`
+	RetryDelay  types.Int64  `tfsdk:"retry_delay"`
+	Id          types.String `tfsdk:"id"`
+
+	// Results
+	LastRun         types.String `tfsdk:"last_run"`
+	Results         types.Map    `tfsdk:"results"`
+	TotalCount      types.Int64  `tfsdk:"total_count"`
+	PassedCount     types.Int64  `tfsdk:"passed_count"`
+	FailedCount     types.Int64  `tfsdk:"failed_count"`
+	FailedEndpoints types.List   `tfsdk:"failed_endpoints"`
+	TestPassed      types.Bool   `tfsdk:"test_passed"`
+	Error           types.String `tfsdk:"error"`
+}
+
+func (r *TcpMatrixTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tcp_matrix_test"
+}
+
+func (r *TcpMatrixTestResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "TCP matrix test resource that checks every combination of a list of hosts and ports concurrently",
+
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Descriptive name for the test",
+				Required:            true,
+			},
+			"hosts": schema.ListAttribute{
+				MarkdownDescription: "Hosts to connect to (IP addresses or hostnames). IPv6 addresses may be given with or without brackets.",
+				Required:            true,
+				ElementType:         types.StringType,
+			},
+			"ports": schema.ListAttribute{
+				MarkdownDescription: "Ports to connect to on every host, either single ports (`\"443\"`) or inclusive ranges (`\"8000-8010\"`)",
+				Required:            true,
+				ElementType:         types.StringType,
+			},
+			"expect": schema.StringAttribute{
+				MarkdownDescription: "Expected state of every port: `open`, `closed` (connection refused) or `filtered` (connection timed out or rejected as unreachable)",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(tcpStateOpen),
+			},
+			"concurrency": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of connection attempts in flight at once",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(10),
+			},
+			"timeout": schema.Int64Attribute{
+				MarkdownDescription: "Timeout in seconds for each connection attempt",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of retries for each connection attempt",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retry_delay": schema.Int64Attribute{
+				MarkdownDescription: "Delay between retries in seconds",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+
+			// Results - these are computed values based on the last test run
+			"last_run": schema.StringAttribute{
+				MarkdownDescription: "Timestamp of the last test run",
+				Computed:            true,
+			},
+			"results": tcpResultsSchemaAttribute("Results of the last test run keyed by endpoint (`host:port`)"),
+			"total_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of endpoints tested",
+				Computed:            true,
+			},
+			"passed_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of endpoints in the expected state",
+				Computed:            true,
+			},
+			"failed_count": schema.Int64Attribute{
+				MarkdownDescription: "Number of endpoints not in the expected state",
+				Computed:            true,
+			},
+			"failed_endpoints": schema.ListAttribute{
+				MarkdownDescription: "Endpoints not in the expected state",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"test_passed": schema.BoolAttribute{
+				MarkdownDescription: "Whether every endpoint was in the expected state",
+				Computed:            true,
+			},
+			"error": schema.StringAttribute{
+				MarkdownDescription: "Error message if the test failed",
+				Computed:            true,
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Test identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *TcpMatrixTestResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	clientConfig, ok := req.ProviderData.(*TerraProbeClientConfig)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *TerraProbeClientConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.clientConfig = clientConfig
+}
+
+func (r *TcpMatrixTestResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data TcpMatrixTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate a unique identifier for this test
+	data.Id = types.StringValue(fmt.Sprintf("tcp-matrix-test-%s", time.Now().Format("20060102150405")))
+
+	// Run the TCP matrix test
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("TCP Matrix Test Error", err.Error())
+		return
+	}
+
+	// Set the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Write logs
+	tflog.Trace(ctx, "created TCP matrix test resource")
+	tflog.Debug(ctx, fmt.Sprintf("TCP Matrix Test Result: %t - %d/%d endpoints passed", data.TestPassed.ValueBool(), data.PassedCount.ValueInt64(), data.TotalCount.ValueInt64()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TcpMatrixTestResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data TcpMatrixTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the TCP matrix test again during Read
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("TCP Matrix Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TcpMatrixTestResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data TcpMatrixTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the TCP matrix test with updated parameters
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("TCP Matrix Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TcpMatrixTestResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data TcpMatrixTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Nothing special to do for delete, as this is a stateless resource
+	// The resource will be removed from Terraform state
+}
+
+func (r *TcpMatrixTestResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// tcpMatrixResult is the outcome of dialing a single endpoint.
+type tcpMatrixResult struct {
+	endpoint    string
+	state       string
+	passed      bool
+	connectTime time.Duration
+	err         error
+}
+
+// runTest runs the TCP matrix test and updates the resource model with the results.
+func (r *TcpMatrixTestResource) runTest(ctx context.Context, data *TcpMatrixTestResourceModel) error {
+	// Get timeout from resource or default from provider
+	timeout := r.clientConfig.HttpClient.Timeout
+	if !data.Timeout.IsNull() && data.Timeout.ValueInt64() > 0 {
+		timeout = time.Duration(data.Timeout.ValueInt64()) * time.Second
+	}
+
+	// Get retries from resource or default from provider
+	retries := r.clientConfig.Retries
+	if !data.Retries.IsNull() && data.Retries.ValueInt64() > 0 {
+		retries = data.Retries.ValueInt64()
+	}
+
+	// Get retry delay from resource or default from provider
+	retryDelay := r.clientConfig.RetryDelay
+	if !data.RetryDelay.IsNull() && data.RetryDelay.ValueInt64() > 0 {
+		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
+	}
+
+	expect, err := validateTcpExpect(data.Expect.ValueString())
+	if err != nil {
+		return err
+	}
+
+	concurrency := 10
+	if !data.Concurrency.IsNull() {
+		if data.Concurrency.ValueInt64() < 1 {
+			return fmt.Errorf("concurrency must be at least 1")
+		}
+		concurrency = int(data.Concurrency.ValueInt64())
+	}
+
+	// Expand the hosts and ports into endpoints
+	var hosts, portSpecs []string
+	if diags := data.Hosts.ElementsAs(ctx, &hosts, false); diags.HasError() {
+		return fmt.Errorf("failed to read hosts")
+	}
+	if diags := data.Ports.ElementsAs(ctx, &portSpecs, false); diags.HasError() {
+		return fmt.Errorf("failed to read ports")
+	}
+
+	endpoints, err := expandTcpEndpoints(hosts, portSpecs)
+	if err != nil {
+		return err
+	}
+
+	// Dial the endpoints with a bounded worker pool
+	dialer := &net.Dialer{Timeout: timeout}
+	results := make([]tcpMatrixResult, len(endpoints))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < min(concurrency, len(endpoints)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				conn, connectTime, state, dialErr := dialTcpExpecting(dialer, endpoints[i], expect, retries, retryDelay)
+				if conn != nil {
+					_ = conn.Close()
+				}
+				results[i] = tcpMatrixResult{
+					endpoint:    endpoints[i],
+					state:       state,
+					passed:      state == expect,
+					connectTime: connectTime,
+					err:         dialErr,
+				}
+			}
+		}()
+	}
+
+	for i := range endpoints {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Update the test results
+	resultValues := make(map[string]attr.Value, len(results))
+	var failed []string
+	for _, result := range results {
+		connectTime := int64(0)
+		if result.state == tcpStateOpen {
+			connectTime = int64(result.connectTime / time.Millisecond)
+		}
+		errorMsg := ""
+		if result.err != nil {
+			errorMsg = result.err.Error()
+		}
+
+		resultValues[result.endpoint] = types.ObjectValueMust(tcpResultAttrTypes, map[string]attr.Value{
+			"state":        types.StringValue(result.state),
+			"passed":       types.BoolValue(result.passed),
+			"connect_time": types.Int64Value(connectTime),
+			"error":        types.StringValue(errorMsg),
+		})
+
+		if !result.passed {
+			failed = append(failed, result.endpoint)
+		}
+	}
+	sort.Strings(failed)
+
+	failedValues := make([]attr.Value, 0, len(failed))
+	for _, endpoint := range failed {
+		failedValues = append(failedValues, types.StringValue(endpoint))
+	}
+
+	data.Results = types.MapValueMust(types.ObjectType{AttrTypes: tcpResultAttrTypes}, resultValues)
+	data.FailedEndpoints = types.ListValueMust(types.StringType, failedValues)
+	data.TotalCount = types.Int64Value(int64(len(results)))
+	data.PassedCount = types.Int64Value(int64(len(results) - len(failed)))
+	data.FailedCount = types.Int64Value(int64(len(failed)))
+
+	// Set the test result
+	data.TestPassed = types.BoolValue(len(failed) == 0)
+
+	// Set error message if test failed
+	if len(failed) > 0 {
+		data.Error = types.StringValue(fmt.Sprintf("%d of %d endpoints are not %s: %s", len(failed), len(results), expect, strings.Join(failed, ", ")))
+	} else {
+		data.Error = types.StringValue("")
+	}
+
+	return nil
+}
+
+// expandTcpEndpoints combines every host with every port, expanding port
+// ranges. Duplicate endpoints are dialed once.
+func expandTcpEndpoints(hosts, portSpecs []string) ([]string, error) {
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("at least one host is required")
+	}
+	if len(portSpecs) == 0 {
+		return nil, fmt.Errorf("at least one port is required")
+	}
+
+	var ports []int64
+	for _, spec := range portSpecs {
+		first, last, err := parseTcpPortRange(spec)
+		if err != nil {
+			return nil, err
+		}
+		if int(last-first+1)*len(hosts) > maxTcpMatrixEndpoints {
+			return nil, fmt.Errorf("too many endpoints, at most %d host and port combinations are supported", maxTcpMatrixEndpoints)
+		}
+		for port := first; port <= last; port++ {
+			ports = append(ports, port)
+		}
+	}
+
+	if len(ports)*len(hosts) > maxTcpMatrixEndpoints {
+		return nil, fmt.Errorf("too many endpoints, at most %d host and port combinations are supported", maxTcpMatrixEndpoints)
+	}
+
+	seen := make(map[string]bool)
+	var endpoints []string
+	for _, host := range hosts {
+		// IPv6 hosts may be given with or without brackets
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		for _, port := range ports {
+			endpoint := net.JoinHostPort(host, strconv.FormatInt(port, 10))
+			if seen[endpoint] {
+				continue
+			}
+			seen[endpoint] = true
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	return endpoints, nil
+}
+
+// parseTcpPortRange parses a single port ("443") or an inclusive range
+// ("8000-8010").
+func parseTcpPortRange(spec string) (int64, int64, error) {
+	firstSpec, lastSpec, isRange := strings.Cut(strings.TrimSpace(spec), "-")
+
+	first, err := strconv.ParseInt(strings.TrimSpace(firstSpec), 10, 64)
+	if err != nil || first < 1 || first > 65535 {
+		return 0, 0, fmt.Errorf("invalid port %q", spec)
+	}
+	if !isRange {
+		return first, first, nil
+	}
+
+	last, err := strconv.ParseInt(strings.TrimSpace(lastSpec), 10, 64)
+	if err != nil || last < first || last > 65535 {
+		return 0, 0, fmt.Errorf("invalid port range %q", spec)
+	}
+
+	return first, last, nil
+}
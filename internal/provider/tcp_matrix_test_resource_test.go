@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TestTcpMatrixTestResource_runTest tests the TCP matrix test resource's runTest function.
+func TestTcpMatrixTestResource_runTest(t *testing.T) {
+	// Two listening ports next to a closed one
+	var openPorts []int64
+	for i := 0; i < 2; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to set up TCP listener: %v", err)
+		}
+		defer func() { _ = listener.Close() }()
+		_, portStr, _ := net.SplitHostPort(listener.Addr().String())
+		port, _ := strconv.ParseInt(portStr, 10, 64)
+		openPorts = append(openPorts, port)
+	}
+
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to set up TCP listener: %v", err)
+	}
+	_, closedPortStr, _ := net.SplitHostPort(closedListener.Addr().String())
+	_ = closedListener.Close()
+
+	resource := &TcpMatrixTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+	ctx := context.Background()
+
+	stringList := func(values ...string) types.List {
+		elements := make([]attr.Value, 0, len(values))
+		for _, v := range values {
+			elements = append(elements, types.StringValue(v))
+		}
+		return types.ListValueMust(types.StringType, elements)
+	}
+
+	openPort0 := strconv.FormatInt(openPorts[0], 10)
+	openPort1 := strconv.FormatInt(openPorts[1], 10)
+
+	// All open ports pass
+	model := &TcpMatrixTestResourceModel{
+		Name:        types.StringValue("Open ports"),
+		Hosts:       stringList("127.0.0.1"),
+		Ports:       stringList(openPort0, openPort1, openPort0),
+		Concurrency: types.Int64Value(2),
+	}
+	if err := resource.runTest(ctx, model); err != nil {
+		t.Fatalf("runTest failed: %v", err)
+	}
+	if !model.TestPassed.ValueBool() {
+		t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+	}
+	if model.TotalCount.ValueInt64() != 2 {
+		t.Errorf("Expected duplicate endpoints to be dialed once, got %d endpoints", model.TotalCount.ValueInt64())
+	}
+
+	// A closed port fails the matrix and is reported per endpoint
+	model.Ports = stringList(openPort0, closedPortStr)
+	if err := resource.runTest(ctx, model); err != nil {
+		t.Fatalf("runTest failed: %v", err)
+	}
+	if model.TestPassed.ValueBool() {
+		t.Error("Expected test to fail with a closed port")
+	}
+	if model.PassedCount.ValueInt64() != 1 || model.FailedCount.ValueInt64() != 1 {
+		t.Errorf("Expected 1 passed and 1 failed endpoint, got %d and %d", model.PassedCount.ValueInt64(), model.FailedCount.ValueInt64())
+	}
+
+	closedEndpoint := net.JoinHostPort("127.0.0.1", closedPortStr)
+	result, ok := model.Results.Elements()[closedEndpoint].(types.Object)
+	if !ok {
+		t.Fatalf("Expected a result for %s", closedEndpoint)
+	}
+	if state, ok := result.Attributes()["state"].(types.String); !ok || state.ValueString() != "closed" {
+		t.Errorf("Expected %s to be closed, got %s", closedEndpoint, result.Attributes()["state"])
+	}
+	if failed := model.FailedEndpoints.Elements(); len(failed) != 1 || !failed[0].Equal(types.StringValue(closedEndpoint)) {
+		t.Errorf("Unexpected failed endpoints: %v", failed)
+	}
+
+	// Expecting closed ports inverts the result
+	model.Ports = stringList(closedPortStr)
+	model.Expect = types.StringValue("closed")
+	if err := resource.runTest(ctx, model); err != nil {
+		t.Fatalf("runTest failed: %v", err)
+	}
+	if !model.TestPassed.ValueBool() {
+		t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+	}
+}
+
+// TestExpandTcpEndpoints tests expanding hosts and port ranges.
+func TestExpandTcpEndpoints(t *testing.T) {
+	// IPv6 hosts with and without brackets expand to the same endpoints
+	endpoints, err := expandTcpEndpoints([]string{"10.0.0.1", "::1", "[::1]"}, []string{"22", "8000-8002"})
+	if err != nil {
+		t.Fatalf("expandTcpEndpoints failed: %v", err)
+	}
+
+	expected := []string{
+		"10.0.0.1:22", "10.0.0.1:8000", "10.0.0.1:8001", "10.0.0.1:8002",
+		"[::1]:22", "[::1]:8000", "[::1]:8001", "[::1]:8002",
+	}
+	if len(endpoints) != len(expected) {
+		t.Fatalf("Expected %d endpoints, got %d: %v", len(expected), len(endpoints), endpoints)
+	}
+	for i := range expected {
+		if endpoints[i] != expected[i] {
+			t.Errorf("Expected endpoint %s, got %s", expected[i], endpoints[i])
+		}
+	}
+
+	for _, ports := range [][]string{{"0"}, {"70000"}, {"90-80"}, {"http"}, {"1-65535"}} {
+		if _, err := expandTcpEndpoints([]string{"localhost"}, ports); err == nil {
+			t.Errorf("Expected an error for ports %v", ports)
+		}
+	}
+}
@@ -1,16 +1,28 @@
 package provider
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
@@ -31,19 +43,33 @@ type TcpTestResource struct {
 
 // TcpTestResourceModel describes the resource data model.
 type TcpTestResourceModel struct {
-	Name       types.String `tfsdk:"name"`
-	Host       types.String `tfsdk:"host"`
-	Port       types.Int64  `tfsdk:"port"`
-	Timeout    types.Int64  `tfsdk:"timeout"`
-	Retries    types.Int64  `tfsdk:"retries"`
-	RetryDelay types.Int64  `tfsdk:"retry_delay"`
-	Id         types.String `tfsdk:"id"`
+	Name             types.String `tfsdk:"name"`
+	Host             types.String `tfsdk:"host"`
+	Port             types.Int64  `tfsdk:"port"`
+	Timeout          types.Int64  `tfsdk:"timeout"`
+	Retries          types.Int64  `tfsdk:"retries"`
+	RetryDelay       types.Int64  `tfsdk:"retry_delay"`
+	Send             types.String `tfsdk:"send"`
+	SendEncoding     types.String `tfsdk:"send_encoding"`
+	ExpectBanner     types.String `tfsdk:"expect_banner"`
+	ExpectResponse   types.String `tfsdk:"expect_response"`
+	MatchMode        types.String `tfsdk:"match_mode"`
+	ReadTimeout      types.Int64  `tfsdk:"read_timeout"`
+	Expect           types.String `tfsdk:"expect"`
+	IPVersion        types.String `tfsdk:"ip_version"`
+	TestAllAddresses types.Bool   `tfsdk:"test_all_addresses"`
+	SourceAddress    types.String `tfsdk:"source_address"`
+	Id               types.String `tfsdk:"id"`
 
 	// Results
-	LastRun         types.String `tfsdk:"last_run"`
-	LastConnectTime types.Int64  `tfsdk:"last_connect_time"`
-	TestPassed      types.Bool   `tfsdk:"test_passed"`
-	Error           types.String `tfsdk:"error"`
+	LastRun          types.String `tfsdk:"last_run"`
+	LastConnectTime  types.Int64  `tfsdk:"last_connect_time"`
+	LastResponse     types.String `tfsdk:"last_response"`
+	LastState        types.String `tfsdk:"last_state"`
+	LastLocalAddress types.String `tfsdk:"last_local_address"`
+	AddressResults   types.Map    `tfsdk:"address_results"`
+	TestPassed       types.Bool   `tfsdk:"test_passed"`
+	Error            types.String `tfsdk:"error"`
 }
 
 func (r *TcpTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -60,7 +86,7 @@ func (r *TcpTestResource) Schema(ctx context.Context, req resource.SchemaRequest
 				Required:            true,
 			},
 			"host": schema.StringAttribute{
-				MarkdownDescription: "Host to connect to (IP address or hostname)",
+				MarkdownDescription: "Host to connect to (IP address or hostname). IPv6 addresses may be given with or without brackets.",
 				Required:            true,
 			},
 			"port": schema.Int64Attribute{
@@ -85,6 +111,53 @@ func (r *TcpTestResource) Schema(ctx context.Context, req resource.SchemaRequest
 				Computed:            true,
 				Default:             int64default.StaticInt64(0), // 0 means use provider default
 			},
+			"send": schema.StringAttribute{
+				MarkdownDescription: "Payload to send once connected, e.g. `\"PING\\r\\n\"`",
+				Optional:            true,
+			},
+			"send_encoding": schema.StringAttribute{
+				MarkdownDescription: "Encoding of `send`: `text`, `hex` or `base64`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(payloadEncodingText),
+			},
+			"expect_banner": schema.StringAttribute{
+				MarkdownDescription: "Data the server is expected to send after connecting, before anything is sent (e.g. an SSH version string or SMTP greeting)",
+				Optional:            true,
+			},
+			"expect_response": schema.StringAttribute{
+				MarkdownDescription: "Data the server is expected to send in response to `send`",
+				Optional:            true,
+			},
+			"match_mode": schema.StringAttribute{
+				MarkdownDescription: "How `expect_banner` and `expect_response` are matched: `contains` or `regex`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(payloadMatchContains),
+			},
+			"read_timeout": schema.Int64Attribute{
+				MarkdownDescription: "Seconds to wait for expected data",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use timeout
+			},
+			"expect": schema.StringAttribute{
+				MarkdownDescription: "Expected state of the port: `open`, `closed` (connection refused) or `filtered` (connection timed out or rejected as unreachable). Use `closed` or `filtered` to verify firewall rules; note that `filtered` takes the full `timeout` to confirm.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(tcpStateOpen),
+			},
+			"ip_version": schema.StringAttribute{
+				MarkdownDescription: "Address family to connect over: `4`, `6` or `both` (each family must pass). By default any resolved address may be used.",
+				Optional:            true,
+			},
+			"test_all_addresses": schema.BoolAttribute{
+				MarkdownDescription: "Resolve the host and check every address (of the selected `ip_version`) separately instead of the first one that connects",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"source_address": sourceAddressSchemaAttribute(),
 
 			// Results - these are computed values based on the last test run
 			"last_run": schema.StringAttribute{
@@ -92,11 +165,24 @@ func (r *TcpTestResource) Schema(ctx context.Context, req resource.SchemaRequest
 				Computed:            true,
 			},
 			"last_connect_time": schema.Int64Attribute{
-				MarkdownDescription: "Connection time in milliseconds from the last test run",
+				MarkdownDescription: "Connection time in milliseconds from the last test run. When several addresses are checked through `ip_version` or `test_all_addresses`, this is the slowest of their connection times, with each address's own time in `address_results`.",
 				Computed:            true,
 			},
+			"last_response": schema.StringAttribute{
+				MarkdownDescription: "Data received from the server in the last test run. Data is only read while waiting for `expect_banner` or `expect_response`.",
+				Computed:            true,
+			},
+			"last_state": schema.StringAttribute{
+				MarkdownDescription: "State of the port observed in the last test run: `open`, `closed`, `filtered` or `error` when the connection failed for another reason (e.g. the host could not be resolved)",
+				Computed:            true,
+			},
+			"last_local_address": schema.StringAttribute{
+				MarkdownDescription: "Local address (`ip:port`) of the connection in the last test run, empty if no connection was established",
+				Computed:            true,
+			},
+			"address_results": tcpResultsSchemaAttribute("Results of the last test run keyed by IP address, set when `ip_version` or `test_all_addresses` is used"),
 			"test_passed": schema.BoolAttribute{
-				MarkdownDescription: "Whether the test passed (connection was established)",
+				MarkdownDescription: "Whether the test passed (the port was in the expected state and any expected data was received)",
 				Computed:            true,
 			},
 			"error": schema.StringAttribute{
@@ -232,9 +318,7 @@ func (r *TcpTestResource) ImportState(ctx context.Context, req resource.ImportSt
 }
 
 // runTest runs the TCP test and updates the resource model with the results.
-//
-//nolint:unparam
-func (r *TcpTestResource) runTest(_ context.Context, data *TcpTestResourceModel) error {
+func (r *TcpTestResource) runTest(ctx context.Context, data *TcpTestResourceModel) error {
 	// Get timeout from resource or default from provider
 	timeout := r.clientConfig.HttpClient.Timeout
 	if !data.Timeout.IsNull() && data.Timeout.ValueInt64() > 0 {
@@ -253,45 +337,507 @@ func (r *TcpTestResource) runTest(_ context.Context, data *TcpTestResourceModel)
 		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
 	}
 
-	// Format the address
-	address := fmt.Sprintf("%s:%d", data.Host.ValueString(), data.Port.ValueInt64())
+	// Resolve the expected port state
+	expect, err := validateTcpExpect(data.Expect.ValueString())
+	if err != nil {
+		return err
+	}
+	if expect != tcpStateOpen && (!data.Send.IsNull() || !data.ExpectBanner.IsNull() || !data.ExpectResponse.IsNull()) {
+		return fmt.Errorf("send, expect_banner and expect_response require expect to be %q", tcpStateOpen)
+	}
+
+	// Prepare the payload exchange
+	payload, err := decodePayload(data.Send.ValueString(), data.SendEncoding.ValueString())
+	if err != nil {
+		return fmt.Errorf("invalid send: %w", err)
+	}
 
-	// Perform the connection attempt with retries
-	var err error
+	bannerMatcher, err := newPayloadMatcher(data.ExpectBanner, data.MatchMode.ValueString())
+	if err != nil {
+		return fmt.Errorf("invalid expect_banner: %w", err)
+	}
+
+	responseMatcher, err := newPayloadMatcher(data.ExpectResponse, data.MatchMode.ValueString())
+	if err != nil {
+		return fmt.Errorf("invalid expect_response: %w", err)
+	}
+
+	readTimeout := timeout
+	if !data.ReadTimeout.IsNull() && data.ReadTimeout.ValueInt64() > 0 {
+		readTimeout = time.Duration(data.ReadTimeout.ValueInt64()) * time.Second
+	}
+
+	sourceAddress, err := parseSourceAddress(data.SourceAddress.ValueString())
+	if err != nil {
+		return err
+	}
+
+	// Resolve the addresses to dial when the address family matters
+	ipVersion := data.IPVersion.ValueString()
+	if err := validateIPVersion(ipVersion); err != nil {
+		return err
+	}
+
+	probe := tcpProbe{
+		expect:      expect,
+		payload:     payload,
+		banner:      bannerMatcher,
+		response:    responseMatcher,
+		dialer:      newSourceDialer("tcp", sourceAddress, timeout),
+		readTimeout: readTimeout,
+		retries:     retries,
+		retryDelay:  retryDelay,
+	}
+	port := strconv.FormatInt(data.Port.ValueInt64(), 10)
+
+	// IPv6 hosts may be given with or without brackets
+	host := strings.TrimSuffix(strings.TrimPrefix(data.Host.ValueString(), "["), "]")
+
+	data.AddressResults = types.MapValueMust(types.ObjectType{AttrTypes: tcpResultAttrTypes}, map[string]attr.Value{})
+
+	addresses, err := resolveIPTargets(ctx, host, ipVersion, data.TestAllAddresses.ValueBool())
+	if err != nil {
+		data.Error = types.StringValue(fmt.Sprintf("Failed to resolve host: %s", err.Error()))
+		data.TestPassed = types.BoolValue(false)
+		data.LastConnectTime = types.Int64Value(0)
+		data.LastResponse = types.StringValue("")
+		data.LastState = types.StringValue(tcpStateError)
+		data.LastLocalAddress = types.StringValue("")
+		return nil // Don't return error as we want to keep the error in the state
+	}
+
+	// Let the dialer pick an address unless specific addresses were resolved
+	if addresses == nil {
+		result := probe.run(net.JoinHostPort(host, port))
+
+		data.LastState = types.StringValue(result.state)
+		data.LastLocalAddress = types.StringValue(result.localAddress)
+		data.LastConnectTime = types.Int64Value(int64(result.connectTime / time.Millisecond))
+		data.LastResponse = types.StringValue(payloadString(result.response))
+		data.TestPassed = types.BoolValue(result.err == "")
+		data.Error = types.StringValue(result.err)
+		return nil
+	}
+
+	// Probe every address separately
+	results := make(map[string]attr.Value, len(addresses))
+	var first, firstFailed *tcpProbeResult
+	var slowest time.Duration
+	var errorMsg strings.Builder
+
+	for _, address := range addresses {
+		result := probe.run(net.JoinHostPort(address, port))
+		results[address] = result.value()
+
+		if first == nil {
+			first = &result
+		}
+		if result.err != "" {
+			if firstFailed == nil {
+				firstFailed = &result
+			}
+			errorMsg.WriteString(fmt.Sprintf("%s: %s. ", address, result.err))
+		}
+		slowest = max(slowest, result.connectTime)
+	}
+
+	// Report the first failing address, or the first address if all passed,
+	// along with the slowest connection time of all addresses
+	reported := first
+	if firstFailed != nil {
+		reported = firstFailed
+	}
+
+	data.AddressResults = types.MapValueMust(types.ObjectType{AttrTypes: tcpResultAttrTypes}, results)
+	data.LastState = types.StringValue(reported.state)
+	data.LastLocalAddress = types.StringValue(reported.localAddress)
+	data.LastConnectTime = types.Int64Value(int64(slowest / time.Millisecond))
+	data.LastResponse = types.StringValue(payloadString(reported.response))
+	data.TestPassed = types.BoolValue(firstFailed == nil)
+	data.Error = types.StringValue(errorMsg.String())
+
+	return nil
+}
+
+// tcpProbe checks that a port is in the expected state and, for open ports,
+// exchanges the configured payload.
+type tcpProbe struct {
+	expect      string
+	payload     []byte
+	banner      *payloadMatcher
+	response    *payloadMatcher
+	dialer      *net.Dialer
+	readTimeout time.Duration
+	retries     int64
+	retryDelay  time.Duration
+}
+
+// tcpProbeResult is the outcome of probing a single address. An empty err
+// means the probe passed.
+type tcpProbeResult struct {
+	state        string
+	connectTime  time.Duration
+	localAddress string
+	response     []byte
+	err          string
+}
+
+// value converts the result to an entry of a results map.
+func (r tcpProbeResult) value() attr.Value {
+	return types.ObjectValueMust(tcpResultAttrTypes, map[string]attr.Value{
+		"state":        types.StringValue(r.state),
+		"passed":       types.BoolValue(r.err == ""),
+		"connect_time": types.Int64Value(int64(r.connectTime / time.Millisecond)),
+		"error":        types.StringValue(r.err),
+	})
+}
+
+// run probes the address.
+func (p tcpProbe) run(address string) tcpProbeResult {
+	// Perform the connection attempt with retries until the expected state is seen
+	conn, connectTime, state, err := dialTcpExpecting(p.dialer, address, p.expect, p.retries, p.retryDelay)
+
+	result := tcpProbeResult{state: state}
+	if conn != nil {
+		defer func() { _ = conn.Close() }()
+		result.connectTime = connectTime
+		result.localAddress = localAddressString(conn)
+	}
+
+	// Handle a port that is not in the expected state
+	if state != p.expect {
+		switch {
+		case state == tcpStateOpen:
+			result.err = fmt.Sprintf("Expected port to be %s but it is open", p.expect)
+		case p.expect == tcpStateOpen:
+			result.err = fmt.Sprintf("TCP connection failed: %s", err.Error())
+		default:
+			result.err = fmt.Sprintf("Expected port to be %s but it is %s: %s", p.expect, state, err.Error())
+		}
+		return result
+	}
+
+	// A closed or filtered port is all that was asked for
+	if p.expect != tcpStateOpen {
+		return result
+	}
+
+	// Exchange data with the server
+	received, exchangeErr := exchangePayload(conn, p.payload, p.banner, p.response, p.readTimeout)
+	result.response = received
+	if exchangeErr != nil {
+		result.err = fmt.Sprintf("TCP exchange failed: %s", exchangeErr.Error())
+	}
+
+	return result
+}
+
+// tcpResultAttrTypes describes an entry of a per-address or per-endpoint
+// results map.
+var tcpResultAttrTypes = map[string]attr.Type{
+	"state":        types.StringType,
+	"passed":       types.BoolType,
+	"connect_time": types.Int64Type,
+	"error":        types.StringType,
+}
+
+// tcpResultsSchemaAttribute returns the schema of a computed results map.
+func tcpResultsSchemaAttribute(description string) schema.MapNestedAttribute {
+	return schema.MapNestedAttribute{
+		MarkdownDescription: description,
+		Computed:            true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"state": schema.StringAttribute{
+					MarkdownDescription: "Observed state: `open`, `closed`, `filtered` or `error`",
+					Computed:            true,
+				},
+				"passed": schema.BoolAttribute{
+					MarkdownDescription: "Whether the port was in the expected state and any expected data was received",
+					Computed:            true,
+				},
+				"connect_time": schema.Int64Attribute{
+					MarkdownDescription: "Connection time in milliseconds for open ports",
+					Computed:            true,
+				},
+				"error": schema.StringAttribute{
+					MarkdownDescription: "Error message if the check failed",
+					Computed:            true,
+				},
+			},
+		},
+	}
+}
+
+const (
+	ipVersion4    = "4"
+	ipVersion6    = "6"
+	ipVersionBoth = "both"
+)
+
+// validateIPVersion checks an ip_version value.
+func validateIPVersion(version string) error {
+	switch version {
+	case "", ipVersion4, ipVersion6, ipVersionBoth:
+		return nil
+	default:
+		return fmt.Errorf("unsupported ip_version %q, must be one of: 4, 6, both", version)
+	}
+}
+
+// resolveIPTargets resolves the addresses of host that should be dialed
+// separately. It returns nil when neither an address family nor all
+// addresses were requested, leaving the choice to the dialer. Otherwise it
+// returns every matching address if all is set, or else the first address of
+// each requested family. "both" requires at least one address per family.
+func resolveIPTargets(ctx context.Context, host, version string, all bool) ([]string, error) {
+	if version == "" && !all {
+		return nil, nil
+	}
+
+	resolved, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var v4, v6 []string
+	for _, addr := range resolved {
+		if addr.IP.To4() != nil {
+			v4 = append(v4, addr.IP.String())
+		} else {
+			v6 = append(v6, addr.IP.String())
+		}
+	}
+
+	if (version == ipVersion4 || version == ipVersionBoth) && len(v4) == 0 {
+		return nil, fmt.Errorf("no IPv4 addresses found for %s", host)
+	}
+	if (version == ipVersion6 || version == ipVersionBoth) && len(v6) == 0 {
+		return nil, fmt.Errorf("no IPv6 addresses found for %s", host)
+	}
+
+	if !all {
+		v4, v6 = v4[:min(1, len(v4))], v6[:min(1, len(v6))]
+	}
+
+	switch version {
+	case ipVersion4:
+		return v4, nil
+	case ipVersion6:
+		return v6, nil
+	default:
+		return append(v4, v6...), nil
+	}
+}
+
+const (
+	tcpStateOpen     = "open"
+	tcpStateClosed   = "closed"
+	tcpStateFiltered = "filtered"
+	tcpStateError    = "error"
+)
+
+// dialTcpExpecting dials the address with the dialer, retrying until the port
+// is in the expected state. It returns the connection if the port is open,
+// the time taken by the last attempt, the observed state and the dial error.
+func dialTcpExpecting(dialer *net.Dialer, address, expect string, retries int64, retryDelay time.Duration) (net.Conn, time.Duration, string, error) {
+	var conn net.Conn
 	var connectTime time.Duration
+	var state string
+	var err error
 
 	for i := int64(0); i <= retries; i++ {
 		start := time.Now()
 		// Try to establish a TCP connection
-		conn, dialErr := net.DialTimeout("tcp", address, timeout)
+		conn, err = dialer.Dial("tcp", address)
 		connectTime = time.Since(start)
+		state = tcpConnectionState(err)
 
-		if dialErr == nil {
-			// Connection successful
-			_ = conn.Close()
-			err = nil
+		if state == expect {
 			break
 		}
 
-		err = dialErr
+		if conn != nil && i < retries {
+			_ = conn.Close()
+		}
 
 		if i < retries {
 			time.Sleep(retryDelay)
 		}
 	}
 
-	// Handle connection errors
-	if err != nil {
-		data.Error = types.StringValue(fmt.Sprintf("TCP connection failed: %s", err.Error()))
-		data.TestPassed = types.BoolValue(false)
-		data.LastConnectTime = types.Int64Value(0)
-		return nil // Don't return error as we want to keep the error in the state
+	return conn, connectTime, state, err
+}
+
+// validateTcpExpect checks an expect value and returns the default if unset.
+func validateTcpExpect(expect string) (string, error) {
+	switch expect {
+	case "":
+		return tcpStateOpen, nil
+	case tcpStateOpen, tcpStateClosed, tcpStateFiltered:
+		return expect, nil
+	default:
+		return "", fmt.Errorf("unsupported expect %q, must be one of: open, closed, filtered", expect)
 	}
+}
 
-	// Update the test results
-	data.LastConnectTime = types.Int64Value(int64(connectTime / time.Millisecond))
-	data.TestPassed = types.BoolValue(true)
-	data.Error = types.StringValue("")
+// tcpConnectionState classifies the result of a dial the way port scanners
+// do: a refused connection means nothing listens on the port, while a
+// timeout or an ICMP unreachable reply means a firewall dropped or rejected
+// the connection.
+func tcpConnectionState(err error) string {
+	if err == nil {
+		return tcpStateOpen
+	}
 
-	return nil
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return tcpStateClosed
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return tcpStateFiltered
+	}
+
+	if errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EACCES) {
+		return tcpStateFiltered
+	}
+
+	return tcpStateError
+}
+
+const (
+	payloadEncodingText   = "text"
+	payloadEncodingHex    = "hex"
+	payloadEncodingBase64 = "base64"
+
+	payloadMatchContains = "contains"
+	payloadMatchRegex    = "regex"
+)
+
+// decodePayload decodes a payload written in the given encoding.
+func decodePayload(payload, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", payloadEncodingText:
+		return []byte(payload), nil
+	case payloadEncodingHex:
+		// Allow hex dumps written with spaces, e.g. "de ad be ef"
+		return hex.DecodeString(strings.Join(strings.Fields(payload), ""))
+	case payloadEncodingBase64:
+		return base64.StdEncoding.DecodeString(payload)
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q, must be one of: text, hex, base64", encoding)
+	}
+}
+
+// payloadString renders received data for the state, replacing byte
+// sequences that are not valid UTF-8.
+func payloadString(data []byte) string {
+	return strings.ToValidUTF8(string(data), "\uFFFD")
+}
+
+// payloadMatcher matches data received from a server against an expectation.
+type payloadMatcher struct {
+	expected string
+	regex    *regexp.Regexp
+}
+
+// newPayloadMatcher returns a matcher for the expectation, or nil if it is
+// not set.
+func newPayloadMatcher(expected types.String, mode string) (*payloadMatcher, error) {
+	if expected.IsNull() || expected.IsUnknown() {
+		return nil, nil
+	}
+
+	switch mode {
+	case "", payloadMatchContains:
+		return &payloadMatcher{expected: expected.ValueString()}, nil
+	case payloadMatchRegex:
+		re, err := regexp.Compile(expected.ValueString())
+		if err != nil {
+			return nil, err
+		}
+		return &payloadMatcher{expected: expected.ValueString(), regex: re}, nil
+	default:
+		return nil, fmt.Errorf("unsupported match_mode %q, must be one of: contains, regex", mode)
+	}
+}
+
+// matches reports whether the received data satisfies the expectation.
+func (m *payloadMatcher) matches(data []byte) bool {
+	if m.regex != nil {
+		return m.regex.Match(data)
+	}
+	return bytes.Contains(data, []byte(m.expected))
+}
+
+// readUntilMatch reads from the connection until the matcher is satisfied,
+// the connection is closed or the deadline passes. It returns the data read
+// and whether it matched.
+func readUntilMatch(conn net.Conn, matcher *payloadMatcher, deadline time.Time) ([]byte, bool, error) {
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, false, err
+	}
+
+	var received []byte
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		received = append(received, buf[:n]...)
+		if matcher.matches(received) {
+			return received, true, nil
+		}
+		if err != nil {
+			return received, false, err
+		}
+	}
+}
+
+// exchangePayload waits for the banner, sends the payload and waits for the
+// response. All data read is returned along with a description of the first
+// expectation that was not met.
+func exchangePayload(conn net.Conn, payload []byte, banner, response *payloadMatcher, readTimeout time.Duration) ([]byte, error) {
+	var received []byte
+
+	if banner != nil {
+		data, matched, err := readUntilMatch(conn, banner, time.Now().Add(readTimeout))
+		received = append(received, data...)
+		if !matched {
+			return received, unmatchedPayloadError("banner", banner, err)
+		}
+	}
+
+	if len(payload) > 0 {
+		if err := conn.SetWriteDeadline(time.Now().Add(readTimeout)); err != nil {
+			return received, err
+		}
+		if _, err := conn.Write(payload); err != nil {
+			return received, fmt.Errorf("failed to send payload: %w", err)
+		}
+	}
+
+	if response != nil {
+		data, matched, err := readUntilMatch(conn, response, time.Now().Add(readTimeout))
+		received = append(received, data...)
+		if !matched {
+			return received, unmatchedPayloadError("response", response, err)
+		}
+	}
+
+	return received, nil
+}
+
+// unmatchedPayloadError describes why an expectation was not met.
+func unmatchedPayloadError(kind string, matcher *payloadMatcher, err error) error {
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("timed out waiting for %s matching '%s'", kind, matcher.expected)
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("connection closed before %s matched '%s'", kind, matcher.expected)
+	case err != nil:
+		return fmt.Errorf("failed to read %s: %w", kind, err)
+	default:
+		return fmt.Errorf("%s does not match '%s'", kind, matcher.expected)
+	}
 }
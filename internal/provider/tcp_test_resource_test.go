@@ -1,10 +1,13 @@
 package provider
 
 import (
+	"bufio"
 	"context"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -85,6 +88,349 @@ func TestTcpTestResource_runTest(t *testing.T) {
 	}
 }
 
+// TestTcpTestResource_payloadExchange tests sending a payload and matching
+// the banner and response.
+func TestTcpTestResource_payloadExchange(t *testing.T) {
+	// Line based server with a greeting that answers PING with +PONG
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to set up TCP listener: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer func() { _ = conn.Close() }()
+				_, _ = conn.Write([]byte("SSH-2.0-TestServer_1.2\r\n"))
+
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					switch strings.TrimSpace(scanner.Text()) {
+					case "PING":
+						_, _ = conn.Write([]byte("+PONG\r\n"))
+					case "QUIT":
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	resource := &TcpTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	host, portStr, _ := net.SplitHostPort(listener.Addr().String())
+	port, _ := strconv.ParseInt(portStr, 10, 64)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		model    TcpTestResourceModel
+		passed   bool
+		response string
+	}{
+		{
+			name: "banner regex",
+			model: TcpTestResourceModel{
+				ExpectBanner: types.StringValue(`^SSH-2\.0-\S+`),
+				MatchMode:    types.StringValue("regex"),
+			},
+			passed:   true,
+			response: "SSH-2.0-TestServer_1.2\r\n",
+		},
+		{
+			name: "text ping",
+			model: TcpTestResourceModel{
+				Send:           types.StringValue("PING\r\n"),
+				ExpectResponse: types.StringValue("+PONG"),
+			},
+			passed: true,
+		},
+		{
+			name: "hex ping",
+			model: TcpTestResourceModel{
+				ExpectBanner:   types.StringValue("SSH-2.0"),
+				Send:           types.StringValue("50 49 4e 47 0d 0a"),
+				SendEncoding:   types.StringValue("hex"),
+				ExpectResponse: types.StringValue("+PONG"),
+			},
+			passed:   true,
+			response: "SSH-2.0-TestServer_1.2\r\n+PONG\r\n",
+		},
+		{
+			name: "response timeout",
+			model: TcpTestResourceModel{
+				Send:           types.StringValue("HELLO\r\n"),
+				ExpectResponse: types.StringValue("+PONG"),
+				ReadTimeout:    types.Int64Value(1),
+			},
+			passed: false,
+		},
+		{
+			name: "closed before match",
+			model: TcpTestResourceModel{
+				Send:           types.StringValue("UVVJVAo="),
+				SendEncoding:   types.StringValue("base64"),
+				ExpectResponse: types.StringValue("+PONG"),
+			},
+			passed: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			model := tc.model
+			model.Name = types.StringValue(tc.name)
+			model.Host = types.StringValue(host)
+			model.Port = types.Int64Value(port)
+
+			if err := resource.runTest(ctx, &model); err != nil {
+				t.Fatalf("runTest failed: %v", err)
+			}
+
+			if model.TestPassed.ValueBool() != tc.passed {
+				t.Errorf("Expected test_passed=%t, got %t (error: %s)", tc.passed, model.TestPassed.ValueBool(), model.Error.ValueString())
+			}
+
+			if tc.response != "" && model.LastResponse.ValueString() != tc.response {
+				t.Errorf("Expected last_response %q, got %q", tc.response, model.LastResponse.ValueString())
+			}
+		})
+	}
+
+	// Invalid payload encodings are configuration errors
+	model := &TcpTestResourceModel{
+		Name:         types.StringValue("invalid"),
+		Host:         types.StringValue(host),
+		Port:         types.Int64Value(port),
+		Send:         types.StringValue("zz"),
+		SendEncoding: types.StringValue("hex"),
+	}
+	if err := resource.runTest(ctx, model); err == nil {
+		t.Error("Expected an error for invalid hex payload")
+	}
+}
+
+// TestTcpTestResource_expectState tests verifying that a port is not reachable.
+func TestTcpTestResource_expectState(t *testing.T) {
+	// Find a port with nothing listening on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to set up TCP listener: %v", err)
+	}
+	host, portStr, _ := net.SplitHostPort(listener.Addr().String())
+	closedPort, _ := strconv.ParseInt(portStr, 10, 64)
+	_ = listener.Close()
+
+	// And one that accepts connections
+	openListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to set up TCP listener: %v", err)
+	}
+	defer func() { _ = openListener.Close() }()
+	_, openPortStr, _ := net.SplitHostPort(openListener.Addr().String())
+	openPort, _ := strconv.ParseInt(openPortStr, 10, 64)
+
+	resource := &TcpTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		port   int64
+		expect string
+		passed bool
+		state  string
+	}{
+		{name: "closed port expected closed", port: closedPort, expect: "closed", passed: true, state: "closed"},
+		{name: "closed port expected open", port: closedPort, expect: "open", passed: false, state: "closed"},
+		{name: "closed port expected filtered", port: closedPort, expect: "filtered", passed: false, state: "closed"},
+		{name: "open port expected closed", port: openPort, expect: "closed", passed: false, state: "open"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			model := &TcpTestResourceModel{
+				Name:   types.StringValue(tc.name),
+				Host:   types.StringValue(host),
+				Port:   types.Int64Value(tc.port),
+				Expect: types.StringValue(tc.expect),
+			}
+
+			if err := resource.runTest(ctx, model); err != nil {
+				t.Fatalf("runTest failed: %v", err)
+			}
+
+			if model.TestPassed.ValueBool() != tc.passed {
+				t.Errorf("Expected test_passed=%t, got %t (error: %s)", tc.passed, model.TestPassed.ValueBool(), model.Error.ValueString())
+			}
+
+			if model.LastState.ValueString() != tc.state {
+				t.Errorf("Expected last_state %s, got %s", tc.state, model.LastState.ValueString())
+			}
+		})
+	}
+
+	// A dial timeout is reported as filtered
+	timeoutErr := &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}
+	if state := tcpConnectionState(timeoutErr); state != "filtered" {
+		t.Errorf("Expected a timeout to be filtered, got %s", state)
+	}
+
+	// Payload expectations only make sense for open ports
+	model := &TcpTestResourceModel{
+		Name:   types.StringValue("invalid"),
+		Host:   types.StringValue(host),
+		Port:   types.Int64Value(closedPort),
+		Expect: types.StringValue("closed"),
+		Send:   types.StringValue("PING"),
+	}
+	if err := resource.runTest(ctx, model); err == nil {
+		t.Error("Expected an error when combining send with expect = closed")
+	}
+}
+
+// TestTcpTestResource_addressFamilies tests per-address results and address
+// family selection.
+func TestTcpTestResource_addressFamilies(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to set up TCP listener: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	_, portStr, _ := net.SplitHostPort(listener.Addr().String())
+	port, _ := strconv.ParseInt(portStr, 10, 64)
+
+	resource := &TcpTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+	ctx := context.Background()
+
+	// Every IPv4 address is reported separately
+	model := &TcpTestResourceModel{
+		Name:             types.StringValue("IPv4"),
+		Host:             types.StringValue("127.0.0.1"),
+		Port:             types.Int64Value(port),
+		IPVersion:        types.StringValue("4"),
+		TestAllAddresses: types.BoolValue(true),
+	}
+	if err := resource.runTest(ctx, model); err != nil {
+		t.Fatalf("runTest failed: %v", err)
+	}
+	if !model.TestPassed.ValueBool() {
+		t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+	}
+	result, ok := model.AddressResults.Elements()["127.0.0.1"].(types.Object)
+	if !ok {
+		t.Fatalf("Expected a result for 127.0.0.1, got %v", model.AddressResults)
+	}
+	if !result.Attributes()["passed"].Equal(types.BoolValue(true)) {
+		t.Errorf("Expected 127.0.0.1 to pass, got %v", result)
+	}
+
+	// Requiring both families fails when the host has no IPv6 address
+	model.IPVersion = types.StringValue("both")
+	if err := resource.runTest(ctx, model); err != nil {
+		t.Fatalf("runTest failed: %v", err)
+	}
+	if model.TestPassed.ValueBool() {
+		t.Error("Expected test to fail without an IPv6 address")
+	}
+	if !strings.Contains(model.Error.ValueString(), "no IPv6 addresses") {
+		t.Errorf("Unexpected error: %s", model.Error.ValueString())
+	}
+
+	// Without a family or all addresses no per-address results are reported
+	model.IPVersion = types.StringNull()
+	model.TestAllAddresses = types.BoolValue(false)
+	if err := resource.runTest(ctx, model); err != nil {
+		t.Fatalf("runTest failed: %v", err)
+	}
+	if !model.TestPassed.ValueBool() || len(model.AddressResults.Elements()) != 0 {
+		t.Errorf("Expected a plain connection check, got error %q and results %v", model.Error.ValueString(), model.AddressResults)
+	}
+
+	// Connections can be bound to a source address
+	model.SourceAddress = types.StringValue("127.0.0.1")
+	if err := resource.runTest(ctx, model); err != nil {
+		t.Fatalf("runTest failed: %v", err)
+	}
+	if !strings.HasPrefix(model.LastLocalAddress.ValueString(), "127.0.0.1:") {
+		t.Errorf("Expected local address on 127.0.0.1, got %q", model.LastLocalAddress.ValueString())
+	}
+
+	model.SourceAddress = types.StringValue("eth0")
+	if err := resource.runTest(ctx, model); err == nil {
+		t.Error("Expected an error for a source_address that is not an IP address")
+	}
+	model.SourceAddress = types.StringNull()
+
+	// Invalid address families are configuration errors
+	model.IPVersion = types.StringValue("5")
+	if err := resource.runTest(ctx, model); err == nil {
+		t.Error("Expected an error for an invalid ip_version")
+	}
+}
+
+// TestTcpTestResource_ipv6Host tests connecting to IPv6 hosts given with
+// and without brackets.
+func TestTcpTestResource_ipv6Host(t *testing.T) {
+	listener, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	_, portStr, _ := net.SplitHostPort(listener.Addr().String())
+	port, _ := strconv.ParseInt(portStr, 10, 64)
+
+	resource := &TcpTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	for _, host := range []string{"::1", "[::1]"} {
+		t.Run(host, func(t *testing.T) {
+			model := &TcpTestResourceModel{
+				Name: types.StringValue("IPv6"),
+				Host: types.StringValue(host),
+				Port: types.Int64Value(port),
+			}
+			if err := resource.runTest(context.Background(), model); err != nil {
+				t.Fatalf("runTest failed: %v", err)
+			}
+			if !model.TestPassed.ValueBool() {
+				t.Errorf("Expected test to pass, but it failed with error: %s", model.Error.ValueString())
+			}
+		})
+	}
+}
+
 // TestAccTcpTestResource is an acceptance test for the TCP test resource.
 func TestAccTcpTestResource(t *testing.T) {
 	// Skip in short mode as acceptance tests make real network connections
@@ -0,0 +1,602 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &TlsTestResource{}
+var _ resource.ResourceWithImportState = &TlsTestResource{}
+
+func NewTlsTestResource() resource.Resource {
+	return &TlsTestResource{}
+}
+
+// TlsTestResource defines the resource implementation.
+type TlsTestResource struct {
+	clientConfig *TerraProbeClientConfig
+}
+
+// TlsTestResourceModel describes the resource data model.
+type TlsTestResourceModel struct {
+	Name                types.String `tfsdk:"name"`
+	Host                types.String `tfsdk:"host"`
+	Port                types.Int64  `tfsdk:"port"`
+	StartTLS            types.String `tfsdk:"starttls"`
+	ServerName          types.String `tfsdk:"server_name"`
+	AllowedVersions     types.List   `tfsdk:"allowed_versions"`
+	AllowedCipherSuites types.List   `tfsdk:"allowed_cipher_suites"`
+	MinDaysUntilExpiry  types.Int64  `tfsdk:"min_days_until_expiry"`
+	ExpectOCSPStaple    types.Bool   `tfsdk:"expect_ocsp_staple"`
+	Timeout             types.Int64  `tfsdk:"timeout"`
+	Retries             types.Int64  `tfsdk:"retries"`
+	RetryDelay          types.Int64  `tfsdk:"retry_delay"`
+	Id                  types.String `tfsdk:"id"`
+
+	// TLS
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+	CACert             types.String `tfsdk:"ca_cert"`
+	ClientCert         types.String `tfsdk:"client_cert"`
+	ClientKey          types.String `tfsdk:"client_key"`
+
+	// Results
+	LastRun              types.String `tfsdk:"last_run"`
+	LastHandshakeTime    types.Int64  `tfsdk:"last_handshake_time"`
+	LastVersion          types.String `tfsdk:"last_version"`
+	LastCipherSuite      types.String `tfsdk:"last_cipher_suite"`
+	LastCertificateChain types.List   `tfsdk:"last_certificate_chain"`
+	LastOCSPStapled      types.Bool   `tfsdk:"last_ocsp_stapled"`
+	LastDaysUntilExpiry  types.Int64  `tfsdk:"last_days_until_expiry"`
+	TestPassed           types.Bool   `tfsdk:"test_passed"`
+	Error                types.String `tfsdk:"error"`
+}
+
+func (r *TlsTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tls_test"
+}
+
+func (r *TlsTestResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "TLS test resource that performs a TLS handshake with any host and port, optionally after a STARTTLS upgrade, and validates the negotiated parameters and certificate chain",
+
+		Attributes: mergeSchemaAttributes(map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Descriptive name for the test",
+				Required:            true,
+			},
+			"host": schema.StringAttribute{
+				MarkdownDescription: "Host to connect to (IP address or hostname)",
+				Required:            true,
+			},
+			"port": schema.Int64Attribute{
+				MarkdownDescription: "Port to connect to",
+				Required:            true,
+			},
+			"starttls": schema.StringAttribute{
+				MarkdownDescription: "Upgrade a plaintext connection before the handshake using the protocol's STARTTLS mechanism: `smtp`, `imap`, `pop3`, `ftp`, `postgres` or `ldap`. By default the handshake starts immediately.",
+				Optional:            true,
+			},
+			"server_name": schema.StringAttribute{
+				MarkdownDescription: "Server name sent via SNI and used for certificate verification (default: `host`)",
+				Optional:            true,
+			},
+			"allowed_versions": schema.ListAttribute{
+				MarkdownDescription: "TLS versions the negotiated version must be one of: `1.0`, `1.1`, `1.2` or `1.3`",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"allowed_cipher_suites": schema.ListAttribute{
+				MarkdownDescription: "Cipher suites the negotiated suite must be one of, using the IANA names, e.g. `TLS_AES_128_GCM_SHA256`",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"min_days_until_expiry": schema.Int64Attribute{
+				MarkdownDescription: "Minimum number of days before the first certificate in the chain expires",
+				Optional:            true,
+			},
+			"expect_ocsp_staple": schema.BoolAttribute{
+				MarkdownDescription: "Whether the server is expected to staple an OCSP response",
+				Optional:            true,
+			},
+			"timeout": schema.Int64Attribute{
+				MarkdownDescription: "Timeout in seconds for connecting, upgrading and completing the handshake",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of retries if the connection or handshake fails",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retry_delay": schema.Int64Attribute{
+				MarkdownDescription: "Delay between retries in seconds",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+
+			// Results - these are computed values based on the last test run
+			"last_run": schema.StringAttribute{
+				MarkdownDescription: "Timestamp of the last test run",
+				Computed:            true,
+			},
+			"last_handshake_time": schema.Int64Attribute{
+				MarkdownDescription: "TLS handshake time in milliseconds from the last test run",
+				Computed:            true,
+			},
+			"last_version": schema.StringAttribute{
+				MarkdownDescription: "TLS version negotiated in the last test run, e.g. `1.3`",
+				Computed:            true,
+			},
+			"last_cipher_suite": schema.StringAttribute{
+				MarkdownDescription: "Cipher suite negotiated in the last test run",
+				Computed:            true,
+			},
+			"last_certificate_chain": schema.ListNestedAttribute{
+				MarkdownDescription: "Certificates presented by the server in the last test run, leaf first",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"subject": schema.StringAttribute{
+							MarkdownDescription: "Certificate subject",
+							Computed:            true,
+						},
+						"issuer": schema.StringAttribute{
+							MarkdownDescription: "Certificate issuer",
+							Computed:            true,
+						},
+						"serial_number": schema.StringAttribute{
+							MarkdownDescription: "Serial number in hexadecimal",
+							Computed:            true,
+						},
+						"dns_names": schema.ListAttribute{
+							MarkdownDescription: "DNS subject alternative names",
+							ElementType:         types.StringType,
+							Computed:            true,
+						},
+						"not_before": schema.StringAttribute{
+							MarkdownDescription: "Start of the validity period (RFC 3339)",
+							Computed:            true,
+						},
+						"not_after": schema.StringAttribute{
+							MarkdownDescription: "End of the validity period (RFC 3339)",
+							Computed:            true,
+						},
+						"sha256_fingerprint": schema.StringAttribute{
+							MarkdownDescription: "SHA-256 fingerprint of the DER encoded certificate",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"last_ocsp_stapled": schema.BoolAttribute{
+				MarkdownDescription: "Whether the server stapled an OCSP response in the last test run",
+				Computed:            true,
+			},
+			"last_days_until_expiry": schema.Int64Attribute{
+				MarkdownDescription: "Days until the first certificate in the chain expires, negative once expired",
+				Computed:            true,
+			},
+			"test_passed": schema.BoolAttribute{
+				MarkdownDescription: "Whether the test passed (the handshake succeeded and all assertions held)",
+				Computed:            true,
+			},
+			"error": schema.StringAttribute{
+				MarkdownDescription: "Error message if the test failed",
+				Computed:            true,
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Test identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		}, httpTLSSchemaAttributes()),
+	}
+}
+
+func (r *TlsTestResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	clientConfig, ok := req.ProviderData.(*TerraProbeClientConfig)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *TerraProbeClientConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.clientConfig = clientConfig
+}
+
+func (r *TlsTestResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data TlsTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate a unique identifier for this test
+	data.Id = types.StringValue(fmt.Sprintf("tls-test-%s", time.Now().Format("20060102150405")))
+
+	// Run the TLS test
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("TLS Test Error", err.Error())
+		return
+	}
+
+	// Set the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Write logs
+	tflog.Trace(ctx, "created TLS test resource")
+	tflog.Debug(ctx, fmt.Sprintf("TLS Test Result: %t - %s:%d", data.TestPassed.ValueBool(), data.Host.ValueString(), data.Port.ValueInt64()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TlsTestResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data TlsTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the TLS test again during Read
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("TLS Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TlsTestResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data TlsTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the TLS test with updated parameters
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("TLS Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *TlsTestResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data TlsTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Nothing special to do for delete, as this is a stateless resource
+	// The resource will be removed from Terraform state
+}
+
+func (r *TlsTestResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// runTest runs the TLS test and updates the resource model with the results.
+func (r *TlsTestResource) runTest(ctx context.Context, data *TlsTestResourceModel) error {
+	// Get timeout from resource or default from provider
+	timeout := r.clientConfig.HttpClient.Timeout
+	if !data.Timeout.IsNull() && data.Timeout.ValueInt64() > 0 {
+		timeout = time.Duration(data.Timeout.ValueInt64()) * time.Second
+	}
+
+	// Get retries from resource or default from provider
+	retries := r.clientConfig.Retries
+	if !data.Retries.IsNull() && data.Retries.ValueInt64() > 0 {
+		retries = data.Retries.ValueInt64()
+	}
+
+	// Get retry delay from resource or default from provider
+	retryDelay := r.clientConfig.RetryDelay
+	if !data.RetryDelay.IsNull() && data.RetryDelay.ValueInt64() > 0 {
+		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
+	}
+
+	starttls := data.StartTLS.ValueString()
+	if err := validateStartTLS(starttls); err != nil {
+		return err
+	}
+
+	// Read the assertions
+	var allowedVersions, allowedCipherSuites []string
+	if !data.AllowedVersions.IsNull() && !data.AllowedVersions.IsUnknown() {
+		if diags := data.AllowedVersions.ElementsAs(ctx, &allowedVersions, false); diags.HasError() {
+			return fmt.Errorf("failed to read allowed_versions")
+		}
+		for _, version := range allowedVersions {
+			if _, ok := tlsVersionsByName[version]; !ok {
+				return fmt.Errorf("unsupported TLS version %q in allowed_versions, must be one of: 1.0, 1.1, 1.2, 1.3", version)
+			}
+		}
+	}
+	if !data.AllowedCipherSuites.IsNull() && !data.AllowedCipherSuites.IsUnknown() {
+		if diags := data.AllowedCipherSuites.ElementsAs(ctx, &allowedCipherSuites, false); diags.HasError() {
+			return fmt.Errorf("failed to read allowed_cipher_suites")
+		}
+		for _, suite := range allowedCipherSuites {
+			if !slices.Contains(tlsCipherSuiteNames(), suite) {
+				return fmt.Errorf("unknown cipher suite %q in allowed_cipher_suites", suite)
+			}
+		}
+	}
+
+	// Build the client configuration
+	serverName := data.Host.ValueString()
+	if !data.ServerName.IsNull() && data.ServerName.ValueString() != "" {
+		serverName = data.ServerName.ValueString()
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: serverName,
+		// Offer everything Go supports so the server's choice can be reported
+		// and judged by the assertions instead of failing the handshake
+		MinVersion:   tls.VersionTLS10,
+		CipherSuites: tlsCipherSuiteIDs(),
+	}
+	tlsOptions := httpTLSOptionsFromModel(data.InsecureSkipVerify, data.CACert, data.ClientCert, data.ClientKey)
+	if err := tlsOptions.applyTo(tlsConfig); err != nil {
+		return err
+	}
+
+	address := net.JoinHostPort(data.Host.ValueString(), strconv.FormatInt(data.Port.ValueInt64(), 10))
+
+	data.LastHandshakeTime = types.Int64Value(0)
+	data.LastVersion = types.StringValue("")
+	data.LastCipherSuite = types.StringValue("")
+	data.LastCertificateChain = types.ListValueMust(types.ObjectType{AttrTypes: tlsCertificateAttrTypes}, []attr.Value{})
+	data.LastOCSPStapled = types.BoolValue(false)
+	data.LastDaysUntilExpiry = types.Int64Value(0)
+
+	// Connect, upgrade and handshake with retries
+	var state tls.ConnectionState
+	var handshakeTime time.Duration
+	var err error
+	for i := int64(0); i <= retries; i++ {
+		if i > 0 {
+			time.Sleep(retryDelay)
+		}
+
+		state, handshakeTime, err = tlsHandshake(address, starttls, tlsConfig, timeout)
+		if err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		data.Error = types.StringValue(err.Error())
+		data.TestPassed = types.BoolValue(false)
+		return nil // Don't return error as we want to keep the error in the state
+	}
+
+	// Record the negotiated parameters
+	version := tlsVersionName(state.Version)
+	cipherSuite := tls.CipherSuiteName(state.CipherSuite)
+	chain := make([]attr.Value, 0, len(state.PeerCertificates))
+	for _, cert := range state.PeerCertificates {
+		chain = append(chain, tlsCertificateValue(cert))
+	}
+	daysUntilExpiry := tlsDaysUntilExpiry(state.PeerCertificates)
+	stapled := len(state.OCSPResponse) > 0
+
+	data.LastHandshakeTime = types.Int64Value(int64(handshakeTime / time.Millisecond))
+	data.LastVersion = types.StringValue(version)
+	data.LastCipherSuite = types.StringValue(cipherSuite)
+	data.LastCertificateChain = types.ListValueMust(types.ObjectType{AttrTypes: tlsCertificateAttrTypes}, chain)
+	data.LastOCSPStapled = types.BoolValue(stapled)
+	data.LastDaysUntilExpiry = types.Int64Value(daysUntilExpiry)
+
+	// Check the assertions
+	var errorMsg strings.Builder
+	if len(allowedVersions) > 0 && !slices.Contains(allowedVersions, version) {
+		errorMsg.WriteString(fmt.Sprintf("TLS version %s is not allowed (allowed: %s). ", version, strings.Join(allowedVersions, ", ")))
+	}
+	if len(allowedCipherSuites) > 0 && !slices.Contains(allowedCipherSuites, cipherSuite) {
+		errorMsg.WriteString(fmt.Sprintf("Cipher suite %s is not allowed. ", cipherSuite))
+	}
+	if !data.MinDaysUntilExpiry.IsNull() && daysUntilExpiry < data.MinDaysUntilExpiry.ValueInt64() {
+		errorMsg.WriteString(fmt.Sprintf("Certificate expires in %d days, expected at least %d. ", daysUntilExpiry, data.MinDaysUntilExpiry.ValueInt64()))
+	}
+	if !data.ExpectOCSPStaple.IsNull() && data.ExpectOCSPStaple.ValueBool() != stapled {
+		if stapled {
+			errorMsg.WriteString("Expected no OCSP staple but the server sent one. ")
+		} else {
+			errorMsg.WriteString("Expected an OCSP staple but the server did not send one. ")
+		}
+	}
+
+	data.TestPassed = types.BoolValue(errorMsg.Len() == 0)
+	data.Error = types.StringValue(errorMsg.String())
+
+	return nil
+}
+
+// tlsHandshake connects to the address, performs the STARTTLS upgrade if
+// requested and completes a TLS handshake within the timeout. It returns the
+// connection state and the time taken by the handshake itself.
+func tlsHandshake(address, starttls string, config *tls.Config, timeout time.Duration) (tls.ConnectionState, time.Duration, error) {
+	conn, _, _, err := dialTcpExpecting(&net.Dialer{Timeout: timeout}, address, tcpStateOpen, 0, 0)
+	if err != nil {
+		return tls.ConnectionState{}, 0, fmt.Errorf("TCP connection failed: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return tls.ConnectionState{}, 0, err
+	}
+
+	if starttls != "" {
+		if err := startTLS(conn, starttls); err != nil {
+			return tls.ConnectionState{}, 0, fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	start := time.Now()
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.Handshake(); err != nil {
+		return tls.ConnectionState{}, 0, fmt.Errorf("TLS handshake failed: %w", err)
+	}
+
+	return tlsConn.ConnectionState(), time.Since(start), nil
+}
+
+// tlsVersionsByName maps the version names used in the schema to their
+// protocol values.
+var tlsVersionsByName = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsVersionName returns the schema name of a TLS version.
+func tlsVersionName(version uint16) string {
+	for name, value := range tlsVersionsByName {
+		if value == version {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+// tlsCipherSuites returns every cipher suite implemented by crypto/tls,
+// including the insecure ones.
+func tlsCipherSuites() []*tls.CipherSuite {
+	return append(tls.CipherSuites(), tls.InsecureCipherSuites()...)
+}
+
+// tlsCipherSuiteIDs returns the IDs of every cipher suite implemented by
+// crypto/tls.
+func tlsCipherSuiteIDs() []uint16 {
+	var ids []uint16
+	for _, suite := range tlsCipherSuites() {
+		ids = append(ids, suite.ID)
+	}
+	return ids
+}
+
+// tlsCipherSuiteNames returns the names of every cipher suite implemented by
+// crypto/tls.
+func tlsCipherSuiteNames() []string {
+	var names []string
+	for _, suite := range tlsCipherSuites() {
+		names = append(names, suite.Name)
+	}
+	return names
+}
+
+// tlsDaysUntilExpiry returns the number of whole days until the first
+// certificate of the chain expires.
+func tlsDaysUntilExpiry(chain []*x509.Certificate) int64 {
+	if len(chain) == 0 {
+		return 0
+	}
+
+	expiry := chain[0].NotAfter
+	for _, cert := range chain[1:] {
+		if cert.NotAfter.Before(expiry) {
+			expiry = cert.NotAfter
+		}
+	}
+
+	remaining := time.Until(expiry)
+	days := int64(remaining / (24 * time.Hour))
+	if remaining < 0 && remaining%(24*time.Hour) != 0 {
+		days-- // Round towards the past for expired certificates
+	}
+	return days
+}
+
+// tlsCertificateAttrTypes describes an entry of last_certificate_chain.
+var tlsCertificateAttrTypes = map[string]attr.Type{
+	"subject":            types.StringType,
+	"issuer":             types.StringType,
+	"serial_number":      types.StringType,
+	"dns_names":          types.ListType{ElemType: types.StringType},
+	"not_before":         types.StringType,
+	"not_after":          types.StringType,
+	"sha256_fingerprint": types.StringType,
+}
+
+// tlsCertificateValue converts a certificate to an entry of
+// last_certificate_chain.
+func tlsCertificateValue(cert *x509.Certificate) attr.Value {
+	dnsNames := make([]attr.Value, 0, len(cert.DNSNames))
+	for _, name := range cert.DNSNames {
+		dnsNames = append(dnsNames, types.StringValue(name))
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	return types.ObjectValueMust(tlsCertificateAttrTypes, map[string]attr.Value{
+		"subject":            types.StringValue(cert.Subject.String()),
+		"issuer":             types.StringValue(cert.Issuer.String()),
+		"serial_number":      types.StringValue(cert.SerialNumber.Text(16)),
+		"dns_names":          types.ListValueMust(types.StringType, dnsNames),
+		"not_before":         types.StringValue(cert.NotBefore.UTC().Format(time.RFC3339)),
+		"not_after":          types.StringValue(cert.NotAfter.UTC().Format(time.RFC3339)),
+		"sha256_fingerprint": types.StringValue(hex.EncodeToString(fingerprint[:])),
+	})
+}
@@ -0,0 +1,203 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TestTlsTestResource_runTest tests the TLS test resource's runTest function.
+func TestTlsTestResource_runTest(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.ParseInt(portStr, 10, 64)
+
+	// SMTP server that upgrades to TLS with the same certificate
+	smtpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to set up SMTP listener: %v", err)
+	}
+	defer func() { _ = smtpListener.Close() }()
+
+	go func() {
+		for {
+			conn, err := smtpListener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSMTP(conn, server.TLS)
+		}
+	}()
+
+	_, smtpPortStr, _ := net.SplitHostPort(smtpListener.Addr().String())
+	smtpPort, _ := strconv.ParseInt(smtpPortStr, 10, 64)
+
+	resource := &TlsTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	ctx := context.Background()
+
+	newModel := func(name string, port int64) *TlsTestResourceModel {
+		return &TlsTestResourceModel{
+			Name:                types.StringValue(name),
+			Host:                types.StringValue(host),
+			Port:                types.Int64Value(port),
+			AllowedVersions:     types.ListNull(types.StringType),
+			AllowedCipherSuites: types.ListNull(types.StringType),
+			Timeout:             types.Int64Value(2),
+			CACert:              types.StringValue(caCert),
+		}
+	}
+
+	t.Run("direct handshake", func(t *testing.T) {
+		data := newModel("direct", port)
+		data.AllowedVersions = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("1.2"), types.StringValue("1.3")})
+		data.MinDaysUntilExpiry = types.Int64Value(30)
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !data.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, got error: %s", data.Error.ValueString())
+		}
+		if data.LastVersion.ValueString() != "1.3" {
+			t.Errorf("Expected TLS 1.3, got %s", data.LastVersion.ValueString())
+		}
+		if data.LastCipherSuite.ValueString() == "" {
+			t.Error("Expected a cipher suite")
+		}
+		if len(data.LastCertificateChain.Elements()) != 1 {
+			t.Errorf("Expected 1 certificate, got %d", len(data.LastCertificateChain.Elements()))
+		}
+		if data.LastDaysUntilExpiry.ValueInt64() < 30 {
+			t.Errorf("Unexpected days until expiry: %d", data.LastDaysUntilExpiry.ValueInt64())
+		}
+	})
+
+	t.Run("failed assertions", func(t *testing.T) {
+		data := newModel("assertions", port)
+		data.AllowedVersions = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("1.2")})
+		data.MinDaysUntilExpiry = types.Int64Value(1000000)
+		data.ExpectOCSPStaple = types.BoolValue(true)
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if data.TestPassed.ValueBool() {
+			t.Error("Expected test to fail")
+		}
+		for _, expected := range []string{"TLS version 1.3 is not allowed", "Certificate expires in", "Expected an OCSP staple"} {
+			if !strings.Contains(data.Error.ValueString(), expected) {
+				t.Errorf("Expected error to contain %q, got: %s", expected, data.Error.ValueString())
+			}
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		data := newModel("untrusted", port)
+		data.CACert = types.StringNull()
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if data.TestPassed.ValueBool() {
+			t.Error("Expected test to fail for an untrusted certificate")
+		}
+		if !strings.Contains(data.Error.ValueString(), "TLS handshake failed") {
+			t.Errorf("Unexpected error: %s", data.Error.ValueString())
+		}
+	})
+
+	t.Run("smtp starttls", func(t *testing.T) {
+		data := newModel("smtp", smtpPort)
+		data.StartTLS = types.StringValue("smtp")
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !data.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, got error: %s", data.Error.ValueString())
+		}
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		data := newModel("invalid", port)
+		data.StartTLS = types.StringValue("xmpp")
+		if err := resource.runTest(ctx, data); err == nil {
+			t.Error("Expected an error for an unsupported starttls protocol")
+		}
+
+		data = newModel("invalid", port)
+		data.AllowedCipherSuites = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("TLS_NOT_A_SUITE")})
+		if err := resource.runTest(ctx, data); err == nil {
+			t.Error("Expected an error for an unknown cipher suite")
+		}
+	})
+}
+
+// serveTestSMTP plays the server side of an SMTP STARTTLS upgrade.
+func serveTestSMTP(conn net.Conn, config *tls.Config) {
+	defer func() { _ = conn.Close() }()
+
+	reader := bufio.NewReader(conn)
+	_, _ = conn.Write([]byte("220 mail.example.com ESMTP\r\n"))
+	if line, _ := reader.ReadString('\n'); !strings.HasPrefix(line, "EHLO") {
+		return
+	}
+	_, _ = conn.Write([]byte("250-mail.example.com\r\n250 STARTTLS\r\n"))
+	if line, _ := reader.ReadString('\n'); !strings.HasPrefix(line, "STARTTLS") {
+		return
+	}
+	_, _ = conn.Write([]byte("220 Ready to start TLS\r\n"))
+
+	_ = tls.Server(conn, config).Handshake()
+}
+
+// TestReadLdapExtendedResultCode tests parsing of LDAP StartTLS replies.
+func TestReadLdapExtendedResultCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    []byte
+		expected int
+		wantErr  bool
+	}{
+		{"success", []byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, 0x00, 0x04, 0x00, 0x04, 0x00}, 0, false},
+		{"protocol error", []byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, 0x02, 0x04, 0x00, 0x04, 0x00}, 2, false},
+		{"long form length", []byte{0x30, 0x81, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, 0x00, 0x04, 0x00, 0x04, 0x00}, 0, false},
+		{"not an extended response", []byte{0x30, 0x05, 0x02, 0x01, 0x01, 0x61, 0x00}, 0, true},
+		{"truncated", []byte{0x30, 0x0c, 0x02, 0x01}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := readLdapExtendedResultCode(bufio.NewReader(bytes.NewReader(tt.reply)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if code != tt.expected {
+				t.Errorf("expected result code %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &UdpTestResource{}
+var _ resource.ResourceWithImportState = &UdpTestResource{}
+
+func NewUdpTestResource() resource.Resource {
+	return &UdpTestResource{}
+}
+
+// UdpTestResource defines the resource implementation.
+type UdpTestResource struct {
+	clientConfig *TerraProbeClientConfig
+}
+
+// UdpTestResourceModel describes the resource data model.
+type UdpTestResourceModel struct {
+	Name           types.String `tfsdk:"name"`
+	Host           types.String `tfsdk:"host"`
+	Port           types.Int64  `tfsdk:"port"`
+	Send           types.String `tfsdk:"send"`
+	SendEncoding   types.String `tfsdk:"send_encoding"`
+	ExpectResponse types.String `tfsdk:"expect_response"`
+	MatchMode      types.String `tfsdk:"match_mode"`
+	ReadTimeout    types.Int64  `tfsdk:"read_timeout"`
+	Timeout        types.Int64  `tfsdk:"timeout"`
+	Retries        types.Int64  `tfsdk:"retries"`
+	RetryDelay     types.Int64  `tfsdk:"retry_delay"`
+	SourceAddress  types.String `tfsdk:"source_address"`
+	Id             types.String `tfsdk:"id"`
+
+	// Results
+	LastRun          types.String `tfsdk:"last_run"`
+	LastResponseTime types.Int64  `tfsdk:"last_response_time"`
+	LastResponse     types.String `tfsdk:"last_response"`
+	LastLocalAddress types.String `tfsdk:"last_local_address"`
+	TestPassed       types.Bool   `tfsdk:"test_passed"`
+	Error            types.String `tfsdk:"error"`
+}
+
+func (r *UdpTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_udp_test"
+}
+
+func (r *UdpTestResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "UDP test resource that sends a datagram to a host and port and optionally validates the response",
+
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Descriptive name for the test",
+				Required:            true,
+			},
+			"host": schema.StringAttribute{
+				MarkdownDescription: "Host to send to (IP address or hostname). IPv6 addresses may be given with or without brackets.",
+				Required:            true,
+			},
+			"port": schema.Int64Attribute{
+				MarkdownDescription: "Port to send to",
+				Required:            true,
+			},
+			"send": schema.StringAttribute{
+				MarkdownDescription: "Payload to send, e.g. `\"deploys:1|c\"` for StatsD",
+				Required:            true,
+			},
+			"send_encoding": schema.StringAttribute{
+				MarkdownDescription: "Encoding of `send`: `text`, `hex` or `base64`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(payloadEncodingText),
+			},
+			"expect_response": schema.StringAttribute{
+				MarkdownDescription: "Data the server is expected to reply with. Without it the test passes unless the host reports the port as unreachable within `read_timeout`.",
+				Optional:            true,
+			},
+			"match_mode": schema.StringAttribute{
+				MarkdownDescription: "How `expect_response` is matched: `contains` or `regex`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(payloadMatchContains),
+			},
+			"read_timeout": schema.Int64Attribute{
+				MarkdownDescription: "Seconds to wait for a response or an ICMP port unreachable error after sending",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use timeout
+			},
+			"timeout": schema.Int64Attribute{
+				MarkdownDescription: "Timeout in seconds for resolving the host and sending the payload",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of times to resend the payload if the check fails",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retry_delay": schema.Int64Attribute{
+				MarkdownDescription: "Delay between retries in seconds",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"source_address": sourceAddressSchemaAttribute(),
+
+			// Results - these are computed values based on the last test run
+			"last_run": schema.StringAttribute{
+				MarkdownDescription: "Timestamp of the last test run",
+				Computed:            true,
+			},
+			"last_response_time": schema.Int64Attribute{
+				MarkdownDescription: "Time in milliseconds between sending the payload and receiving the response in the last test run",
+				Computed:            true,
+			},
+			"last_response": schema.StringAttribute{
+				MarkdownDescription: "Data received from the server in the last test run",
+				Computed:            true,
+			},
+			"last_local_address": schema.StringAttribute{
+				MarkdownDescription: "Local address (`ip:port`) the payload was sent from in the last test run",
+				Computed:            true,
+			},
+			"test_passed": schema.BoolAttribute{
+				MarkdownDescription: "Whether the test passed (the payload was sent, the port was not reported unreachable and any expected response was received)",
+				Computed:            true,
+			},
+			"error": schema.StringAttribute{
+				MarkdownDescription: "Error message if the test failed",
+				Computed:            true,
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Test identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *UdpTestResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	clientConfig, ok := req.ProviderData.(*TerraProbeClientConfig)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *TerraProbeClientConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.clientConfig = clientConfig
+}
+
+func (r *UdpTestResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data UdpTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate a unique identifier for this test
+	data.Id = types.StringValue(fmt.Sprintf("udp-test-%s", time.Now().Format("20060102150405")))
+
+	// Run the UDP test
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("UDP Test Error", err.Error())
+		return
+	}
+
+	// Set the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Write logs
+	tflog.Trace(ctx, "created UDP test resource")
+	tflog.Debug(ctx, fmt.Sprintf("UDP Test Result: %t - %s:%d", data.TestPassed.ValueBool(), data.Host.ValueString(), data.Port.ValueInt64()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *UdpTestResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data UdpTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the UDP test again during Read
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("UDP Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *UdpTestResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data UdpTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the UDP test with updated parameters
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("UDP Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *UdpTestResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data UdpTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Nothing special to do for delete, as this is a stateless resource
+	// The resource will be removed from Terraform state
+}
+
+func (r *UdpTestResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// runTest runs the UDP test and updates the resource model with the results.
+func (r *UdpTestResource) runTest(ctx context.Context, data *UdpTestResourceModel) error {
+	// Get timeout from resource or default from provider
+	timeout := r.clientConfig.HttpClient.Timeout
+	if !data.Timeout.IsNull() && data.Timeout.ValueInt64() > 0 {
+		timeout = time.Duration(data.Timeout.ValueInt64()) * time.Second
+	}
+
+	// Get retries from resource or default from provider
+	retries := r.clientConfig.Retries
+	if !data.Retries.IsNull() && data.Retries.ValueInt64() > 0 {
+		retries = data.Retries.ValueInt64()
+	}
+
+	// Get retry delay from resource or default from provider
+	retryDelay := r.clientConfig.RetryDelay
+	if !data.RetryDelay.IsNull() && data.RetryDelay.ValueInt64() > 0 {
+		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
+	}
+
+	// Prepare the payload exchange
+	payload, err := decodePayload(data.Send.ValueString(), data.SendEncoding.ValueString())
+	if err != nil {
+		return fmt.Errorf("invalid send: %w", err)
+	}
+	if len(payload) == 0 {
+		return fmt.Errorf("send must not be empty")
+	}
+
+	responseMatcher, err := newPayloadMatcher(data.ExpectResponse, data.MatchMode.ValueString())
+	if err != nil {
+		return fmt.Errorf("invalid expect_response: %w", err)
+	}
+
+	readTimeout := timeout
+	if !data.ReadTimeout.IsNull() && data.ReadTimeout.ValueInt64() > 0 {
+		readTimeout = time.Duration(data.ReadTimeout.ValueInt64()) * time.Second
+	}
+
+	sourceAddress, err := parseSourceAddress(data.SourceAddress.ValueString())
+	if err != nil {
+		return err
+	}
+
+	dialer := newSourceDialer("udp", sourceAddress, timeout)
+	// IPv6 hosts may be given with or without brackets
+	host := strings.TrimSuffix(strings.TrimPrefix(data.Host.ValueString(), "["), "]")
+	address := net.JoinHostPort(host, strconv.FormatInt(data.Port.ValueInt64(), 10))
+
+	// Send the payload with retries, as datagrams may be lost
+	var received []byte
+	var responseTime time.Duration
+	var localAddress string
+	for i := int64(0); i <= retries; i++ {
+		if i > 0 {
+			time.Sleep(retryDelay)
+		}
+
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, "udp", address)
+		if err != nil {
+			localAddress = ""
+			continue
+		}
+
+		localAddress = localAddressString(conn)
+		received, responseTime, err = exchangeDatagram(conn, payload, responseMatcher, timeout, readTimeout)
+		_ = conn.Close()
+		if err == nil {
+			break
+		}
+	}
+
+	data.LastLocalAddress = types.StringValue(localAddress)
+	data.LastResponse = types.StringValue(payloadString(received))
+	data.LastResponseTime = types.Int64Value(int64(responseTime / time.Millisecond))
+
+	if err != nil {
+		data.Error = types.StringValue(fmt.Sprintf("UDP exchange failed: %s", err.Error()))
+		data.TestPassed = types.BoolValue(false)
+		return nil // Don't return error as we want to keep the error in the state
+	}
+
+	data.TestPassed = types.BoolValue(true)
+	data.Error = types.StringValue("")
+
+	return nil
+}
+
+// exchangeDatagram sends the payload over a connected UDP socket and waits up
+// to readTimeout for a reply. When response is nil the first datagram
+// received is accepted and receiving nothing is not an error, as many UDP
+// services never reply.
+func exchangeDatagram(conn net.Conn, payload []byte, response *payloadMatcher, timeout, readTimeout time.Duration) ([]byte, time.Duration, error) {
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+	if _, err := conn.Write(payload); err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, 0, errUdpPortUnreachable
+		}
+		return nil, 0, fmt.Errorf("failed to send payload: %w", err)
+	}
+
+	if err := conn.SetReadDeadline(start.Add(readTimeout)); err != nil {
+		return nil, 0, err
+	}
+
+	// Every read returns a single datagram
+	var received []byte
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			received = append(received, buf[:n]...)
+			if response == nil || response.matches(received) {
+				return received, time.Since(start), nil
+			}
+		}
+		if err != nil {
+			var netErr net.Error
+			switch {
+			case errors.Is(err, syscall.ECONNREFUSED):
+				return received, 0, errUdpPortUnreachable
+			case response != nil:
+				return received, 0, unmatchedPayloadError("response", response, err)
+			case errors.As(err, &netErr) && netErr.Timeout():
+				return received, 0, nil
+			default:
+				return received, 0, fmt.Errorf("failed to read response: %w", err)
+			}
+		}
+	}
+}
+
+// errUdpPortUnreachable is reported when the host answers with an ICMP port
+// unreachable message, which surfaces as "connection refused" on a connected
+// UDP socket.
+var errUdpPortUnreachable = errors.New("port unreachable (ICMP port unreachable received)")
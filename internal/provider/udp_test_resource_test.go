@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TestUdpTestResource_runTest tests the UDP test resource's runTest function.
+func TestUdpTestResource_runTest(t *testing.T) {
+	// Set up a UDP server that answers PING with PONG and ignores everything else
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to set up UDP listener: %v", err)
+	}
+	defer func() { _ = server.Close() }()
+
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := server.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if bytes.HasPrefix(buf[:n], []byte("PING")) {
+				_, _ = server.WriteTo([]byte("PONG 42\n"), addr)
+			}
+		}
+	}()
+
+	_, portStr, _ := net.SplitHostPort(server.LocalAddr().String())
+	port, _ := strconv.ParseInt(portStr, 10, 64)
+
+	// Find a port nothing listens on
+	closed, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve UDP port: %v", err)
+	}
+	_, closedPortStr, _ := net.SplitHostPort(closed.LocalAddr().String())
+	closedPort, _ := strconv.ParseInt(closedPortStr, 10, 64)
+	_ = closed.Close()
+
+	resource := &UdpTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	ctx := context.Background()
+
+	newModel := func(name string, port int64, send string) *UdpTestResourceModel {
+		return &UdpTestResourceModel{
+			Name:         types.StringValue(name),
+			Host:         types.StringValue("127.0.0.1"),
+			Port:         types.Int64Value(port),
+			Send:         types.StringValue(send),
+			SendEncoding: types.StringValue("text"),
+			MatchMode:    types.StringValue("contains"),
+			ReadTimeout:  types.Int64Value(1),
+		}
+	}
+
+	t.Run("expected response", func(t *testing.T) {
+		data := newModel("ping", port, "PING\n")
+		data.ExpectResponse = types.StringValue(`^PONG \d+`)
+		data.MatchMode = types.StringValue("regex")
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !data.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, got error: %s", data.Error.ValueString())
+		}
+		if data.LastResponse.ValueString() != "PONG 42\n" {
+			t.Errorf("Unexpected response: %q", data.LastResponse.ValueString())
+		}
+	})
+
+	t.Run("source address", func(t *testing.T) {
+		data := newModel("source", port, "PING\n")
+		data.ExpectResponse = types.StringValue("PONG")
+		data.SourceAddress = types.StringValue("127.0.0.1")
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !data.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, got error: %s", data.Error.ValueString())
+		}
+		if !strings.HasPrefix(data.LastLocalAddress.ValueString(), "127.0.0.1:") {
+			t.Errorf("Expected local address on 127.0.0.1, got %q", data.LastLocalAddress.ValueString())
+		}
+	})
+
+	t.Run("IPv6 host", func(t *testing.T) {
+		server6, err := net.ListenPacket("udp", "[::1]:0")
+		if err != nil {
+			t.Skipf("IPv6 loopback not available: %v", err)
+		}
+		defer func() { _ = server6.Close() }()
+
+		go func() {
+			buf := make([]byte, 1024)
+			for {
+				_, addr, err := server6.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+				_, _ = server6.WriteTo([]byte("PONG 6\n"), addr)
+			}
+		}()
+
+		_, port6Str, _ := net.SplitHostPort(server6.LocalAddr().String())
+		port6, _ := strconv.ParseInt(port6Str, 10, 64)
+
+		for _, host := range []string{"::1", "[::1]"} {
+			data := newModel("ipv6", port6, "PING\n")
+			data.Host = types.StringValue(host)
+			data.ExpectResponse = types.StringValue("PONG 6")
+
+			if err := resource.runTest(ctx, data); err != nil {
+				t.Fatalf("runTest failed for %s: %v", host, err)
+			}
+			if !data.TestPassed.ValueBool() {
+				t.Errorf("Expected test to pass for %s, got error: %s", host, data.Error.ValueString())
+			}
+		}
+	})
+
+	t.Run("no response expected", func(t *testing.T) {
+		data := newModel("fire and forget", port, "deploys:1|c")
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !data.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, got error: %s", data.Error.ValueString())
+		}
+	})
+
+	t.Run("missing response", func(t *testing.T) {
+		data := newModel("silent", port, "HELLO\n")
+		data.ExpectResponse = types.StringValue("PONG")
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if data.TestPassed.ValueBool() {
+			t.Error("Expected test to fail without a response")
+		}
+		if !strings.Contains(data.Error.ValueString(), "timed out") {
+			t.Errorf("Expected a timeout error, got: %s", data.Error.ValueString())
+		}
+	})
+
+	t.Run("port unreachable", func(t *testing.T) {
+		data := newModel("unreachable", closedPort, "deploys:1|c")
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if data.TestPassed.ValueBool() {
+			t.Error("Expected test to fail for an unreachable port")
+		}
+		if !strings.Contains(data.Error.ValueString(), "port unreachable") {
+			t.Errorf("Expected a port unreachable error, got: %s", data.Error.ValueString())
+		}
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		data := newModel("invalid", port, "zz")
+		data.SendEncoding = types.StringValue("hex")
+
+		if err := resource.runTest(ctx, data); err == nil {
+			t.Error("Expected an error for an invalid hex payload")
+		}
+	})
+}
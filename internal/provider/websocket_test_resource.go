@@ -0,0 +1,652 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &WebsocketTestResource{}
+var _ resource.ResourceWithImportState = &WebsocketTestResource{}
+
+func NewWebsocketTestResource() resource.Resource {
+	return &WebsocketTestResource{}
+}
+
+// WebsocketTestResource defines the resource implementation.
+type WebsocketTestResource struct {
+	clientConfig *TerraProbeClientConfig
+}
+
+// WebsocketTestResourceModel describes the resource data model.
+type WebsocketTestResourceModel struct {
+	Name              types.String `tfsdk:"name"`
+	URL               types.String `tfsdk:"url"`
+	Headers           types.Map    `tfsdk:"headers"`
+	Subprotocols      types.List   `tfsdk:"subprotocols"`
+	Messages          types.List   `tfsdk:"messages"`
+	ExpectMessages    types.List   `tfsdk:"expect_messages"`
+	ExpectSubprotocol types.String `tfsdk:"expect_subprotocol"`
+	Timeout           types.Int64  `tfsdk:"timeout"`
+	Retries           types.Int64  `tfsdk:"retries"`
+	RetryDelay        types.Int64  `tfsdk:"retry_delay"`
+	Id                types.String `tfsdk:"id"`
+
+	// TLS
+	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+	CACert             types.String `tfsdk:"ca_cert"`
+	ClientCert         types.String `tfsdk:"client_cert"`
+	ClientKey          types.String `tfsdk:"client_key"`
+
+	// Authentication
+	BearerToken types.String `tfsdk:"bearer_token"`
+	BasicAuth   types.Object `tfsdk:"basic_auth"`
+
+	// Results
+	LastRun           types.String `tfsdk:"last_run"`
+	LastStatusCode    types.Int64  `tfsdk:"last_status_code"`
+	LastSubprotocol   types.String `tfsdk:"last_subprotocol"`
+	LastHandshakeTime types.Int64  `tfsdk:"last_handshake_time"`
+	LastRoundTripTime types.Int64  `tfsdk:"last_round_trip_time"`
+	LastMessages      types.List   `tfsdk:"last_messages"`
+	TestPassed        types.Bool   `tfsdk:"test_passed"`
+	Error             types.String `tfsdk:"error"`
+}
+
+// WebsocketMessageModel describes a message sent after the handshake.
+type WebsocketMessageModel struct {
+	Type types.String `tfsdk:"type"`
+	Data types.String `tfsdk:"data"`
+}
+
+// WebsocketExpectModel describes a message that must be received. All of the
+// set criteria have to match the same message.
+type WebsocketExpectModel struct {
+	Contains types.String `tfsdk:"contains"`
+	Regex    types.String `tfsdk:"regex"`
+	JsonPath types.Map    `tfsdk:"json_path"`
+}
+
+func (r *WebsocketTestResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_websocket_test"
+}
+
+func (r *WebsocketTestResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "WebSocket test resource that performs the upgrade handshake, sends messages and validates the replies",
+
+		Attributes: mergeSchemaAttributes(map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Descriptive name for the test",
+				Required:            true,
+			},
+			"url": schema.StringAttribute{
+				MarkdownDescription: "WebSocket URL to connect to (`ws://` or `wss://`)",
+				Required:            true,
+			},
+			"headers": schema.MapAttribute{
+				MarkdownDescription: "HTTP headers to include in the handshake request",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"subprotocols": schema.ListAttribute{
+				MarkdownDescription: "Subprotocols offered in the `Sec-WebSocket-Protocol` header, in order of preference",
+				Optional:            true,
+				ElementType:         types.StringType,
+			},
+			"messages": schema.ListNestedAttribute{
+				MarkdownDescription: "Messages sent in order after the handshake",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"type": schema.StringAttribute{
+							MarkdownDescription: "Message type, `text` or `binary`",
+							Optional:            true,
+							Computed:            true,
+							Default:             stringdefault.StaticString("text"),
+						},
+						"data": schema.StringAttribute{
+							MarkdownDescription: "Message payload. Binary payloads are base64 encoded.",
+							Required:            true,
+						},
+					},
+				},
+			},
+			"expect_messages": schema.ListNestedAttribute{
+				MarkdownDescription: "Messages that must be received, in order, before the timeout. Each entry is matched by the first following message that satisfies all of its criteria.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"contains": schema.StringAttribute{
+							MarkdownDescription: "Literal string the message must contain",
+							Optional:            true,
+						},
+						"regex": schema.StringAttribute{
+							MarkdownDescription: "Regular expression the message must match",
+							Optional:            true,
+						},
+						"json_path": schema.MapAttribute{
+							MarkdownDescription: "Expected values keyed by JSONPath evaluated against the message decoded as JSON",
+							Optional:            true,
+							ElementType:         types.StringType,
+						},
+					},
+				},
+			},
+			"expect_subprotocol": schema.StringAttribute{
+				MarkdownDescription: "Subprotocol the server is expected to select",
+				Optional:            true,
+			},
+			"timeout": schema.Int64Attribute{
+				MarkdownDescription: "Timeout in seconds for the handshake and for receiving the expected messages",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of retries for the handshake",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+			"retry_delay": schema.Int64Attribute{
+				MarkdownDescription: "Delay between retries in seconds",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(0), // 0 means use provider default
+			},
+
+			// Results - these are computed values based on the last test run
+			"last_run": schema.StringAttribute{
+				MarkdownDescription: "Timestamp of the last test run",
+				Computed:            true,
+			},
+			"last_status_code": schema.Int64Attribute{
+				MarkdownDescription: "HTTP status code of the handshake response from the last test run",
+				Computed:            true,
+			},
+			"last_subprotocol": schema.StringAttribute{
+				MarkdownDescription: "Subprotocol selected by the server in the last test run",
+				Computed:            true,
+			},
+			"last_handshake_time": schema.Int64Attribute{
+				MarkdownDescription: "Handshake time in milliseconds from the last test run",
+				Computed:            true,
+			},
+			"last_round_trip_time": schema.Int64Attribute{
+				MarkdownDescription: "Time in milliseconds between sending the last message and receiving the next message from the server",
+				Computed:            true,
+			},
+			"last_messages": schema.ListAttribute{
+				MarkdownDescription: "Messages received in the last test run. Binary messages are base64 encoded.",
+				Computed:            true,
+				ElementType:         types.StringType,
+			},
+			"test_passed": schema.BoolAttribute{
+				MarkdownDescription: "Whether the test passed",
+				Computed:            true,
+			},
+			"error": schema.StringAttribute{
+				MarkdownDescription: "Error message if the test failed",
+				Computed:            true,
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Test identifier",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		}, httpTLSSchemaAttributes(), httpAuthSchemaAttributes()),
+	}
+}
+
+func (r *WebsocketTestResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	clientConfig, ok := req.ProviderData.(*TerraProbeClientConfig)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *TerraProbeClientConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.clientConfig = clientConfig
+}
+
+func (r *WebsocketTestResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data WebsocketTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Generate a unique identifier for this test
+	data.Id = types.StringValue(fmt.Sprintf("websocket-test-%s", time.Now().Format("20060102150405")))
+
+	// Run the WebSocket test
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("WebSocket Test Error", err.Error())
+		return
+	}
+
+	// Set the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Write logs
+	tflog.Trace(ctx, "created WebSocket test resource")
+	tflog.Debug(ctx, fmt.Sprintf("WebSocket Test Result: %t - %s", data.TestPassed.ValueBool(), data.URL.ValueString()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *WebsocketTestResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data WebsocketTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the WebSocket test again during Read
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("WebSocket Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *WebsocketTestResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data WebsocketTestResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Run the WebSocket test with updated parameters
+	err := r.runTest(ctx, &data)
+	if err != nil {
+		resp.Diagnostics.AddError("WebSocket Test Error", err.Error())
+		return
+	}
+
+	// Update the last run time
+	data.LastRun = types.StringValue(time.Now().Format(time.RFC3339))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *WebsocketTestResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data WebsocketTestResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Nothing special to do for delete, as this is a stateless resource
+	// The resource will be removed from Terraform state
+}
+
+func (r *WebsocketTestResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// websocketOutgoing is a decoded message ready to be written.
+type websocketOutgoing struct {
+	messageType int
+	data        []byte
+}
+
+// websocketExpectation is a compiled message expectation.
+type websocketExpectation struct {
+	contains string
+	regex    *regexp.Regexp
+	jsonPath map[string]string
+}
+
+// matches reports whether a received message satisfies the expectation.
+func (e websocketExpectation) matches(message []byte) bool {
+	if e.contains != "" && !strings.Contains(string(message), e.contains) {
+		return false
+	}
+
+	if e.regex != nil && !e.regex.Match(message) {
+		return false
+	}
+
+	if len(e.jsonPath) > 0 {
+		var document interface{}
+		if err := json.Unmarshal(message, &document); err != nil {
+			return false
+		}
+		if len(checkJSONPathExpectations(document, e.jsonPath)) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// describe renders the expectation for error messages.
+func (e websocketExpectation) describe() string {
+	var parts []string
+	if e.contains != "" {
+		parts = append(parts, fmt.Sprintf("containing '%s'", e.contains))
+	}
+	if e.regex != nil {
+		parts = append(parts, fmt.Sprintf("matching '%s'", e.regex.String()))
+	}
+	if len(e.jsonPath) > 0 {
+		encoded, err := json.Marshal(e.jsonPath)
+		if err == nil {
+			parts = append(parts, fmt.Sprintf("with JSONPath values %s", encoded))
+		}
+	}
+	if len(parts) == 0 {
+		return "any message"
+	}
+	return "a message " + strings.Join(parts, " and ")
+}
+
+// runTest runs the WebSocket test and updates the resource model with the results.
+func (r *WebsocketTestResource) runTest(ctx context.Context, data *WebsocketTestResourceModel) error {
+	// Get timeout from resource or default from provider
+	timeout := r.clientConfig.HttpClient.Timeout
+	if !data.Timeout.IsNull() && data.Timeout.ValueInt64() > 0 {
+		timeout = time.Duration(data.Timeout.ValueInt64()) * time.Second
+	}
+
+	// Get retries from resource or default from provider
+	retries := r.clientConfig.Retries
+	if !data.Retries.IsNull() && data.Retries.ValueInt64() > 0 {
+		retries = data.Retries.ValueInt64()
+	}
+
+	// Get retry delay from resource or default from provider
+	retryDelay := r.clientConfig.RetryDelay
+	if !data.RetryDelay.IsNull() && data.RetryDelay.ValueInt64() > 0 {
+		retryDelay = time.Duration(data.RetryDelay.ValueInt64()) * time.Second
+	}
+
+	target, err := url.Parse(data.URL.ValueString())
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if target.Scheme != "ws" && target.Scheme != "wss" {
+		return fmt.Errorf("url must use the ws or wss scheme, got %q", target.Scheme)
+	}
+
+	// Build the handshake headers
+	request := &http.Request{Header: make(http.Header)}
+	request.Header.Set("User-Agent", r.clientConfig.UserAgent)
+	if !data.Headers.IsNull() {
+		headers := make(map[string]string)
+		if diags := data.Headers.ElementsAs(ctx, &headers, false); diags.HasError() {
+			return fmt.Errorf("failed to read headers")
+		}
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+	}
+	if err := applyHttpAuth(ctx, request, data.BearerToken, data.BasicAuth); err != nil {
+		return err
+	}
+
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: timeout,
+		TLSClientConfig:  &tls.Config{},
+	}
+	tlsOptions := httpTLSOptionsFromModel(data.InsecureSkipVerify, data.CACert, data.ClientCert, data.ClientKey)
+	if err := tlsOptions.applyTo(dialer.TLSClientConfig); err != nil {
+		return err
+	}
+	if !data.Subprotocols.IsNull() {
+		if diags := data.Subprotocols.ElementsAs(ctx, &dialer.Subprotocols, false); diags.HasError() {
+			return fmt.Errorf("failed to read subprotocols")
+		}
+	}
+
+	outgoing, err := websocketOutgoingMessages(ctx, data.Messages)
+	if err != nil {
+		return err
+	}
+
+	expectations, err := websocketExpectations(ctx, data.ExpectMessages)
+	if err != nil {
+		return err
+	}
+
+	// Perform the handshake with retries
+	var conn *websocket.Conn
+	var resp *http.Response
+	var handshakeTime time.Duration
+
+	for i := int64(0); i <= retries; i++ {
+		start := time.Now()
+		conn, resp, err = dialer.DialContext(ctx, target.String(), request.Header)
+		handshakeTime = time.Since(start)
+
+		if err == nil {
+			break
+		}
+
+		if i < retries {
+			time.Sleep(retryDelay)
+		}
+	}
+
+	// Close the handshake response body
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+
+	// Update the handshake results
+	data.LastStatusCode = types.Int64Value(0)
+	if resp != nil {
+		data.LastStatusCode = types.Int64Value(int64(resp.StatusCode))
+	}
+	data.LastHandshakeTime = types.Int64Value(int64(handshakeTime / time.Millisecond))
+	data.LastRoundTripTime = types.Int64Value(0)
+	data.LastSubprotocol = types.StringValue("")
+	data.LastMessages = types.ListValueMust(types.StringType, []attr.Value{})
+
+	if err != nil {
+		data.Error = types.StringValue(fmt.Sprintf("Handshake failed: %s", err.Error()))
+		data.TestPassed = types.BoolValue(false)
+		return nil // Don't return error as we want to keep the error in the state
+	}
+	defer func() { _ = conn.Close() }()
+
+	data.LastSubprotocol = types.StringValue(conn.Subprotocol())
+
+	// Check if the test passed
+	passed := true
+	var errorMsg strings.Builder
+
+	if !data.ExpectSubprotocol.IsNull() && conn.Subprotocol() != data.ExpectSubprotocol.ValueString() {
+		passed = false
+		errorMsg.WriteString(fmt.Sprintf("Expected subprotocol '%s' but got '%s'. ", data.ExpectSubprotocol.ValueString(), conn.Subprotocol()))
+	}
+
+	// Send the messages in order
+	deadline := time.Now().Add(timeout)
+	_ = conn.SetWriteDeadline(deadline)
+	var lastSent time.Time
+	for i, message := range outgoing {
+		if err := conn.WriteMessage(message.messageType, message.data); err != nil {
+			passed = false
+			errorMsg.WriteString(fmt.Sprintf("Failed to send message %d: %s. ", i, err.Error()))
+			break
+		}
+		lastSent = time.Now()
+	}
+
+	// Receive messages until every expectation is met or the timeout expires
+	var received []attr.Value
+	var roundTripTime time.Duration
+	next := 0
+	_ = conn.SetReadDeadline(deadline)
+	for next < len(expectations) {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				errorMsg.WriteString(fmt.Sprintf("Timed out waiting for %s. ", expectations[next].describe()))
+			} else {
+				errorMsg.WriteString(fmt.Sprintf("Connection closed while waiting for %s: %s. ", expectations[next].describe(), err.Error()))
+			}
+			passed = false
+			break
+		}
+
+		if roundTripTime == 0 && !lastSent.IsZero() {
+			roundTripTime = time.Since(lastSent)
+		}
+
+		if messageType == websocket.BinaryMessage {
+			received = append(received, types.StringValue(base64.StdEncoding.EncodeToString(message)))
+		} else {
+			received = append(received, types.StringValue(string(message)))
+		}
+
+		if expectations[next].matches(message) {
+			next++
+		}
+	}
+
+	// Close the connection gracefully
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+
+	data.LastRoundTripTime = types.Int64Value(int64(roundTripTime / time.Millisecond))
+	if received != nil {
+		data.LastMessages = types.ListValueMust(types.StringType, received)
+	}
+
+	// Set the test result
+	data.TestPassed = types.BoolValue(passed)
+
+	// Set error message if test failed
+	if !passed {
+		data.Error = types.StringValue(errorMsg.String())
+	} else {
+		data.Error = types.StringValue("")
+	}
+
+	return nil
+}
+
+// websocketOutgoingMessages decodes the configured messages.
+func websocketOutgoingMessages(ctx context.Context, list types.List) ([]websocketOutgoing, error) {
+	if list.IsNull() || list.IsUnknown() {
+		return nil, nil
+	}
+
+	var messages []WebsocketMessageModel
+	if diags := list.ElementsAs(ctx, &messages, false); diags.HasError() {
+		return nil, fmt.Errorf("failed to read messages")
+	}
+
+	outgoing := make([]websocketOutgoing, 0, len(messages))
+	for i, message := range messages {
+		switch message.Type.ValueString() {
+		case "", "text":
+			outgoing = append(outgoing, websocketOutgoing{messageType: websocket.TextMessage, data: []byte(message.Data.ValueString())})
+		case "binary":
+			decoded, err := base64.StdEncoding.DecodeString(message.Data.ValueString())
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: binary data must be base64 encoded: %w", i, err)
+			}
+			outgoing = append(outgoing, websocketOutgoing{messageType: websocket.BinaryMessage, data: decoded})
+		default:
+			return nil, fmt.Errorf("messages[%d]: unsupported type %q", i, message.Type.ValueString())
+		}
+	}
+
+	return outgoing, nil
+}
+
+// websocketExpectations compiles the configured message expectations.
+func websocketExpectations(ctx context.Context, list types.List) ([]websocketExpectation, error) {
+	if list.IsNull() || list.IsUnknown() {
+		return nil, nil
+	}
+
+	var models []WebsocketExpectModel
+	if diags := list.ElementsAs(ctx, &models, false); diags.HasError() {
+		return nil, fmt.Errorf("failed to read expect_messages")
+	}
+
+	expectations := make([]websocketExpectation, 0, len(models))
+	for i, model := range models {
+		expectation := websocketExpectation{contains: model.Contains.ValueString()}
+
+		if !model.Regex.IsNull() && model.Regex.ValueString() != "" {
+			re, err := regexp.Compile(model.Regex.ValueString())
+			if err != nil {
+				return nil, fmt.Errorf("expect_messages[%d]: invalid regex: %w", i, err)
+			}
+			expectation.regex = re
+		}
+
+		if !model.JsonPath.IsNull() {
+			expectation.jsonPath = make(map[string]string)
+			if diags := model.JsonPath.ElementsAs(ctx, &expectation.jsonPath, false); diags.HasError() {
+				return nil, fmt.Errorf("expect_messages[%d]: failed to read json_path", i)
+			}
+		}
+
+		expectations = append(expectations, expectation)
+	}
+
+	return expectations, nil
+}
@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TestWebsocketTestResource_runTest tests the WebSocket test resource's runTest function.
+func TestWebsocketTestResource_runTest(t *testing.T) {
+	// Echo server that greets every client and requires a bearer token
+	upgrader := websocket.Upgrader{Subprotocols: []string{"graphql-ws", "chat"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"welcome","version":2}`))
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(messageType, append([]byte("echo:"), message...))
+		}
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	resource := &WebsocketTestResource{
+		clientConfig: &TerraProbeClientConfig{
+			HttpClient: &http.Client{Timeout: 5 * time.Second},
+			UserAgent:  "TerraProbe-Test",
+			Retries:    0,
+			RetryDelay: time.Second,
+		},
+	}
+
+	ctx := context.Background()
+
+	messageType := types.ObjectType{AttrTypes: map[string]attr.Type{
+		"type": types.StringType,
+		"data": types.StringType,
+	}}
+	expectType := types.ObjectType{AttrTypes: map[string]attr.Type{
+		"contains":  types.StringType,
+		"regex":     types.StringType,
+		"json_path": types.MapType{ElemType: types.StringType},
+	}}
+
+	newModel := func(name string) *WebsocketTestResourceModel {
+		return &WebsocketTestResourceModel{
+			Name:           types.StringValue(name),
+			URL:            types.StringValue(wsURL),
+			Headers:        types.MapNull(types.StringType),
+			Subprotocols:   types.ListNull(types.StringType),
+			Messages:       types.ListNull(messageType),
+			ExpectMessages: types.ListNull(expectType),
+			Timeout:        types.Int64Value(2),
+			Retries:        types.Int64Value(0),
+			RetryDelay:     types.Int64Value(1),
+			BearerToken:    types.StringValue("secret"),
+			BasicAuth:      types.ObjectNull(map[string]attr.Type{"username": types.StringType, "password": types.StringType}),
+		}
+	}
+
+	newExpect := func(contains, regex string, jsonPath map[string]string) attr.Value {
+		values := map[string]attr.Value{
+			"contains":  types.StringNull(),
+			"regex":     types.StringNull(),
+			"json_path": types.MapNull(types.StringType),
+		}
+		if contains != "" {
+			values["contains"] = types.StringValue(contains)
+		}
+		if regex != "" {
+			values["regex"] = types.StringValue(regex)
+		}
+		if jsonPath != nil {
+			elements := make(map[string]attr.Value)
+			for k, v := range jsonPath {
+				elements[k] = types.StringValue(v)
+			}
+			values["json_path"] = types.MapValueMust(types.StringType, elements)
+		}
+		return types.ObjectValueMust(expectType.AttrTypes, values)
+	}
+
+	t.Run("messages and expectations", func(t *testing.T) {
+		data := newModel("echo")
+		data.Subprotocols = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("chat")})
+		data.ExpectSubprotocol = types.StringValue("chat")
+		data.Messages = types.ListValueMust(messageType, []attr.Value{
+			types.ObjectValueMust(messageType.AttrTypes, map[string]attr.Value{
+				"type": types.StringValue("text"),
+				"data": types.StringValue("ping"),
+			}),
+			types.ObjectValueMust(messageType.AttrTypes, map[string]attr.Value{
+				"type": types.StringValue("binary"),
+				"data": types.StringValue("AAEC"), // 0x00 0x01 0x02
+			}),
+		})
+		data.ExpectMessages = types.ListValueMust(expectType, []attr.Value{
+			newExpect("", "", map[string]string{"$.type": "welcome", "$.version": "2"}),
+			newExpect("echo:ping", "", nil),
+			newExpect("", "^echo:\x00\x01\x02$", nil),
+		})
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if !data.TestPassed.ValueBool() {
+			t.Errorf("Expected test to pass, got error: %s", data.Error.ValueString())
+		}
+		if data.LastStatusCode.ValueInt64() != http.StatusSwitchingProtocols {
+			t.Errorf("Expected status 101, got %d", data.LastStatusCode.ValueInt64())
+		}
+		if data.LastSubprotocol.ValueString() != "chat" {
+			t.Errorf("Expected subprotocol chat, got %s", data.LastSubprotocol.ValueString())
+		}
+		if len(data.LastMessages.Elements()) != 3 {
+			t.Errorf("Expected 3 received messages, got %d", len(data.LastMessages.Elements()))
+		}
+	})
+
+	t.Run("expectation timeout", func(t *testing.T) {
+		data := newModel("timeout")
+		data.Timeout = types.Int64Value(1)
+		data.ExpectMessages = types.ListValueMust(expectType, []attr.Value{
+			newExpect("never sent", "", nil),
+		})
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if data.TestPassed.ValueBool() {
+			t.Error("Expected test to fail")
+		}
+		if !strings.Contains(data.Error.ValueString(), "Timed out") {
+			t.Errorf("Expected timeout error, got: %s", data.Error.ValueString())
+		}
+	})
+
+	t.Run("rejected handshake", func(t *testing.T) {
+		data := newModel("unauthorized")
+		data.BearerToken = types.StringNull()
+
+		if err := resource.runTest(ctx, data); err != nil {
+			t.Fatalf("runTest failed: %v", err)
+		}
+		if data.TestPassed.ValueBool() {
+			t.Error("Expected test to fail")
+		}
+		if data.LastStatusCode.ValueInt64() != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", data.LastStatusCode.ValueInt64())
+		}
+	})
+
+	t.Run("invalid scheme", func(t *testing.T) {
+		data := newModel("invalid")
+		data.URL = types.StringValue(server.URL)
+
+		if err := resource.runTest(ctx, data); err == nil {
+			t.Error("Expected an error for a non-WebSocket URL")
+		}
+	})
+}